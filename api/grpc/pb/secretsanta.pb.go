@@ -0,0 +1,1048 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: api/grpc/secretsanta.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// DrawStage is a step of a draw
+type DrawStage int32
+
+const (
+	DrawStage_DRAW_STAGE_UNSPECIFIED          DrawStage = 0
+	DrawStage_DRAW_STAGE_VALIDATING           DrawStage = 1
+	DrawStage_DRAW_STAGE_DRAWING              DrawStage = 2
+	DrawStage_DRAW_STAGE_SENDING              DrawStage = 3
+	DrawStage_DRAW_STAGE_SCHEDULING           DrawStage = 4
+	DrawStage_DRAW_STAGE_SCHEDULING_REMINDERS DrawStage = 5
+	DrawStage_DRAW_STAGE_COMPLETE             DrawStage = 6
+)
+
+// Enum value maps for DrawStage.
+var (
+	DrawStage_name = map[int32]string{
+		0: "DRAW_STAGE_UNSPECIFIED",
+		1: "DRAW_STAGE_VALIDATING",
+		2: "DRAW_STAGE_DRAWING",
+		3: "DRAW_STAGE_SENDING",
+		4: "DRAW_STAGE_SCHEDULING",
+		5: "DRAW_STAGE_SCHEDULING_REMINDERS",
+		6: "DRAW_STAGE_COMPLETE",
+	}
+	DrawStage_value = map[string]int32{
+		"DRAW_STAGE_UNSPECIFIED":          0,
+		"DRAW_STAGE_VALIDATING":           1,
+		"DRAW_STAGE_DRAWING":              2,
+		"DRAW_STAGE_SENDING":              3,
+		"DRAW_STAGE_SCHEDULING":           4,
+		"DRAW_STAGE_SCHEDULING_REMINDERS": 5,
+		"DRAW_STAGE_COMPLETE":             6,
+	}
+)
+
+func (x DrawStage) Enum() *DrawStage {
+	p := new(DrawStage)
+	*p = x
+	return p
+}
+
+func (x DrawStage) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DrawStage) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_grpc_secretsanta_proto_enumTypes[0].Descriptor()
+}
+
+func (DrawStage) Type() protoreflect.EnumType {
+	return &file_api_grpc_secretsanta_proto_enumTypes[0]
+}
+
+func (x DrawStage) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DrawStage.Descriptor instead.
+func (DrawStage) EnumDescriptor() ([]byte, []int) {
+	return file_api_grpc_secretsanta_proto_rawDescGZIP(), []int{0}
+}
+
+// Participant is a person taking part in a draw
+type Participant struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Name             string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	NotificationType string                 `protobuf:"bytes,2,opt,name=notification_type,json=notificationType,proto3" json:"notification_type,omitempty"` // e.g. "email" or "email:account"
+	ContactInfo      []string               `protobuf:"bytes,3,rep,name=contact_info,json=contactInfo,proto3" json:"contact_info,omitempty"`
+	Exclusions       []string               `protobuf:"bytes,4,rep,name=exclusions,proto3" json:"exclusions,omitempty"`
+	NoReminders      bool                   `protobuf:"varint,5,opt,name=no_reminders,json=noReminders,proto3" json:"no_reminders,omitempty"`
+	// Set in draw results
+	Recipient      string `protobuf:"bytes,6,opt,name=recipient,proto3" json:"recipient,omitempty"`
+	DeliveredVia   string `protobuf:"bytes,7,opt,name=delivered_via,json=deliveredVia,proto3" json:"delivered_via,omitempty"`
+	NotificationId string `protobuf:"bytes,8,opt,name=notification_id,json=notificationId,proto3" json:"notification_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Participant) Reset() {
+	*x = Participant{}
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Participant) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Participant) ProtoMessage() {}
+
+func (x *Participant) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Participant.ProtoReflect.Descriptor instead.
+func (*Participant) Descriptor() ([]byte, []int) {
+	return file_api_grpc_secretsanta_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Participant) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Participant) GetNotificationType() string {
+	if x != nil {
+		return x.NotificationType
+	}
+	return ""
+}
+
+func (x *Participant) GetContactInfo() []string {
+	if x != nil {
+		return x.ContactInfo
+	}
+	return nil
+}
+
+func (x *Participant) GetExclusions() []string {
+	if x != nil {
+		return x.Exclusions
+	}
+	return nil
+}
+
+func (x *Participant) GetNoReminders() bool {
+	if x != nil {
+		return x.NoReminders
+	}
+	return false
+}
+
+func (x *Participant) GetRecipient() string {
+	if x != nil {
+		return x.Recipient
+	}
+	return ""
+}
+
+func (x *Participant) GetDeliveredVia() string {
+	if x != nil {
+		return x.DeliveredVia
+	}
+	return ""
+}
+
+func (x *Participant) GetNotificationId() string {
+	if x != nil {
+		return x.NotificationId
+	}
+	return ""
+}
+
+// ParticipantFile is participant data in one of the upload formats
+type ParticipantFile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Format        string                 `protobuf:"bytes,1,opt,name=format,proto3" json:"format,omitempty"` // json, yaml, toml, csv or tsv
+	Content       []byte                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParticipantFile) Reset() {
+	*x = ParticipantFile{}
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParticipantFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParticipantFile) ProtoMessage() {}
+
+func (x *ParticipantFile) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParticipantFile.ProtoReflect.Descriptor instead.
+func (*ParticipantFile) Descriptor() ([]byte, []int) {
+	return file_api_grpc_secretsanta_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ParticipantFile) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ParticipantFile) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+// ReminderRule sends a reminder to every giver days_before the exchange
+type ReminderRule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DaysBefore    int32                  `protobuf:"varint,1,opt,name=days_before,json=daysBefore,proto3" json:"days_before,omitempty"`
+	Subject       string                 `protobuf:"bytes,2,opt,name=subject,proto3" json:"subject,omitempty"`   // Go text/template; the default is used when empty
+	Template      string                 `protobuf:"bytes,3,opt,name=template,proto3" json:"template,omitempty"` // Go text/template; the default is used when empty
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReminderRule) Reset() {
+	*x = ReminderRule{}
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReminderRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReminderRule) ProtoMessage() {}
+
+func (x *ReminderRule) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReminderRule.ProtoReflect.Descriptor instead.
+func (*ReminderRule) Descriptor() ([]byte, []int) {
+	return file_api_grpc_secretsanta_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ReminderRule) GetDaysBefore() int32 {
+	if x != nil {
+		return x.DaysBefore
+	}
+	return 0
+}
+
+func (x *ReminderRule) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *ReminderRule) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+// Event describes the gift exchange a draw is for
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	ExchangeDate  *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=exchange_date,json=exchangeDate,proto3" json:"exchange_date,omitempty"`
+	Budget        string                 `protobuf:"bytes,3,opt,name=budget,proto3" json:"budget,omitempty"`
+	Reminders     []*ReminderRule        `protobuf:"bytes,4,rep,name=reminders,proto3" json:"reminders,omitempty"` // defaults to 7 and 1 days before
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_api_grpc_secretsanta_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Event) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Event) GetExchangeDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExchangeDate
+	}
+	return nil
+}
+
+func (x *Event) GetBudget() string {
+	if x != nil {
+		return x.Budget
+	}
+	return ""
+}
+
+func (x *Event) GetReminders() []*ReminderRule {
+	if x != nil {
+		return x.Reminders
+	}
+	return nil
+}
+
+type ValidateParticipantsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Participants are given either as a list or as a file
+	Participants  []*Participant   `protobuf:"bytes,1,rep,name=participants,proto3" json:"participants,omitempty"`
+	File          *ParticipantFile `protobuf:"bytes,2,opt,name=file,proto3" json:"file,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateParticipantsRequest) Reset() {
+	*x = ValidateParticipantsRequest{}
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateParticipantsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateParticipantsRequest) ProtoMessage() {}
+
+func (x *ValidateParticipantsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateParticipantsRequest.ProtoReflect.Descriptor instead.
+func (*ValidateParticipantsRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_secretsanta_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ValidateParticipantsRequest) GetParticipants() []*Participant {
+	if x != nil {
+		return x.Participants
+	}
+	return nil
+}
+
+func (x *ValidateParticipantsRequest) GetFile() *ParticipantFile {
+	if x != nil {
+		return x.File
+	}
+	return nil
+}
+
+type ValidateParticipantsResponse struct {
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	Valid                     bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	Errors                    []string               `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
+	Warnings                  []string               `protobuf:"bytes,3,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	ParticipantsWithNoOptions []string               `protobuf:"bytes,4,rep,name=participants_with_no_options,json=participantsWithNoOptions,proto3" json:"participants_with_no_options,omitempty"`
+	MinCompatibility          int32                  `protobuf:"varint,5,opt,name=min_compatibility,json=minCompatibility,proto3" json:"min_compatibility,omitempty"`
+	AvgCompatibility          float64                `protobuf:"fixed64,6,opt,name=avg_compatibility,json=avgCompatibility,proto3" json:"avg_compatibility,omitempty"`
+	TotalParticipants         int32                  `protobuf:"varint,7,opt,name=total_participants,json=totalParticipants,proto3" json:"total_participants,omitempty"`
+	unknownFields             protoimpl.UnknownFields
+	sizeCache                 protoimpl.SizeCache
+}
+
+func (x *ValidateParticipantsResponse) Reset() {
+	*x = ValidateParticipantsResponse{}
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateParticipantsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateParticipantsResponse) ProtoMessage() {}
+
+func (x *ValidateParticipantsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateParticipantsResponse.ProtoReflect.Descriptor instead.
+func (*ValidateParticipantsResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_secretsanta_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ValidateParticipantsResponse) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+func (x *ValidateParticipantsResponse) GetErrors() []string {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *ValidateParticipantsResponse) GetWarnings() []string {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+func (x *ValidateParticipantsResponse) GetParticipantsWithNoOptions() []string {
+	if x != nil {
+		return x.ParticipantsWithNoOptions
+	}
+	return nil
+}
+
+func (x *ValidateParticipantsResponse) GetMinCompatibility() int32 {
+	if x != nil {
+		return x.MinCompatibility
+	}
+	return 0
+}
+
+func (x *ValidateParticipantsResponse) GetAvgCompatibility() float64 {
+	if x != nil {
+		return x.AvgCompatibility
+	}
+	return 0
+}
+
+func (x *ValidateParticipantsResponse) GetTotalParticipants() int32 {
+	if x != nil {
+		return x.TotalParticipants
+	}
+	return 0
+}
+
+type DrawRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Participants are given either as a list or as a file
+	Participants []*Participant   `protobuf:"bytes,1,rep,name=participants,proto3" json:"participants,omitempty"`
+	File         *ParticipantFile `protobuf:"bytes,2,opt,name=file,proto3" json:"file,omitempty"`
+	ArchiveEmail string           `protobuf:"bytes,3,opt,name=archive_email,json=archiveEmail,proto3" json:"archive_email,omitempty"`
+	// Notifications are held until send_at when set; it must be in the future
+	SendAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=send_at,json=sendAt,proto3" json:"send_at,omitempty"`
+	// Reminders are scheduled for the event when set
+	Event         *Event `protobuf:"bytes,5,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DrawRequest) Reset() {
+	*x = DrawRequest{}
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DrawRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrawRequest) ProtoMessage() {}
+
+func (x *DrawRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrawRequest.ProtoReflect.Descriptor instead.
+func (*DrawRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_secretsanta_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DrawRequest) GetParticipants() []*Participant {
+	if x != nil {
+		return x.Participants
+	}
+	return nil
+}
+
+func (x *DrawRequest) GetFile() *ParticipantFile {
+	if x != nil {
+		return x.File
+	}
+	return nil
+}
+
+func (x *DrawRequest) GetArchiveEmail() string {
+	if x != nil {
+		return x.ArchiveEmail
+	}
+	return ""
+}
+
+func (x *DrawRequest) GetSendAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.SendAt
+	}
+	return nil
+}
+
+func (x *DrawRequest) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+// Draw is the result of a draw
+type Draw struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Id                 string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	CreatedAt          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Participants       []*Participant         `protobuf:"bytes,3,rep,name=participants,proto3" json:"participants,omitempty"`
+	ScheduledFor       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=scheduled_for,json=scheduledFor,proto3" json:"scheduled_for,omitempty"`
+	RemindersScheduled int32                  `protobuf:"varint,5,opt,name=reminders_scheduled,json=remindersScheduled,proto3" json:"reminders_scheduled,omitempty"`
+	// Set when sending failed; the assignments still stand
+	NotificationError string `protobuf:"bytes,6,opt,name=notification_error,json=notificationError,proto3" json:"notification_error,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Draw) Reset() {
+	*x = Draw{}
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Draw) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Draw) ProtoMessage() {}
+
+func (x *Draw) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Draw.ProtoReflect.Descriptor instead.
+func (*Draw) Descriptor() ([]byte, []int) {
+	return file_api_grpc_secretsanta_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Draw) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Draw) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Draw) GetParticipants() []*Participant {
+	if x != nil {
+		return x.Participants
+	}
+	return nil
+}
+
+func (x *Draw) GetScheduledFor() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ScheduledFor
+	}
+	return nil
+}
+
+func (x *Draw) GetRemindersScheduled() int32 {
+	if x != nil {
+		return x.RemindersScheduled
+	}
+	return 0
+}
+
+func (x *Draw) GetNotificationError() string {
+	if x != nil {
+		return x.NotificationError
+	}
+	return ""
+}
+
+type DrawResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Draw          *Draw                  `protobuf:"bytes,1,opt,name=draw,proto3" json:"draw,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DrawResponse) Reset() {
+	*x = DrawResponse{}
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DrawResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrawResponse) ProtoMessage() {}
+
+func (x *DrawResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrawResponse.ProtoReflect.Descriptor instead.
+func (*DrawResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_secretsanta_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DrawResponse) GetDraw() *Draw {
+	if x != nil {
+		return x.Draw
+	}
+	return nil
+}
+
+type GetDrawRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDrawRequest) Reset() {
+	*x = GetDrawRequest{}
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDrawRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDrawRequest) ProtoMessage() {}
+
+func (x *GetDrawRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDrawRequest.ProtoReflect.Descriptor instead.
+func (*GetDrawRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_secretsanta_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetDrawRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetDrawResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Draw          *Draw                  `protobuf:"bytes,1,opt,name=draw,proto3" json:"draw,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetDrawResponse) Reset() {
+	*x = GetDrawResponse{}
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDrawResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDrawResponse) ProtoMessage() {}
+
+func (x *GetDrawResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDrawResponse.ProtoReflect.Descriptor instead.
+func (*GetDrawResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_secretsanta_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetDrawResponse) GetDraw() *Draw {
+	if x != nil {
+		return x.Draw
+	}
+	return nil
+}
+
+type DrawProgress struct {
+	state         protoimpl.MessageState        `protogen:"open.v1"`
+	Stage         DrawStage                     `protobuf:"varint,1,opt,name=stage,proto3,enum=secretsanta.v1.DrawStage" json:"stage,omitempty"`
+	Message       string                        `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Validation    *ValidateParticipantsResponse `protobuf:"bytes,3,opt,name=validation,proto3" json:"validation,omitempty"` // Set after validation
+	Draw          *Draw                         `protobuf:"bytes,4,opt,name=draw,proto3" json:"draw,omitempty"`             // Set when complete
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DrawProgress) Reset() {
+	*x = DrawProgress{}
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DrawProgress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrawProgress) ProtoMessage() {}
+
+func (x *DrawProgress) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_secretsanta_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrawProgress.ProtoReflect.Descriptor instead.
+func (*DrawProgress) Descriptor() ([]byte, []int) {
+	return file_api_grpc_secretsanta_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DrawProgress) GetStage() DrawStage {
+	if x != nil {
+		return x.Stage
+	}
+	return DrawStage_DRAW_STAGE_UNSPECIFIED
+}
+
+func (x *DrawProgress) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *DrawProgress) GetValidation() *ValidateParticipantsResponse {
+	if x != nil {
+		return x.Validation
+	}
+	return nil
+}
+
+func (x *DrawProgress) GetDraw() *Draw {
+	if x != nil {
+		return x.Draw
+	}
+	return nil
+}
+
+var File_api_grpc_secretsanta_proto protoreflect.FileDescriptor
+
+const file_api_grpc_secretsanta_proto_rawDesc = "" +
+	"\n" +
+	"\x1aapi/grpc/secretsanta.proto\x12\x0esecretsanta.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa0\x02\n" +
+	"\vParticipant\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12+\n" +
+	"\x11notification_type\x18\x02 \x01(\tR\x10notificationType\x12!\n" +
+	"\fcontact_info\x18\x03 \x03(\tR\vcontactInfo\x12\x1e\n" +
+	"\n" +
+	"exclusions\x18\x04 \x03(\tR\n" +
+	"exclusions\x12!\n" +
+	"\fno_reminders\x18\x05 \x01(\bR\vnoReminders\x12\x1c\n" +
+	"\trecipient\x18\x06 \x01(\tR\trecipient\x12#\n" +
+	"\rdelivered_via\x18\a \x01(\tR\fdeliveredVia\x12'\n" +
+	"\x0fnotification_id\x18\b \x01(\tR\x0enotificationId\"C\n" +
+	"\x0fParticipantFile\x12\x16\n" +
+	"\x06format\x18\x01 \x01(\tR\x06format\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent\"e\n" +
+	"\fReminderRule\x12\x1f\n" +
+	"\vdays_before\x18\x01 \x01(\x05R\n" +
+	"daysBefore\x12\x18\n" +
+	"\asubject\x18\x02 \x01(\tR\asubject\x12\x1a\n" +
+	"\btemplate\x18\x03 \x01(\tR\btemplate\"\xb0\x01\n" +
+	"\x05Event\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12?\n" +
+	"\rexchange_date\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\fexchangeDate\x12\x16\n" +
+	"\x06budget\x18\x03 \x01(\tR\x06budget\x12:\n" +
+	"\treminders\x18\x04 \x03(\v2\x1c.secretsanta.v1.ReminderRuleR\treminders\"\x93\x01\n" +
+	"\x1bValidateParticipantsRequest\x12?\n" +
+	"\fparticipants\x18\x01 \x03(\v2\x1b.secretsanta.v1.ParticipantR\fparticipants\x123\n" +
+	"\x04file\x18\x02 \x01(\v2\x1f.secretsanta.v1.ParticipantFileR\x04file\"\xb2\x02\n" +
+	"\x1cValidateParticipantsResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x12\x16\n" +
+	"\x06errors\x18\x02 \x03(\tR\x06errors\x12\x1a\n" +
+	"\bwarnings\x18\x03 \x03(\tR\bwarnings\x12?\n" +
+	"\x1cparticipants_with_no_options\x18\x04 \x03(\tR\x19participantsWithNoOptions\x12+\n" +
+	"\x11min_compatibility\x18\x05 \x01(\x05R\x10minCompatibility\x12+\n" +
+	"\x11avg_compatibility\x18\x06 \x01(\x01R\x10avgCompatibility\x12-\n" +
+	"\x12total_participants\x18\a \x01(\x05R\x11totalParticipants\"\x8a\x02\n" +
+	"\vDrawRequest\x12?\n" +
+	"\fparticipants\x18\x01 \x03(\v2\x1b.secretsanta.v1.ParticipantR\fparticipants\x123\n" +
+	"\x04file\x18\x02 \x01(\v2\x1f.secretsanta.v1.ParticipantFileR\x04file\x12#\n" +
+	"\rarchive_email\x18\x03 \x01(\tR\farchiveEmail\x123\n" +
+	"\asend_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x06sendAt\x12+\n" +
+	"\x05event\x18\x05 \x01(\v2\x15.secretsanta.v1.EventR\x05event\"\xb3\x02\n" +
+	"\x04Draw\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x129\n" +
+	"\n" +
+	"created_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12?\n" +
+	"\fparticipants\x18\x03 \x03(\v2\x1b.secretsanta.v1.ParticipantR\fparticipants\x12?\n" +
+	"\rscheduled_for\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\fscheduledFor\x12/\n" +
+	"\x13reminders_scheduled\x18\x05 \x01(\x05R\x12remindersScheduled\x12-\n" +
+	"\x12notification_error\x18\x06 \x01(\tR\x11notificationError\"8\n" +
+	"\fDrawResponse\x12(\n" +
+	"\x04draw\x18\x01 \x01(\v2\x14.secretsanta.v1.DrawR\x04draw\" \n" +
+	"\x0eGetDrawRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\";\n" +
+	"\x0fGetDrawResponse\x12(\n" +
+	"\x04draw\x18\x01 \x01(\v2\x14.secretsanta.v1.DrawR\x04draw\"\xd1\x01\n" +
+	"\fDrawProgress\x12/\n" +
+	"\x05stage\x18\x01 \x01(\x0e2\x19.secretsanta.v1.DrawStageR\x05stage\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12L\n" +
+	"\n" +
+	"validation\x18\x03 \x01(\v2,.secretsanta.v1.ValidateParticipantsResponseR\n" +
+	"validation\x12(\n" +
+	"\x04draw\x18\x04 \x01(\v2\x14.secretsanta.v1.DrawR\x04draw*\xcb\x01\n" +
+	"\tDrawStage\x12\x1a\n" +
+	"\x16DRAW_STAGE_UNSPECIFIED\x10\x00\x12\x19\n" +
+	"\x15DRAW_STAGE_VALIDATING\x10\x01\x12\x16\n" +
+	"\x12DRAW_STAGE_DRAWING\x10\x02\x12\x16\n" +
+	"\x12DRAW_STAGE_SENDING\x10\x03\x12\x19\n" +
+	"\x15DRAW_STAGE_SCHEDULING\x10\x04\x12#\n" +
+	"\x1fDRAW_STAGE_SCHEDULING_REMINDERS\x10\x05\x12\x17\n" +
+	"\x13DRAW_STAGE_COMPLETE\x10\x062\xe9\x02\n" +
+	"\x12SecretSantaService\x12q\n" +
+	"\x14ValidateParticipants\x12+.secretsanta.v1.ValidateParticipantsRequest\x1a,.secretsanta.v1.ValidateParticipantsResponse\x12A\n" +
+	"\x04Draw\x12\x1b.secretsanta.v1.DrawRequest\x1a\x1c.secretsanta.v1.DrawResponse\x12J\n" +
+	"\aGetDraw\x12\x1e.secretsanta.v1.GetDrawRequest\x1a\x1f.secretsanta.v1.GetDrawResponse\x12Q\n" +
+	"\x12StreamDrawProgress\x12\x1b.secretsanta.v1.DrawRequest\x1a\x1c.secretsanta.v1.DrawProgress0\x01B,Z*github.com/igodwin/secretsanta/api/grpc/pbb\x06proto3"
+
+var (
+	file_api_grpc_secretsanta_proto_rawDescOnce sync.Once
+	file_api_grpc_secretsanta_proto_rawDescData []byte
+)
+
+func file_api_grpc_secretsanta_proto_rawDescGZIP() []byte {
+	file_api_grpc_secretsanta_proto_rawDescOnce.Do(func() {
+		file_api_grpc_secretsanta_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_grpc_secretsanta_proto_rawDesc), len(file_api_grpc_secretsanta_proto_rawDesc)))
+	})
+	return file_api_grpc_secretsanta_proto_rawDescData
+}
+
+var file_api_grpc_secretsanta_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_api_grpc_secretsanta_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_api_grpc_secretsanta_proto_goTypes = []any{
+	(DrawStage)(0),                       // 0: secretsanta.v1.DrawStage
+	(*Participant)(nil),                  // 1: secretsanta.v1.Participant
+	(*ParticipantFile)(nil),              // 2: secretsanta.v1.ParticipantFile
+	(*ReminderRule)(nil),                 // 3: secretsanta.v1.ReminderRule
+	(*Event)(nil),                        // 4: secretsanta.v1.Event
+	(*ValidateParticipantsRequest)(nil),  // 5: secretsanta.v1.ValidateParticipantsRequest
+	(*ValidateParticipantsResponse)(nil), // 6: secretsanta.v1.ValidateParticipantsResponse
+	(*DrawRequest)(nil),                  // 7: secretsanta.v1.DrawRequest
+	(*Draw)(nil),                         // 8: secretsanta.v1.Draw
+	(*DrawResponse)(nil),                 // 9: secretsanta.v1.DrawResponse
+	(*GetDrawRequest)(nil),               // 10: secretsanta.v1.GetDrawRequest
+	(*GetDrawResponse)(nil),              // 11: secretsanta.v1.GetDrawResponse
+	(*DrawProgress)(nil),                 // 12: secretsanta.v1.DrawProgress
+	(*timestamppb.Timestamp)(nil),        // 13: google.protobuf.Timestamp
+}
+var file_api_grpc_secretsanta_proto_depIdxs = []int32{
+	13, // 0: secretsanta.v1.Event.exchange_date:type_name -> google.protobuf.Timestamp
+	3,  // 1: secretsanta.v1.Event.reminders:type_name -> secretsanta.v1.ReminderRule
+	1,  // 2: secretsanta.v1.ValidateParticipantsRequest.participants:type_name -> secretsanta.v1.Participant
+	2,  // 3: secretsanta.v1.ValidateParticipantsRequest.file:type_name -> secretsanta.v1.ParticipantFile
+	1,  // 4: secretsanta.v1.DrawRequest.participants:type_name -> secretsanta.v1.Participant
+	2,  // 5: secretsanta.v1.DrawRequest.file:type_name -> secretsanta.v1.ParticipantFile
+	13, // 6: secretsanta.v1.DrawRequest.send_at:type_name -> google.protobuf.Timestamp
+	4,  // 7: secretsanta.v1.DrawRequest.event:type_name -> secretsanta.v1.Event
+	13, // 8: secretsanta.v1.Draw.created_at:type_name -> google.protobuf.Timestamp
+	1,  // 9: secretsanta.v1.Draw.participants:type_name -> secretsanta.v1.Participant
+	13, // 10: secretsanta.v1.Draw.scheduled_for:type_name -> google.protobuf.Timestamp
+	8,  // 11: secretsanta.v1.DrawResponse.draw:type_name -> secretsanta.v1.Draw
+	8,  // 12: secretsanta.v1.GetDrawResponse.draw:type_name -> secretsanta.v1.Draw
+	0,  // 13: secretsanta.v1.DrawProgress.stage:type_name -> secretsanta.v1.DrawStage
+	6,  // 14: secretsanta.v1.DrawProgress.validation:type_name -> secretsanta.v1.ValidateParticipantsResponse
+	8,  // 15: secretsanta.v1.DrawProgress.draw:type_name -> secretsanta.v1.Draw
+	5,  // 16: secretsanta.v1.SecretSantaService.ValidateParticipants:input_type -> secretsanta.v1.ValidateParticipantsRequest
+	7,  // 17: secretsanta.v1.SecretSantaService.Draw:input_type -> secretsanta.v1.DrawRequest
+	10, // 18: secretsanta.v1.SecretSantaService.GetDraw:input_type -> secretsanta.v1.GetDrawRequest
+	7,  // 19: secretsanta.v1.SecretSantaService.StreamDrawProgress:input_type -> secretsanta.v1.DrawRequest
+	6,  // 20: secretsanta.v1.SecretSantaService.ValidateParticipants:output_type -> secretsanta.v1.ValidateParticipantsResponse
+	9,  // 21: secretsanta.v1.SecretSantaService.Draw:output_type -> secretsanta.v1.DrawResponse
+	11, // 22: secretsanta.v1.SecretSantaService.GetDraw:output_type -> secretsanta.v1.GetDrawResponse
+	12, // 23: secretsanta.v1.SecretSantaService.StreamDrawProgress:output_type -> secretsanta.v1.DrawProgress
+	20, // [20:24] is the sub-list for method output_type
+	16, // [16:20] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_api_grpc_secretsanta_proto_init() }
+func file_api_grpc_secretsanta_proto_init() {
+	if File_api_grpc_secretsanta_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_grpc_secretsanta_proto_rawDesc), len(file_api_grpc_secretsanta_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_grpc_secretsanta_proto_goTypes,
+		DependencyIndexes: file_api_grpc_secretsanta_proto_depIdxs,
+		EnumInfos:         file_api_grpc_secretsanta_proto_enumTypes,
+		MessageInfos:      file_api_grpc_secretsanta_proto_msgTypes,
+	}.Build()
+	File_api_grpc_secretsanta_proto = out.File
+	file_api_grpc_secretsanta_proto_goTypes = nil
+	file_api_grpc_secretsanta_proto_depIdxs = nil
+}
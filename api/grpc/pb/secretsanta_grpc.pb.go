@@ -0,0 +1,257 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: api/grpc/secretsanta.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SecretSantaService_ValidateParticipants_FullMethodName = "/secretsanta.v1.SecretSantaService/ValidateParticipants"
+	SecretSantaService_Draw_FullMethodName                 = "/secretsanta.v1.SecretSantaService/Draw"
+	SecretSantaService_GetDraw_FullMethodName              = "/secretsanta.v1.SecretSantaService/GetDraw"
+	SecretSantaService_StreamDrawProgress_FullMethodName   = "/secretsanta.v1.SecretSantaService/StreamDrawProgress"
+)
+
+// SecretSantaServiceClient is the client API for SecretSantaService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// SecretSantaService runs draws for other services. Invalid requests fail
+// with INVALID_ARGUMENT and a google.rpc.BadRequest detail listing each
+// problem by field.
+type SecretSantaServiceClient interface {
+	// ValidateParticipants checks whether a draw is possible without performing it
+	ValidateParticipants(ctx context.Context, in *ValidateParticipantsRequest, opts ...grpc.CallOption) (*ValidateParticipantsResponse, error)
+	// Draw assigns recipients and sends or schedules the notifications
+	Draw(ctx context.Context, in *DrawRequest, opts ...grpc.CallOption) (*DrawResponse, error)
+	// GetDraw returns a draw made by this service
+	GetDraw(ctx context.Context, in *GetDrawRequest, opts ...grpc.CallOption) (*GetDrawResponse, error)
+	// StreamDrawProgress performs a draw, reporting each stage as it starts.
+	// The last message has stage DRAW_STAGE_COMPLETE and carries the draw.
+	StreamDrawProgress(ctx context.Context, in *DrawRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DrawProgress], error)
+}
+
+type secretSantaServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSecretSantaServiceClient(cc grpc.ClientConnInterface) SecretSantaServiceClient {
+	return &secretSantaServiceClient{cc}
+}
+
+func (c *secretSantaServiceClient) ValidateParticipants(ctx context.Context, in *ValidateParticipantsRequest, opts ...grpc.CallOption) (*ValidateParticipantsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateParticipantsResponse)
+	err := c.cc.Invoke(ctx, SecretSantaService_ValidateParticipants_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretSantaServiceClient) Draw(ctx context.Context, in *DrawRequest, opts ...grpc.CallOption) (*DrawResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DrawResponse)
+	err := c.cc.Invoke(ctx, SecretSantaService_Draw_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretSantaServiceClient) GetDraw(ctx context.Context, in *GetDrawRequest, opts ...grpc.CallOption) (*GetDrawResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDrawResponse)
+	err := c.cc.Invoke(ctx, SecretSantaService_GetDraw_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *secretSantaServiceClient) StreamDrawProgress(ctx context.Context, in *DrawRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DrawProgress], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SecretSantaService_ServiceDesc.Streams[0], SecretSantaService_StreamDrawProgress_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DrawRequest, DrawProgress]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SecretSantaService_StreamDrawProgressClient = grpc.ServerStreamingClient[DrawProgress]
+
+// SecretSantaServiceServer is the server API for SecretSantaService service.
+// All implementations must embed UnimplementedSecretSantaServiceServer
+// for forward compatibility.
+//
+// SecretSantaService runs draws for other services. Invalid requests fail
+// with INVALID_ARGUMENT and a google.rpc.BadRequest detail listing each
+// problem by field.
+type SecretSantaServiceServer interface {
+	// ValidateParticipants checks whether a draw is possible without performing it
+	ValidateParticipants(context.Context, *ValidateParticipantsRequest) (*ValidateParticipantsResponse, error)
+	// Draw assigns recipients and sends or schedules the notifications
+	Draw(context.Context, *DrawRequest) (*DrawResponse, error)
+	// GetDraw returns a draw made by this service
+	GetDraw(context.Context, *GetDrawRequest) (*GetDrawResponse, error)
+	// StreamDrawProgress performs a draw, reporting each stage as it starts.
+	// The last message has stage DRAW_STAGE_COMPLETE and carries the draw.
+	StreamDrawProgress(*DrawRequest, grpc.ServerStreamingServer[DrawProgress]) error
+	mustEmbedUnimplementedSecretSantaServiceServer()
+}
+
+// UnimplementedSecretSantaServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSecretSantaServiceServer struct{}
+
+func (UnimplementedSecretSantaServiceServer) ValidateParticipants(context.Context, *ValidateParticipantsRequest) (*ValidateParticipantsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateParticipants not implemented")
+}
+func (UnimplementedSecretSantaServiceServer) Draw(context.Context, *DrawRequest) (*DrawResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Draw not implemented")
+}
+func (UnimplementedSecretSantaServiceServer) GetDraw(context.Context, *GetDrawRequest) (*GetDrawResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDraw not implemented")
+}
+func (UnimplementedSecretSantaServiceServer) StreamDrawProgress(*DrawRequest, grpc.ServerStreamingServer[DrawProgress]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDrawProgress not implemented")
+}
+func (UnimplementedSecretSantaServiceServer) mustEmbedUnimplementedSecretSantaServiceServer() {}
+func (UnimplementedSecretSantaServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafeSecretSantaServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SecretSantaServiceServer will
+// result in compilation errors.
+type UnsafeSecretSantaServiceServer interface {
+	mustEmbedUnimplementedSecretSantaServiceServer()
+}
+
+func RegisterSecretSantaServiceServer(s grpc.ServiceRegistrar, srv SecretSantaServiceServer) {
+	// If the following call pancis, it indicates UnimplementedSecretSantaServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SecretSantaService_ServiceDesc, srv)
+}
+
+func _SecretSantaService_ValidateParticipants_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateParticipantsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretSantaServiceServer).ValidateParticipants(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SecretSantaService_ValidateParticipants_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretSantaServiceServer).ValidateParticipants(ctx, req.(*ValidateParticipantsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SecretSantaService_Draw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrawRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretSantaServiceServer).Draw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SecretSantaService_Draw_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretSantaServiceServer).Draw(ctx, req.(*DrawRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SecretSantaService_GetDraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDrawRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SecretSantaServiceServer).GetDraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SecretSantaService_GetDraw_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SecretSantaServiceServer).GetDraw(ctx, req.(*GetDrawRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SecretSantaService_StreamDrawProgress_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DrawRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SecretSantaServiceServer).StreamDrawProgress(m, &grpc.GenericServerStream[DrawRequest, DrawProgress]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SecretSantaService_StreamDrawProgressServer = grpc.ServerStreamingServer[DrawProgress]
+
+// SecretSantaService_ServiceDesc is the grpc.ServiceDesc for SecretSantaService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SecretSantaService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "secretsanta.v1.SecretSantaService",
+	HandlerType: (*SecretSantaServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ValidateParticipants",
+			Handler:    _SecretSantaService_ValidateParticipants_Handler,
+		},
+		{
+			MethodName: "Draw",
+			Handler:    _SecretSantaService_Draw_Handler,
+		},
+		{
+			MethodName: "GetDraw",
+			Handler:    _SecretSantaService_GetDraw_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamDrawProgress",
+			Handler:       _SecretSantaService_StreamDrawProgress_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/grpc/secretsanta.proto",
+}
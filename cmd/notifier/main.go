@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+	"github.com/igodwin/secretsanta/internal/logging"
+	"github.com/igodwin/secretsanta/internal/notifierserver"
+	"github.com/igodwin/secretsanta/pkg/config"
+)
+
+// Build-time variables (set via -ldflags)
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+	BuildTime = "unknown"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:50051", "gRPC listen address")
+	storePath := flag.String("store", "notifier-queue.json", "File to persist notifications to, relative to data_dir; empty keeps them in memory")
+	apiKey := flag.String("api-key", os.Getenv("NOTIFIER_SERVER_API_KEY"), "Bearer token clients must send (default $NOTIFIER_SERVER_API_KEY)")
+	maxRetries := flag.Int("max-retries", 3, "Retries for scheduled notifications that don't set their own")
+	retryDelay := flag.Duration("retry-delay", 30*time.Second, "Delay before the first retry; doubles after each attempt")
+	retention := flag.Duration("retention", 30*24*time.Hour, "How long sent and failed notifications are kept; 0 keeps them forever")
+	tlsCert := flag.String("tls-cert", "", "TLS certificate file")
+	tlsKey := flag.String("tls-key", "", "TLS private key file")
+	clientCA := flag.String("client-ca", "", "CA file for verifying client certificates (enables mutual TLS)")
+	insecure := flag.Bool("insecure", false, "Allow listening on a non-loopback address without -api-key or -client-ca")
+	flag.Parse()
+
+	appConfig := config.GetConfig()
+	if err := logging.Setup(appConfig.Logging, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up logging: %v\n", err)
+		os.Exit(1)
+	}
+	slog.Info("Secret Santa notifier service", "version", Version, "commit", GitCommit, "built", BuildTime)
+	logging.LogConfig(appConfig)
+
+	if err := checkExposure(*addr, *apiKey, *clientCA, *insecure); err != nil {
+		fatal("Refusing to start", err)
+	}
+
+	var store notifierserver.Store
+	if *storePath != "" {
+		store = &notifierserver.FileStore{Path: appConfig.DataPath(*storePath)}
+	}
+	server, err := notifierserver.New(appConfig, store)
+	if err != nil {
+		fatal("Failed to load notification queue", err)
+	}
+	server.MaxRetries = int32(*maxRetries)
+	server.RetryDelay = *retryDelay
+	server.Retention = *retention
+
+	var opts []grpc.ServerOption
+	if *tlsCert != "" {
+		tlsConfig, err := serverTLSConfig(*tlsCert, *tlsKey, *clientCA)
+		if err != nil {
+			fatal("Failed to configure TLS", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	if *apiKey != "" {
+		if *tlsCert == "" {
+			slog.Warn("Clients send the API key without TLS; set -tls-cert and -tls-key")
+		}
+		opts = append(opts, grpc.UnaryInterceptor(notifierserver.APIKeyInterceptor(*apiKey)))
+	}
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fatal("Failed to listen", err)
+	}
+	grpcServer := grpc.NewServer(opts...)
+	pb.RegisterNotifierServiceServer(grpcServer, server)
+
+	server.Start()
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+		slog.Info("Shutting down")
+		grpcServer.GracefulStop()
+	}()
+
+	slog.Info("Notifier service listening", "addr", lis.Addr().String())
+	if err := grpcServer.Serve(lis); err != nil {
+		slog.Error("Server stopped", "error", err)
+	}
+	server.Stop()
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// checkExposure refuses a listen address other hosts can reach unless
+// clients must authenticate with an API key or a client certificate.
+// Otherwise anyone who can reach the port could send messages from the
+// configured accounts to anyone.
+func checkExposure(addr, apiKey, clientCA string, insecure bool) error {
+	if apiKey != "" || clientCA != "" || insecure {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return nil
+	}
+	return fmt.Errorf("%s is reachable from other hosts without authentication; set -api-key or -client-ca, or pass -insecure", addr)
+}
+
+// serverTLSConfig loads the server certificate and, when caFile is set,
+// requires clients to present a certificate signed by it
+func serverTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if caFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in client CA file %s", caFile)
+	}
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
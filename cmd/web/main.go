@@ -1,15 +1,22 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"net"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/igodwin/secretsanta/internal/api"
+	"github.com/igodwin/secretsanta/internal/logging"
+	"github.com/igodwin/secretsanta/internal/tracing"
 	"github.com/igodwin/secretsanta/pkg/config"
 )
 
@@ -20,42 +27,7 @@ var (
 	BuildTime = "unknown"
 )
 
-// Logger wraps the standard logger with level support
-type Logger struct {
-	*log.Logger
-}
-
-func NewLogger(out io.Writer) *Logger {
-	return &Logger{
-		Logger: log.New(out, "", 0),
-	}
-}
-
-func (l *Logger) Info(format string, v ...interface{}) {
-	l.logWithLevel("INFO", format, v...)
-}
-
-func (l *Logger) Error(format string, v ...interface{}) {
-	l.logWithLevel("ERROR", format, v...)
-}
-
-func (l *Logger) Fatal(format string, v ...interface{}) {
-	l.logWithLevel("FATAL", format, v...)
-	os.Exit(1)
-}
-
-func (l *Logger) logWithLevel(level, format string, v ...interface{}) {
-	timestamp := time.Now().UTC().Format("2006-01-02T15:04:05Z")
-	message := fmt.Sprintf(format, v...)
-	l.Logger.Printf("%s [%s] %s", timestamp, level, message)
-}
-
-// Print without log level (for banners, etc.)
-func (l *Logger) Print(msg string) {
-	fmt.Println(msg)
-}
-
-func printBanner(logger *Logger) {
+func printBanner() {
 	banner := strings.Builder{}
 	banner.WriteString("\n====================================\n")
 	banner.WriteString("Secret Santa Web Server\n")
@@ -63,43 +35,143 @@ func printBanner(logger *Logger) {
 	banner.WriteString(fmt.Sprintf("Git Commit: %s\n", GitCommit))
 	banner.WriteString(fmt.Sprintf("Build Time: %s\n", BuildTime))
 	banner.WriteString("====================================\n")
-	logger.Print(banner.String())
+	fmt.Println(banner.String())
 }
 
 func main() {
-	addr := flag.String("addr", ":8080", "HTTP server address")
+	addr := flag.String("addr", "localhost:8080", "HTTP server address")
+	grpcAddr := flag.String("grpc-addr", "", "SecretSantaService gRPC address (disabled when empty)")
+	previewFile := flag.String("preview", "", "Render notifications for a participants file without drawing or sending, then exit")
+	previewDir := flag.String("preview-dir", "previews", "Directory to write -preview messages to as .eml files")
+	staticDir := flag.String("static-dir", "", "Serve the UI from this directory instead of the embedded copy, e.g. internal/web/static while developing")
+	logLevel := flag.String("log-level", "", "Log level: debug, info, warn or error (overrides logging.level)")
+	logFormat := flag.String("log-format", "", "Log format: text or json (overrides logging.format)")
+	testSMTP := flag.Bool("test-smtp", false, "Connect to the SMTP server, negotiate TLS and authenticate, print each step's result and exit")
+	testSMTPTo := flag.String("test-smtp-to", "", "With -test-smtp, also send a test message to this address, which must be the from or archive address")
+	hashPassword := flag.Bool("hash-password", false, "Read a password from stdin, print its bcrypt hash for auth.users and exit")
+	insecure := flag.Bool("insecure", false, "Allow listening on addresses other hosts can reach with authentication disabled")
 	flag.Parse()
 
-	// Create custom logger
-	logger := NewLogger(os.Stdout)
+	if *hashPassword {
+		if err := printPasswordHash(os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to hash password: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Load configuration at startup
+	cfg := config.GetConfig()
+	if *logLevel != "" {
+		cfg.Logging.Level = *logLevel
+	}
+	if *logFormat != "" {
+		cfg.Logging.Format = *logFormat
+	}
+	if err := logging.Setup(cfg.Logging, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up logging: %v\n", err)
+		os.Exit(1)
+	}
+	logging.LogConfig(cfg)
+	// Keep JSON output machine-readable
+	if !strings.EqualFold(cfg.Logging.Format, "json") {
+		printBanner()
+	}
+
+	if *previewFile != "" {
+		if err := runPreview(*previewFile, *previewDir); err != nil {
+			fatal("Preview failed", err)
+		}
+		return
+	}
 
-	// Print startup banner
-	printBanner(logger)
+	if *testSMTP {
+		if err := runSMTPTest(os.Stdout, *testSMTPTo); err != nil {
+			fatal("SMTP test failed", err)
+		}
+		return
+	}
 
-	// Configure global log package to use our logger format
-	log.SetFlags(0)
-	log.SetOutput(&logWriter{logger: logger})
+	for _, listen := range []string{*addr, *grpcAddr} {
+		if err := checkExposure(listen, cfg.Auth.Enabled, *insecure); err != nil {
+			fatal("Refusing to start", err)
+		}
+	}
 
-	// Load configuration at startup
-	_ = config.GetConfig()
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
 
-	logger.Info("Visit http://localhost%s to get started", *addr)
+	scheme := "http"
+	if tlsConfig := cfg.Server.TLS; tlsConfig.CertFile != "" || len(tlsConfig.ACMEDomains) > 0 {
+		scheme = "https"
+	}
+	visit := *addr
+	if strings.HasPrefix(visit, ":") {
+		visit = "localhost" + visit
+	}
+	slog.Info(fmt.Sprintf("Visit %s://%s to get started", scheme, visit))
 
 	server := api.NewServer(*addr)
+	server.GRPCAddr = *grpcAddr
+	server.StaticDir = *staticDir
+
+	// Docker stops containers with SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = server.Start(ctx)
 
-	if err := server.Start(); err != nil {
-		logger.Fatal("Server failed to start: %v", err)
+	// Send the spans still buffered
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
 	}
+
+	if err != nil {
+		fatal("Server failed", err)
+	}
+	slog.Info("Server stopped")
 }
 
-// logWriter adapts our Logger to io.Writer for the global log package
-type logWriter struct {
-	logger *Logger
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
 
-func (w *logWriter) Write(p []byte) (n int, err error) {
-	// Remove trailing newline from log package
-	msg := strings.TrimSuffix(string(p), "\n")
-	w.logger.Info("%s", msg)
-	return len(p), nil
+// checkExposure refuses a listen address other hosts can reach while
+// authentication is disabled, since anyone reaching it could draw and send
+// notifications from the configured accounts. An empty address isn't served.
+func checkExposure(addr string, authEnabled, insecure bool) error {
+	if addr == "" || authEnabled || insecure {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return nil
+	}
+	return fmt.Errorf("%s is reachable from other hosts without authentication; enable auth in the config, or pass -insecure", addr)
+}
+
+// printPasswordHash hashes the first line of in
+func printPasswordHash(in io.Reader) error {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return fmt.Errorf("empty password")
+	}
+	hash, err := api.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	fmt.Println(hash)
+	return nil
 }
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/igodwin/secretsanta/internal/draw"
+	"github.com/igodwin/secretsanta/internal/formats"
+	"github.com/igodwin/secretsanta/internal/logging"
+	"github.com/igodwin/secretsanta/internal/notification"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+)
+
+// runPreview renders every notification for the participants in file and
+// writes them to dir as .eml files, without drawing or sending anything
+func runPreview(file, dir string) error {
+	format, err := formats.DetectFormat(file)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read participants: %w", err)
+	}
+	participants, err := formats.Parse(data, format)
+	if err != nil {
+		return err
+	}
+
+	validation := draw.ValidateParticipants(participants)
+	for _, warning := range validation.Warnings {
+		slog.Warn("Validation warning", "warning", warning)
+	}
+	if !validation.IsValid {
+		return fmt.Errorf("validation failed: %s", strings.Join(validation.Errors, "; "))
+	}
+
+	previews := notification.RenderPreviews(participants, config.GetConfig())
+	for _, preview := range previews {
+		for _, previewErr := range preview.Errors {
+			slog.Error("Preview failed", "participant", logging.PII(preview.Participant), "error", logging.RedactError(errors.New(previewErr), notifier.PersonalData(participants...)...))
+		}
+	}
+
+	paths, err := notification.WritePreviews(dir, previews)
+	if err != nil {
+		return err
+	}
+	slog.Info("Wrote previews", "files", len(paths), "participants", len(previews), "dir", dir)
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/igodwin/secretsanta/internal/notification"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+)
+
+// runSMTPTest checks the SMTP settings step by step, printing each step's
+// result to out, and sends a test message to sendTo when it is set
+func runSMTPTest(out io.Writer, sendTo string) error {
+	steps, err := notification.TestSMTP(context.Background(), config.GetConfig(), sendTo)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range steps {
+		result := "ok"
+		switch {
+		case step.Skipped:
+			result = "skipped"
+		case !step.OK:
+			result = "FAILED"
+		}
+		fmt.Fprintf(out, "%-5s %-8s %s\n", step.Name, result, step.Detail)
+	}
+	if !notifier.ConnectionSucceeded(steps) {
+		return fmt.Errorf("SMTP settings don't work")
+	}
+	return nil
+}
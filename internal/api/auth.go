@@ -0,0 +1,575 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+	"github.com/igodwin/secretsanta/internal/logging"
+	"github.com/igodwin/secretsanta/pkg/config"
+)
+
+// Roles. Viewers can prepare and validate participant lists; drawing and
+// sending notifications needs an organizer.
+const (
+	RoleViewer    = "viewer"
+	RoleOrganizer = "organizer"
+)
+
+const (
+	sessionCookie = "secretsanta_session"
+	oidcCookie    = "secretsanta_oidc"
+	// csrfHeader must echo the session's CSRF token on unsafe requests
+	csrfHeader = "X-CSRF-Token"
+	// oidcLoginTTL bounds how long a provider login may take
+	oidcLoginTTL = 10 * time.Minute
+)
+
+// session is a logged-in user. Sessions are kept in memory, so restarting
+// the server logs everyone out.
+type session struct {
+	username  string
+	role      string
+	csrfToken string
+	expires   time.Time
+}
+
+// oidcLogin is a provider login in progress, keyed by its state parameter
+type oidcLogin struct {
+	nonce    string
+	verifier string
+	expires  time.Time
+}
+
+type oidcProvider struct {
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	config   config.OIDCConfig
+}
+
+// Authenticator checks local accounts and OIDC logins and issues session
+// cookies. A nil *Authenticator leaves every endpoint open.
+type Authenticator struct {
+	users        map[string]config.UserConfig
+	ttl          time.Duration
+	secureCookie bool
+	oidc         *oidcProvider
+	// dummyHash is compared against for unknown users so a login takes
+	// as long whether or not the account exists
+	dummyHash []byte
+
+	mu       sync.Mutex
+	sessions map[string]*session
+	logins   map[string]*oidcLogin
+}
+
+// NewAuthenticator checks cfg and, when an OIDC issuer is set, fetches the
+// provider's discovery document
+func NewAuthenticator(ctx context.Context, cfg config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		users:        make(map[string]config.UserConfig, len(cfg.Users)),
+		ttl:          cfg.SessionTTL,
+		secureCookie: cfg.SecureCookie,
+		sessions:     make(map[string]*session),
+		logins:       make(map[string]*oidcLogin),
+	}
+	if a.ttl <= 0 {
+		a.ttl = 12 * time.Hour
+	}
+
+	for _, user := range cfg.Users {
+		if user.Username == "" {
+			return nil, fmt.Errorf("auth user without a username")
+		}
+		if _, err := bcrypt.Cost([]byte(user.PasswordHash)); err != nil {
+			return nil, fmt.Errorf("auth user %s: password_hash is not a bcrypt hash: %w", user.Username, err)
+		}
+		switch user.Role {
+		case "":
+			user.Role = RoleViewer
+		case RoleViewer, RoleOrganizer:
+		default:
+			return nil, fmt.Errorf("auth user %s: unknown role %q", user.Username, user.Role)
+		}
+		a.users[user.Username] = user
+	}
+
+	dummyHash, err := bcrypt.GenerateFromPassword([]byte("secretsanta"), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	a.dummyHash = dummyHash
+
+	if cfg.OIDC.Issuer != "" {
+		provider, err := oidc.NewProvider(ctx, cfg.OIDC.Issuer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover OIDC issuer %s: %w", cfg.OIDC.Issuer, err)
+		}
+		scopes := cfg.OIDC.Scopes
+		if !slices.Contains(scopes, oidc.ScopeOpenID) {
+			scopes = append([]string{oidc.ScopeOpenID}, scopes...)
+		}
+		a.oidc = &oidcProvider{
+			oauth2: oauth2.Config{
+				ClientID:     cfg.OIDC.ClientID,
+				ClientSecret: cfg.OIDC.ClientSecret,
+				RedirectURL:  cfg.OIDC.RedirectURL,
+				Endpoint:     provider.Endpoint(),
+				Scopes:       scopes,
+			},
+			verifier: provider.Verifier(&oidc.Config{ClientID: cfg.OIDC.ClientID}),
+			config:   cfg.OIDC,
+		}
+	}
+
+	if len(a.users) == 0 && a.oidc == nil {
+		return nil, fmt.Errorf("auth is enabled but no users or OIDC issuer are configured")
+	}
+	return a, nil
+}
+
+// HashPassword returns the bcrypt hash to put in a user's password_hash
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// isLocalUser reports whether username names a local account, ignoring
+// case. Provider logins with such a name are refused, since the two would
+// share rate limits, the daily cap and draw ownership.
+func (a *Authenticator) isLocalUser(username string) bool {
+	for name := range a.users {
+		if strings.EqualFold(name, username) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPassword returns the local account matching username and password
+func (a *Authenticator) checkPassword(username, password string) (config.UserConfig, bool) {
+	user, ok := a.users[username]
+	hash := []byte(user.PasswordHash)
+	if !ok {
+		hash = a.dummyHash
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || !ok {
+		return config.UserConfig{}, false
+	}
+	return user, true
+}
+
+// require wraps next so it needs a session with role. Unsafe methods must
+// also carry the session's CSRF token. An empty role leaves next public.
+func (a *Authenticator) require(role string, next http.HandlerFunc) http.HandlerFunc {
+	if a == nil || role == "" {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		sess := a.session(r)
+		if sess == nil {
+			writeRejection(w, http.StatusUnauthorized, rejectAuthRequired, "Authentication required")
+			return
+		}
+		if !safeMethod(r.Method) && !validCSRFToken(sess, r.Header.Get(csrfHeader)) {
+			writeRejection(w, http.StatusForbidden, rejectInvalidCSRFToken, "Invalid or missing CSRF token")
+			return
+		}
+		if role == RoleOrganizer && sess.role != RoleOrganizer {
+			writeRejection(w, http.StatusForbidden, rejectRoleRequired, "Organizer role required")
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, sess)))
+	}
+}
+
+type sessionKey struct{}
+
+// sessionFromContext returns the session of an authenticated request or
+// SecretSantaService call, or nil
+func sessionFromContext(ctx context.Context) *session {
+	sess, _ := ctx.Value(sessionKey{}).(*session)
+	return sess
+}
+
+func safeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func validCSRFToken(sess *session, token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(sess.csrfToken)) == 1
+}
+
+// session returns the unexpired session named by r's cookie, or nil
+func (a *Authenticator) session(r *http.Request) *session {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	sess, ok := a.sessions[cookie.Value]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(sess.expires) {
+		delete(a.sessions, cookie.Value)
+		return nil
+	}
+	return sess
+}
+
+// startSession stores a new session and sets its cookie
+func (a *Authenticator) startSession(w http.ResponseWriter, username, role string) *session {
+	token, csrfToken := randomToken(), randomToken()
+	sess := &session{username: username, role: role, csrfToken: csrfToken, expires: time.Now().Add(a.ttl)}
+
+	a.mu.Lock()
+	now := time.Now()
+	for key, existing := range a.sessions {
+		if now.After(existing.expires) {
+			delete(a.sessions, key)
+		}
+	}
+	a.sessions[token] = sess
+	a.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  sess.expires,
+		HttpOnly: true,
+		Secure:   a.secureCookie,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return sess
+}
+
+func (a *Authenticator) endSession(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		a.mu.Lock()
+		delete(a.sessions, cookie.Value)
+		a.mu.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   a.secureCookie,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// SessionResponse describes the caller's login. CSRFToken must be sent in
+// the X-CSRF-Token header of every POST.
+type SessionResponse struct {
+	AuthEnabled   bool   `json:"auth_enabled"`
+	OIDCEnabled   bool   `json:"oidc_enabled,omitempty"`
+	Authenticated bool   `json:"authenticated"`
+	Username      string `json:"username,omitempty"`
+	Role          string `json:"role,omitempty"`
+	CSRFToken     string `json:"csrf_token,omitempty"`
+}
+
+// LoginRequest holds local account credentials
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func (a *Authenticator) sessionResponse(sess *session) SessionResponse {
+	if a == nil {
+		return SessionResponse{}
+	}
+	response := SessionResponse{AuthEnabled: true, OIDCEnabled: a.oidc != nil}
+	if sess != nil {
+		response.Authenticated = true
+		response.Username = sess.username
+		response.Role = sess.role
+		response.CSRFToken = sess.csrfToken
+	}
+	return response
+}
+
+// HandleSession reports whether the caller is logged in
+func (s *Server) HandleSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var sess *session
+	if s.Auth != nil {
+		sess = s.Auth.session(r)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Auth.sessionResponse(sess))
+}
+
+// HandleLogin logs in with a local account and sets the session cookie
+func (s *Server) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.Auth == nil {
+		http.Error(w, "Authentication is not enabled", http.StatusNotFound)
+		return
+	}
+
+	var req LoginRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	user, ok := s.Auth.checkPassword(req.Username, req.Password)
+	if !ok {
+		writeRejection(w, http.StatusUnauthorized, rejectInvalidCredentials, "Invalid username or password")
+		return
+	}
+
+	sess := s.Auth.startSession(w, user.Username, user.Role)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Auth.sessionResponse(sess))
+}
+
+// HandleLogout ends the caller's session
+func (s *Server) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.Auth != nil {
+		s.Auth.endSession(w, r)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Auth.sessionResponse(nil))
+}
+
+// HandleOIDCLogin redirects to the OIDC provider's login page
+func (s *Server) HandleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.Auth == nil || s.Auth.oidc == nil {
+		http.Error(w, "OIDC login is not enabled", http.StatusNotFound)
+		return
+	}
+	a := s.Auth
+
+	state := randomToken()
+	login := &oidcLogin{nonce: randomToken(), verifier: oauth2.GenerateVerifier(), expires: time.Now().Add(oidcLoginTTL)}
+	a.mu.Lock()
+	now := time.Now()
+	for key, existing := range a.logins {
+		if now.After(existing.expires) {
+			delete(a.logins, key)
+		}
+	}
+	a.logins[state] = login
+	a.mu.Unlock()
+
+	// The cookie ties the callback to the browser that started the login
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookie,
+		Value:    state,
+		Path:     "/api/auth/oidc/",
+		MaxAge:   int(oidcLoginTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   a.secureCookie,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, a.oidc.oauth2.AuthCodeURL(state, oidc.Nonce(login.nonce), oauth2.S256ChallengeOption(login.verifier)), http.StatusFound)
+}
+
+// HandleOIDCCallback completes a provider login, starts a session and
+// returns to the UI
+func (s *Server) HandleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.Auth == nil || s.Auth.oidc == nil {
+		http.Error(w, "OIDC login is not enabled", http.StatusNotFound)
+		return
+	}
+	a := s.Auth
+
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		http.Error(w, fmt.Sprintf("OIDC login failed: %s %s", providerErr, query.Get("error_description")), http.StatusUnauthorized)
+		return
+	}
+	state := query.Get("state")
+	cookie, err := r.Cookie(oidcCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		http.Error(w, "OIDC login state does not match", http.StatusBadRequest)
+		return
+	}
+	a.mu.Lock()
+	login, ok := a.logins[state]
+	delete(a.logins, state)
+	a.mu.Unlock()
+	if !ok || time.Now().After(login.expires) {
+		http.Error(w, "OIDC login expired, please try again", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oidcCookie, Path: "/api/auth/oidc/", MaxAge: -1})
+
+	username, role, err := a.oidc.exchange(r.Context(), query.Get("code"), login)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("OIDC login failed: %v", err), http.StatusUnauthorized)
+		return
+	}
+	if a.isLocalUser(username) {
+		slog.WarnContext(r.Context(), "OIDC login rejected: username belongs to a local account", "username", logging.PII(username))
+		http.Error(w, "OIDC login failed: the username belongs to a local account", http.StatusForbidden)
+		return
+	}
+	a.startSession(w, username, role)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// exchange redeems code and returns the user named by the verified ID token
+func (p *oidcProvider) exchange(ctx context.Context, code string, login *oidcLogin) (string, string, error) {
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.VerifierOption(login.verifier))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to exchange code: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return "", "", fmt.Errorf("token response has no id_token")
+	}
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid ID token: %w", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(login.nonce)) != 1 {
+		return "", "", fmt.Errorf("ID token nonce does not match")
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return "", "", fmt.Errorf("invalid ID token claims: %w", err)
+	}
+	return p.user(idToken.Subject, claims)
+}
+
+// user maps ID token claims to a username and role. The email is trusted,
+// as the username or to grant the organizer role by address, only when the
+// token says it is verified; a missing email_verified claim doesn't count.
+func (p *oidcProvider) user(subject string, claims map[string]any) (string, string, error) {
+	email, _ := claims["email"].(string)
+	verified, _ := claims["email_verified"].(bool)
+	emailTrusted := email != "" && verified
+
+	username := subject
+	if preferred, ok := claims["preferred_username"].(string); ok && preferred != "" {
+		username = preferred
+	}
+	if emailTrusted {
+		username = email
+	}
+	if username == "" {
+		return "", "", fmt.Errorf("ID token has no subject")
+	}
+
+	role := RoleViewer
+	if emailTrusted && slices.ContainsFunc(p.config.OrganizerEmails, func(organizer string) bool {
+		return strings.EqualFold(organizer, email)
+	}) {
+		role = RoleOrganizer
+	}
+	groups, _ := claims[p.config.GroupsClaim].([]any)
+	for _, group := range groups {
+		if name, ok := group.(string); ok && slices.Contains(p.config.OrganizerGroups, name) {
+			role = RoleOrganizer
+		}
+	}
+	return username, role, nil
+}
+
+// grpcServerOptions require local account credentials, sent as HTTP basic
+// authorization metadata, on SecretSantaService calls
+func (a *Authenticator) grpcServerOptions() []grpc.ServerOption {
+	if a == nil {
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			ctx, err := a.authorizeRPC(ctx, info.FullMethod)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, err := a.authorizeRPC(stream.Context(), info.FullMethod)
+			if err != nil {
+				return err
+			}
+			return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+		}),
+	}
+}
+
+// authorizeRPC checks the call's credentials and returns ctx carrying the
+// caller as a session
+func (a *Authenticator) authorizeRPC(ctx context.Context, fullMethod string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing credentials")
+	}
+	username, password, ok := parseBasicAuth(values[0])
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "malformed credentials")
+	}
+	user, ok := a.checkPassword(username, password)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid username or password")
+	}
+	if fullMethod != pb.SecretSantaService_ValidateParticipants_FullMethodName && user.Role != RoleOrganizer {
+		return nil, status.Error(codes.PermissionDenied, "organizer role required")
+	}
+	return context.WithValue(ctx, sessionKey{}, &session{username: user.Username, role: user.Role}), nil
+}
+
+func parseBasicAuth(header string) (string, string, bool) {
+	encoded, ok := strings.CutPrefix(header, "Basic ")
+	if !ok {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(decoded), ":")
+}
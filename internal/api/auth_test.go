@@ -0,0 +1,433 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+func testUsers(t *testing.T) []config.UserConfig {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []config.UserConfig{
+		{Username: "alice", PasswordHash: string(hash), Role: RoleOrganizer},
+		{Username: "bob", PasswordHash: string(hash)},
+	}
+}
+
+// startAuthServer serves the full handler with local accounts alice
+// (organizer) and bob (viewer), both with password hunter2
+func startAuthServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+
+	auth, err := NewAuthenticator(context.Background(), config.AuthConfig{Users: testUsers(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(":8080")
+	server.Auth = auth
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// authClient is a browser-like client that keeps cookies and doesn't
+// follow redirects
+func authClient(t *testing.T) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &http.Client{Jar: jar, CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+}
+
+func login(t *testing.T, client *http.Client, baseURL, username string) SessionResponse {
+	t.Helper()
+	body, _ := json.Marshal(LoginRequest{Username: username, Password: "hunter2"})
+	resp, err := client.Post(baseURL+"/api/auth/login", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected login to succeed, got %d", resp.StatusCode)
+	}
+	var session SessionResponse
+	json.NewDecoder(resp.Body).Decode(&session)
+	return session
+}
+
+func post(t *testing.T, client *http.Client, url, csrfToken string, body any) int {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	if csrfToken != "" {
+		req.Header.Set(csrfHeader, csrfToken)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestAuthRequiresSession(t *testing.T) {
+	ts := startAuthServer(t)
+	client := authClient(t)
+
+	resp, err := client.Get(ts.URL + "/api/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for /api/status without a session, got %d", resp.StatusCode)
+	}
+	if code := post(t, client, ts.URL+"/api/draw", "", DrawRequest{}); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for /api/draw without a session, got %d", code)
+	}
+
+	// Public routes stay open
+	resp, err = client.Get(ts.URL + "/api/auth/session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var session SessionResponse
+	json.NewDecoder(resp.Body).Decode(&session)
+	resp.Body.Close()
+	if !session.AuthEnabled || session.Authenticated {
+		t.Errorf("Expected auth enabled and not authenticated, got %+v", session)
+	}
+}
+
+func TestLoginRejectsBadCredentials(t *testing.T) {
+	ts := startAuthServer(t)
+	client := authClient(t)
+
+	for _, creds := range []LoginRequest{{Username: "alice", Password: "wrong"}, {Username: "mallory", Password: "hunter2"}} {
+		if code := post(t, client, ts.URL+"/api/auth/login", "", creds); code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for %s, got %d", creds.Username, code)
+		}
+	}
+}
+
+func TestRolesAndCSRF(t *testing.T) {
+	ts := startAuthServer(t)
+	participants := []participant.Participant{
+		{Name: "Alice", NotificationType: "stdout"},
+		{Name: "Bob", NotificationType: "stdout"},
+	}
+
+	viewer := authClient(t)
+	session := login(t, viewer, ts.URL, "bob")
+	if session.Role != RoleViewer || session.CSRFToken == "" {
+		t.Fatalf("Expected a viewer session with a CSRF token, got %+v", session)
+	}
+	if code := post(t, viewer, ts.URL+"/api/validate", session.CSRFToken, participants); code != http.StatusOK {
+		t.Errorf("Expected a viewer to validate, got %d", code)
+	}
+	if code := post(t, viewer, ts.URL+"/api/validate", "", participants); code != http.StatusForbidden {
+		t.Errorf("Expected 403 without a CSRF token, got %d", code)
+	}
+	if code := post(t, viewer, ts.URL+"/api/validate", "forged", participants); code != http.StatusForbidden {
+		t.Errorf("Expected 403 with a wrong CSRF token, got %d", code)
+	}
+	if code := post(t, viewer, ts.URL+"/api/draw", session.CSRFToken, DrawRequest{Participants: participants}); code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a viewer drawing, got %d", code)
+	}
+
+	organizer := authClient(t)
+	session = login(t, organizer, ts.URL, "alice")
+	if code := post(t, organizer, ts.URL+"/api/draw", session.CSRFToken, DrawRequest{Participants: participants}); code != http.StatusOK {
+		t.Errorf("Expected an organizer to draw, got %d", code)
+	}
+
+	if code := post(t, organizer, ts.URL+"/api/auth/logout", session.CSRFToken, struct{}{}); code != http.StatusOK {
+		t.Fatalf("Expected logout to succeed, got %d", code)
+	}
+	if code := post(t, organizer, ts.URL+"/api/draw", session.CSRFToken, DrawRequest{Participants: participants}); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 after logout, got %d", code)
+	}
+}
+
+func TestNewAuthenticatorRejectsBadConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		users []config.UserConfig
+	}{
+		{"no users or issuer", nil},
+		{"plaintext password", []config.UserConfig{{Username: "alice", PasswordHash: "hunter2"}}},
+		{"unknown role", []config.UserConfig{{Username: "alice", PasswordHash: testUsers(t)[0].PasswordHash, Role: "admin"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAuthenticator(context.Background(), config.AuthConfig{Users: tt.users}); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestAuthorizeRPC(t *testing.T) {
+	auth, err := NewAuthenticator(context.Background(), config.AuthConfig{Users: testUsers(t)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withCreds := func(username, password string) context.Context {
+		creds := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic "+creds))
+	}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		want   codes.Code
+	}{
+		{"no credentials", context.Background(), pb.SecretSantaService_Draw_FullMethodName, codes.Unauthenticated},
+		{"wrong password", withCreds("alice", "wrong"), pb.SecretSantaService_Draw_FullMethodName, codes.Unauthenticated},
+		{"viewer validates", withCreds("bob", "hunter2"), pb.SecretSantaService_ValidateParticipants_FullMethodName, codes.OK},
+		{"viewer draws", withCreds("bob", "hunter2"), pb.SecretSantaService_Draw_FullMethodName, codes.PermissionDenied},
+		{"organizer draws", withCreds("alice", "hunter2"), pb.SecretSantaService_StreamDrawProgress_FullMethodName, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := auth.authorizeRPC(tt.ctx, tt.method)
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOIDCUserRole(t *testing.T) {
+	provider := &oidcProvider{config: config.OIDCConfig{
+		GroupsClaim:     "groups",
+		OrganizerEmails: []string{"Carol@example.com"},
+		OrganizerGroups: []string{"elves"},
+	}}
+
+	tests := []struct {
+		name     string
+		claims   map[string]any
+		wantUser string
+		wantRole string
+	}{
+		{"organizer email", map[string]any{"email": "carol@example.com", "email_verified": true}, "carol@example.com", RoleOrganizer},
+		{"unverified email", map[string]any{"email": "carol@example.com", "email_verified": false, "preferred_username": "carol"}, "carol", RoleViewer},
+		{"email without verification claim", map[string]any{"email": "carol@example.com", "preferred_username": "carol"}, "carol", RoleViewer},
+		{"organizer group", map[string]any{"preferred_username": "dave", "groups": []any{"staff", "elves"}}, "dave", RoleOrganizer},
+		{"other user", map[string]any{}, "subject", RoleViewer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			username, role, err := provider.user("subject", tt.claims)
+			if err != nil || username != tt.wantUser || role != tt.wantRole {
+				t.Errorf("Expected %s as %s, got %s as %s (%v)", tt.wantUser, tt.wantRole, username, role, err)
+			}
+		})
+	}
+}
+
+// fakeOIDCProvider is an issuer that accepts any code and signs ID tokens
+// for the nonce of the last authorization request
+type fakeOIDCProvider struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	clientID  string
+	nonce     string
+	challenge string
+}
+
+func newFakeOIDCProvider(t *testing.T, clientID string) *fakeOIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &fakeOIDCProvider{key: key, clientID: clientID}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                p.URL,
+			"authorization_endpoint":                p.URL + "/authorize",
+			"token_endpoint":                        p.URL + "/token",
+			"jwks_uri":                              p.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []any{map[string]any{
+			"kty": "RSA", "kid": "test", "alg": "RS256", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != p.challenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"id_token": p.sign(t, map[string]any{
+				"iss": p.URL, "aud": p.clientID, "sub": "carol-id", "nonce": p.nonce,
+				"email": "carol@example.com", "email_verified": true,
+				"iat": time.Now().Unix(), "exp": time.Now().Add(time.Hour).Unix(),
+			}),
+		})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *fakeOIDCProvider) sign(t *testing.T, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// startOIDCServer serves the full handler with OIDC login through provider,
+// which grants carol@example.com the organizer role, and the given local
+// accounts
+func startOIDCServer(t *testing.T, provider *fakeOIDCProvider, users []config.UserConfig) *httptest.Server {
+	t.Helper()
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+
+	server := NewServer(":8080")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.Handler().ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	auth, err := NewAuthenticator(context.Background(), config.AuthConfig{Users: users, OIDC: config.OIDCConfig{
+		Issuer:          provider.URL,
+		ClientID:        "secretsanta",
+		ClientSecret:    "secret",
+		RedirectURL:     ts.URL + "/api/auth/oidc/callback",
+		OrganizerEmails: []string{"carol@example.com"},
+	}})
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	server.Auth = auth
+	return ts
+}
+
+// beginOIDCLogin follows the login redirect to provider and returns the
+// state to send back to the callback
+func beginOIDCLogin(t *testing.T, ts *httptest.Server, client *http.Client, provider *fakeOIDCProvider) string {
+	t.Helper()
+	resp, err := client.Get(ts.URL + "/api/auth/oidc/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if resp.StatusCode != http.StatusFound || err != nil || !strings.HasPrefix(location.String(), provider.URL+"/authorize") {
+		t.Fatalf("Expected a redirect to the provider, got %d %s", resp.StatusCode, location)
+	}
+	provider.nonce = location.Query().Get("nonce")
+	provider.challenge = location.Query().Get("code_challenge")
+	return location.Query().Get("state")
+}
+
+func TestOIDCLogin(t *testing.T) {
+	provider := newFakeOIDCProvider(t, "secretsanta")
+	ts := startOIDCServer(t, provider, nil)
+	client := authClient(t)
+	state := beginOIDCLogin(t, ts, client, provider)
+
+	// A callback with someone else's state is rejected
+	resp, err := client.Get(ts.URL + "/api/auth/oidc/callback?code=abc&state=forged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a forged state, got %d", resp.StatusCode)
+	}
+
+	resp, err = client.Get(ts.URL + "/api/auth/oidc/callback?code=abc&state=" + url.QueryEscape(state))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/" {
+		t.Fatalf("Expected a redirect to the UI, got %d %s", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	resp, err = client.Get(ts.URL + "/api/auth/session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var session SessionResponse
+	json.NewDecoder(resp.Body).Decode(&session)
+	resp.Body.Close()
+	if !session.Authenticated || session.Username != "carol@example.com" || session.Role != RoleOrganizer {
+		t.Errorf("Expected carol logged in as organizer, got %+v", session)
+	}
+}
+
+func TestOIDCLoginRejectsLocalAccountName(t *testing.T) {
+	provider := newFakeOIDCProvider(t, "secretsanta")
+	users := append(testUsers(t), config.UserConfig{Username: "Carol@example.com", PasswordHash: testUsers(t)[0].PasswordHash})
+	ts := startOIDCServer(t, provider, users)
+	client := authClient(t)
+	state := beginOIDCLogin(t, ts, client, provider)
+
+	resp, err := client.Get(ts.URL + "/api/auth/oidc/callback?code=abc&state=" + url.QueryEscape(state))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected 403 for a provider login named like a local account, got %d", resp.StatusCode)
+	}
+	if session := login(t, client, ts.URL, "Carol@example.com"); session.Username != "Carol@example.com" {
+		t.Errorf("Expected the local account to still log in, got %+v", session)
+	}
+}
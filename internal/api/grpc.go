@@ -0,0 +1,379 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+	"github.com/igodwin/secretsanta/internal/draw"
+	"github.com/igodwin/secretsanta/internal/formats"
+	"github.com/igodwin/secretsanta/internal/logging"
+	"github.com/igodwin/secretsanta/internal/tracing"
+	"github.com/igodwin/secretsanta/pkg/event"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+// requestIDMetadata carries the request ID of a SecretSantaService call
+const requestIDMetadata = "x-request-id"
+
+// grpcServerOptions log and trace each SecretSantaService call under a
+// request ID, kept from x-request-id metadata when valid, and require
+// authentication when it is enabled
+func (s *Server) grpcServerOptions() []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			ctx = rpcRequestContext(ctx)
+			grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, logging.RequestID(ctx)))
+			ctx, span := tracing.StartRPC(ctx, info.FullMethod)
+			start := time.Now()
+			resp, err := handler(ctx, req)
+			logRPC(ctx, info.FullMethod, start, err)
+			tracing.EndRPC(span, err)
+			return resp, err
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx := rpcRequestContext(stream.Context())
+			stream.SetHeader(metadata.Pairs(requestIDMetadata, logging.RequestID(ctx)))
+			ctx, span := tracing.StartRPC(ctx, info.FullMethod)
+			start := time.Now()
+			err := handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+			logRPC(ctx, info.FullMethod, start, err)
+			tracing.EndRPC(span, err)
+			return err
+		}),
+	}
+	return append(opts, s.Auth.grpcServerOptions()...)
+}
+
+func rpcRequestContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(requestIDMetadata); len(ids) > 0 && logging.ValidRequestID(ids[0]) {
+		return logging.WithRequestID(ctx, ids[0])
+	}
+	return logging.WithRequestID(ctx, logging.NewRequestID())
+}
+
+func logRPC(ctx context.Context, method string, start time.Time, err error) {
+	slog.LogAttrs(ctx, slog.LevelInfo, "RPC",
+		slog.String("method", method),
+		slog.String("code", status.Code(err).String()),
+		slog.Duration("duration", time.Since(start)))
+}
+
+// contextStream replaces a stream's context, e.g. with one carrying the caller
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// maxStoredDraws bounds the draws kept for GetDraw; the oldest are dropped first
+const maxStoredDraws = 100
+
+// DrawService implements SecretSantaService with the same validation, draw
+// and notification code as the HTTP handlers. Draws are kept in memory only,
+// so assignments never reach disk.
+type DrawService struct {
+	pb.UnimplementedSecretSantaServiceServer
+
+	server *Server
+
+	mu    sync.Mutex
+	draws map[string]storedDraw
+	order []string
+}
+
+// storedDraw is a draw kept for GetDraw with the user who made it, the only
+// one it is returned to. owner is empty when authentication is disabled.
+type storedDraw struct {
+	owner string
+	draw  *pb.Draw
+}
+
+// NewDrawService returns a service that draws with s's scheduler and tracker
+func NewDrawService(s *Server) *DrawService {
+	return &DrawService{server: s, draws: make(map[string]storedDraw)}
+}
+
+// ValidateParticipants checks whether a draw is possible without performing it
+func (d *DrawService) ValidateParticipants(ctx context.Context, req *pb.ValidateParticipantsRequest) (*pb.ValidateParticipantsResponse, error) {
+	participants, err := requestParticipants(req.Participants, req.File)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return validationResponse(validateParticipants(ctx, participants)), nil
+}
+
+// Draw assigns recipients and sends or schedules the notifications
+func (d *DrawService) Draw(ctx context.Context, req *pb.DrawRequest) (*pb.DrawResponse, error) {
+	result, err := d.draw(ctx, req, nil)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &pb.DrawResponse{Draw: result}, nil
+}
+
+// GetDraw returns a draw the caller made with this service since it
+// started. Other users' draws are reported as not found.
+func (d *DrawService) GetDraw(ctx context.Context, req *pb.GetDrawRequest) (*pb.GetDrawResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stored, ok := d.draws[req.Id]
+	if !ok || stored.owner != drawOwner(ctx) {
+		return nil, status.Errorf(codes.NotFound, "draw %s not found", req.Id)
+	}
+	return &pb.GetDrawResponse{Draw: stored.draw}, nil
+}
+
+// drawOwner is the username of the caller, or empty without authentication
+func drawOwner(ctx context.Context) string {
+	if sess := sessionFromContext(ctx); sess != nil {
+		return sess.username
+	}
+	return ""
+}
+
+// StreamDrawProgress performs a draw, sending a message as each stage
+// starts. Once drawing has begun the draw is completed even if the client
+// goes away, so notifications are never half sent.
+func (d *DrawService) StreamDrawProgress(req *pb.DrawRequest, stream pb.SecretSantaService_StreamDrawProgressServer) error {
+	result, err := d.draw(stream.Context(), req, func(progress *pb.DrawProgress) {
+		stream.Send(progress)
+	})
+	if err != nil {
+		return grpcError(err)
+	}
+	return stream.Send(&pb.DrawProgress{
+		Stage:   pb.DrawStage_DRAW_STAGE_COMPLETE,
+		Message: fmt.Sprintf("Drew %d participants", len(result.Participants)),
+		Draw:    result,
+	})
+}
+
+// draw runs a draw request through checkDrawRequest, the daily notification
+// cap and executeDraw and stores the result. progress may be nil.
+func (d *DrawService) draw(ctx context.Context, req *pb.DrawRequest, progress func(*pb.DrawProgress)) (*pb.Draw, error) {
+	report := func(p *pb.DrawProgress) {
+		if progress != nil {
+			progress(p)
+		}
+	}
+
+	if err := d.server.limits.allowRPC(ctx); err != nil {
+		return nil, err
+	}
+
+	participants, err := requestParticipants(req.Participants, req.File)
+	if err != nil {
+		return nil, err
+	}
+	drawRequest := &DrawRequest{ArchiveEmail: req.ArchiveEmail}
+	if req.SendAt != nil {
+		sendAt := req.SendAt.AsTime()
+		drawRequest.SendAt = &sendAt
+	}
+	if req.Event != nil {
+		drawRequest.Event = fromProtoEvent(req.Event)
+	}
+
+	validation, err := checkDrawRequest(ctx, participants, drawRequest)
+	report(&pb.DrawProgress{
+		Stage:      pb.DrawStage_DRAW_STAGE_VALIDATING,
+		Message:    fmt.Sprintf("Validated %d participants", len(participants)),
+		Validation: validationResponse(validation),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	release, err := d.server.limits.reserveNotifications(grpcOrganizer(ctx), participants, drawRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome, err := d.server.executeDraw(ctx, participants, drawRequest, func(stage drawStage) {
+		report(stageProgress(stage, len(participants)))
+	})
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	result := &pb.Draw{
+		Id:                 newDrawID(),
+		CreatedAt:          timestamppb.Now(),
+		Participants:       toProtoParticipants(outcome.participants),
+		ScheduledFor:       req.SendAt,
+		RemindersScheduled: int32(outcome.remindersScheduled),
+	}
+	if outcome.notificationErr != nil {
+		result.NotificationError = outcome.notificationErr.Error()
+	}
+	d.store(drawOwner(ctx), result)
+	return result, nil
+}
+
+// store keeps result for GetDraw by owner, dropping the oldest draw when full
+func (d *DrawService) store(owner string, result *pb.Draw) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.order) >= maxStoredDraws {
+		delete(d.draws, d.order[0])
+		d.order = d.order[1:]
+	}
+	d.draws[result.Id] = storedDraw{owner: owner, draw: result}
+	d.order = append(d.order, result.Id)
+}
+
+func stageProgress(stage drawStage, count int) *pb.DrawProgress {
+	switch stage {
+	case stageDrawing:
+		return &pb.DrawProgress{Stage: pb.DrawStage_DRAW_STAGE_DRAWING, Message: fmt.Sprintf("Drawing names for %d participants", count)}
+	case stageSending:
+		return &pb.DrawProgress{Stage: pb.DrawStage_DRAW_STAGE_SENDING, Message: "Sending notifications"}
+	case stageScheduling:
+		return &pb.DrawProgress{Stage: pb.DrawStage_DRAW_STAGE_SCHEDULING, Message: "Scheduling notifications"}
+	default:
+		return &pb.DrawProgress{Stage: pb.DrawStage_DRAW_STAGE_SCHEDULING_REMINDERS, Message: "Scheduling reminders"}
+	}
+}
+
+// requestParticipants returns the participants given either as a list or
+// as a file in one of the upload formats. Delivery results in a file, such
+// as a saved draw response, are dropped.
+func requestParticipants(list []*pb.Participant, file *pb.ParticipantFile) ([]*participant.Participant, error) {
+	if file == nil {
+		return fromProtoParticipants(list), nil
+	}
+	if len(list) > 0 {
+		msg := "give participants either as a list or as a file, not both"
+		return nil, &requestError{Field: "file", Message: msg, Violations: []string{msg}}
+	}
+	participants, err := formats.Parse(file.Content, formats.FileFormat(strings.ToLower(file.Format)))
+	if err != nil {
+		return nil, &requestError{Field: "file", Message: err.Error(), Violations: []string{err.Error()}}
+	}
+	clearDeliveryState(participants)
+	return participants, nil
+}
+
+// grpcError maps a draw error to a status. Request errors become
+// INVALID_ARGUMENT with a BadRequest detail and limit errors
+// RESOURCE_EXHAUSTED with a RetryInfo detail; errors that already carry a
+// status, such as those from the notifier service, keep their code.
+func grpcError(err error) error {
+	var limitErr *limitError
+	if errors.As(err, &limitErr) {
+		st := status.New(codes.ResourceExhausted, limitErr.Message)
+		if detailed, detailErr := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(limitErr.RetryAfter)}); detailErr == nil {
+			return detailed.Err()
+		}
+		return st.Err()
+	}
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		st := status.New(codes.InvalidArgument, reqErr.Message)
+		badRequest := &errdetails.BadRequest{}
+		for _, violation := range reqErr.Violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       reqErr.Field,
+				Description: violation,
+			})
+		}
+		if detailed, detailErr := st.WithDetails(badRequest); detailErr == nil {
+			return detailed.Err()
+		}
+		return st.Err()
+	}
+	if st, ok := status.FromError(err); ok {
+		return status.Error(st.Code(), err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func validationResponse(result *draw.ValidationResult) *pb.ValidateParticipantsResponse {
+	return &pb.ValidateParticipantsResponse{
+		Valid:                     result.IsValid,
+		Errors:                    result.Errors,
+		Warnings:                  result.Warnings,
+		ParticipantsWithNoOptions: result.ParticipantsWithNoOptions,
+		MinCompatibility:          int32(result.MinCompatibility),
+		AvgCompatibility:          result.AvgCompatibility,
+		TotalParticipants:         int32(result.TotalParticipants),
+	}
+}
+
+func fromProtoParticipants(list []*pb.Participant) []*participant.Participant {
+	participants := make([]*participant.Participant, len(list))
+	for i, p := range list {
+		participants[i] = &participant.Participant{
+			Name:             p.Name,
+			NotificationType: p.NotificationType,
+			ContactInfo:      p.ContactInfo,
+			Exclusions:       p.Exclusions,
+			NoReminders:      p.NoReminders,
+		}
+	}
+	return participants
+}
+
+func toProtoParticipants(participants []*participant.Participant) []*pb.Participant {
+	list := make([]*pb.Participant, len(participants))
+	for i, p := range participants {
+		list[i] = &pb.Participant{
+			Name:             p.Name,
+			NotificationType: p.NotificationType,
+			ContactInfo:      p.ContactInfo,
+			Exclusions:       p.Exclusions,
+			NoReminders:      p.NoReminders,
+			DeliveredVia:     p.DeliveredVia,
+			NotificationId:   p.NotificationID,
+		}
+		if p.Recipient != nil {
+			list[i].Recipient = p.Recipient.Name
+		}
+	}
+	return list
+}
+
+func fromProtoEvent(e *pb.Event) *event.Event {
+	result := &event.Event{Name: e.Name, Budget: e.Budget}
+	if e.ExchangeDate != nil {
+		result.ExchangeDate = e.ExchangeDate.AsTime()
+	}
+	for _, rule := range e.Reminders {
+		result.Reminders = append(result.Reminders, event.ReminderRule{
+			DaysBefore: int(rule.DaysBefore),
+			Subject:    rule.Subject,
+			Template:   rule.Template,
+		})
+	}
+	return result
+}
+
+func newDrawID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+)
+
+// startDrawService serves a DrawService on a local port and returns a client
+func startDrawService(t *testing.T) pb.SecretSantaServiceClient {
+	t.Helper()
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+	return serveDrawService(t, NewServer(":8080"))
+}
+
+// serveDrawService serves a DrawService drawing with s
+func serveDrawService(t *testing.T, s *Server) pb.SecretSantaServiceClient {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	pb.RegisterSecretSantaServiceServer(server, NewDrawService(s))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewSecretSantaServiceClient(conn)
+}
+
+func stdoutParticipants(names ...string) []*pb.Participant {
+	participants := make([]*pb.Participant, len(names))
+	for i, name := range names {
+		participants[i] = &pb.Participant{Name: name, NotificationType: "stdout"}
+	}
+	return participants
+}
+
+func TestGRPCValidateParticipants(t *testing.T) {
+	client := startDrawService(t)
+	ctx := context.Background()
+
+	resp, err := client.ValidateParticipants(ctx, &pb.ValidateParticipantsRequest{
+		File: &pb.ParticipantFile{Format: "CSV", Content: []byte("name,notification_type,contact_info,exclusions\nAlice,stdout,,Bob\nBob,stdout,,Alice\n")},
+	})
+	if err != nil {
+		t.Fatalf("ValidateParticipants() error = %v", err)
+	}
+	if resp.Valid || resp.TotalParticipants != 2 || len(resp.ParticipantsWithNoOptions) != 2 {
+		t.Errorf("Expected an invalid draw for 2 participants with no options, got %v", resp)
+	}
+
+	_, err = client.ValidateParticipants(ctx, &pb.ValidateParticipantsRequest{File: &pb.ParticipantFile{Format: "xls"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for an unsupported format, got %v", err)
+	}
+}
+
+func TestGRPCDrawAndGetDraw(t *testing.T) {
+	client := startDrawService(t)
+	ctx := context.Background()
+
+	resp, err := client.Draw(ctx, &pb.DrawRequest{Participants: stdoutParticipants("Alice", "Bob", "Carol")})
+	if err != nil {
+		t.Fatalf("Draw() error = %v", err)
+	}
+	for _, p := range resp.Draw.Participants {
+		if p.Recipient == "" || p.Recipient == p.Name || p.DeliveredVia != "stdout" {
+			t.Errorf("Unexpected assignment for %s: %v", p.Name, p)
+		}
+	}
+
+	got, err := client.GetDraw(ctx, &pb.GetDrawRequest{Id: resp.Draw.Id})
+	if err != nil || got.Draw.Id != resp.Draw.Id || len(got.Draw.Participants) != 3 {
+		t.Errorf("GetDraw() = %v, %v", got, err)
+	}
+	if _, err := client.GetDraw(ctx, &pb.GetDrawRequest{Id: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
+
+func TestGRPCGetDrawOnlyForOwner(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+	service := NewDrawService(NewServer(":8080"))
+	alice := context.WithValue(context.Background(), sessionKey{}, &session{username: "alice", role: RoleOrganizer})
+	bob := context.WithValue(context.Background(), sessionKey{}, &session{username: "bob", role: RoleOrganizer})
+
+	resp, err := service.Draw(alice, &pb.DrawRequest{Participants: stdoutParticipants("Alice", "Bob", "Carol")})
+	if err != nil {
+		t.Fatalf("Draw() error = %v", err)
+	}
+	if _, err := service.GetDraw(alice, &pb.GetDrawRequest{Id: resp.Draw.Id}); err != nil {
+		t.Errorf("Expected the organizer to get their draw, got %v", err)
+	}
+	if _, err := service.GetDraw(bob, &pb.GetDrawRequest{Id: resp.Draw.Id}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for another organizer's draw, got %v", err)
+	}
+	if _, err := service.GetDraw(context.Background(), &pb.GetDrawRequest{Id: resp.Draw.Id}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound without a session, got %v", err)
+	}
+}
+
+func TestGRPCDrawInvalidRequest(t *testing.T) {
+	client := startDrawService(t)
+	ctx := context.Background()
+
+	participants := stdoutParticipants("Alice", "Bob")
+	participants[0].Exclusions = []string{"Bob"}
+	_, err := client.Draw(ctx, &pb.DrawRequest{Participants: participants})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument, got %v", err)
+	}
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			violations = badRequest.FieldViolations
+		}
+	}
+	if len(violations) == 0 || violations[0].Field != "participants" {
+		t.Errorf("Expected participant field violations, got %v", st.Details())
+	}
+
+	_, err = client.Draw(ctx, &pb.DrawRequest{
+		Participants: stdoutParticipants("Alice", "Bob"),
+		SendAt:       timestamppb.New(time.Now().Add(-time.Hour)),
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for send_at in the past, got %v", err)
+	}
+}
+
+func TestGRPCStreamDrawProgress(t *testing.T) {
+	client := startDrawService(t)
+
+	stream, err := client.StreamDrawProgress(context.Background(), &pb.DrawRequest{Participants: stdoutParticipants("Alice", "Bob")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stages []pb.DrawStage
+	var last *pb.DrawProgress
+	for {
+		progress, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv() error = %v", err)
+		}
+		stages = append(stages, progress.Stage)
+		last = progress
+	}
+
+	want := []pb.DrawStage{
+		pb.DrawStage_DRAW_STAGE_VALIDATING,
+		pb.DrawStage_DRAW_STAGE_DRAWING,
+		pb.DrawStage_DRAW_STAGE_SENDING,
+		pb.DrawStage_DRAW_STAGE_COMPLETE,
+	}
+	if len(stages) != len(want) {
+		t.Fatalf("Expected stages %v, got %v", want, stages)
+	}
+	for i := range want {
+		if stages[i] != want[i] {
+			t.Errorf("Expected stages %v, got %v", want, stages)
+			break
+		}
+	}
+	if last.Draw == nil || len(last.Draw.Participants) != 2 {
+		t.Errorf("Expected the final message to carry the draw, got %v", last)
+	}
+}
@@ -3,30 +3,56 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"slices"
+	"sync"
 	"time"
 
+	pb "github.com/igodwin/secretsanta/api/grpc/pb"
 	"github.com/igodwin/secretsanta/internal/draw"
 	"github.com/igodwin/secretsanta/internal/formats"
+	"github.com/igodwin/secretsanta/internal/logging"
+	"github.com/igodwin/secretsanta/internal/metrics"
 	"github.com/igodwin/secretsanta/internal/notification"
+	"github.com/igodwin/secretsanta/internal/scheduler"
+	"github.com/igodwin/secretsanta/internal/tracing"
 	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/event"
+	"github.com/igodwin/secretsanta/pkg/notifier"
 	"github.com/igodwin/secretsanta/pkg/participant"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/metadata"
-
-	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
 type Server struct {
 	addr string
+	// GRPCAddr is where SecretSantaService listens; empty disables it
+	GRPCAddr string
+	// Auth protects the endpoints; nil leaves them open. Start sets it from
+	// the auth config when it is enabled.
+	Auth *Authenticator
+	// StaticDir serves the UI from disk instead of the embedded copy, so
+	// edits show up without rebuilding
+	StaticDir string
+	scheduler *scheduler.Scheduler
+	tracker   *notification.Tracker
+	stats     statsCache
+	readiness readinessCache
+	// limits is nil in servers built without NewServer, which are unlimited
+	limits  *limiter
+	batches batchTracker
 }
 
 func NewServer(addr string) *Server {
-	return &Server{addr: addr}
+	return &Server{addr: addr, limits: newLimiter(config.GetConfig().Limits)}
 }
 
 // Participant input/output models
@@ -43,6 +69,9 @@ type ParticipantResponse struct {
 	ContactInfo      []string `json:"contact_info"`
 	Exclusions       []string `json:"exclusions"`
 	Recipient        *string  `json:"recipient,omitempty"`
+	NoReminders      bool     `json:"no_reminders,omitempty"`
+	DeliveredVia     string   `json:"delivered_via,omitempty"`
+	NotificationID   string   `json:"notification_id,omitempty"`
 }
 
 type ValidationResponse struct {
@@ -58,12 +87,23 @@ type ValidationResponse struct {
 type DrawRequest struct {
 	Participants []participant.Participant `json:"participants"`
 	ArchiveEmail string                    `json:"archive_email,omitempty"`
+	// Preview renders the notifications without drawing or sending anything
+	Preview bool `json:"preview,omitempty"`
+	// SendAt delays delivery of the assignments until the given time
+	SendAt *time.Time `json:"send_at,omitempty"`
+	// Event schedules reminders before the exchange date. Without reminder
+	// rules the default one-week and one-day reminders are used.
+	Event *event.Event `json:"event,omitempty"`
 }
 
 type DrawResponse struct {
-	Success      bool                   `json:"success"`
-	Participants []*ParticipantResponse `json:"participants,omitempty"`
-	Error        string                 `json:"error,omitempty"`
+	Success      bool                    `json:"success"`
+	Participants []*ParticipantResponse  `json:"participants,omitempty"`
+	Previews     []*notification.Preview `json:"previews,omitempty"`
+	ScheduledFor *time.Time              `json:"scheduled_for,omitempty"`
+	// RemindersScheduled counts the reminder messages queued for the event
+	RemindersScheduled int    `json:"reminders_scheduled,omitempty"`
+	Error              string `json:"error,omitempty"`
 }
 
 // HandleValidate validates participant data without performing draw
@@ -74,12 +114,11 @@ func (s *Server) HandleValidate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var participants []*participant.Participant
-	if err := json.NewDecoder(r.Body).Decode(&participants); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+	if !decodeJSON(w, r, &participants) {
 		return
 	}
 
-	result := draw.ValidateParticipants(participants)
+	result := validateParticipants(r.Context(), participants)
 
 	response := ValidationResponse{
 		Valid:                     result.IsValid,
@@ -103,8 +142,7 @@ func (s *Server) HandleDraw(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var drawRequest DrawRequest
-	if err := json.NewDecoder(r.Body).Decode(&drawRequest); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+	if !decodeJSON(w, r, &drawRequest) {
 		return
 	}
 
@@ -113,13 +151,12 @@ func (s *Server) HandleDraw(w http.ResponseWriter, r *http.Request) {
 	for i := range drawRequest.Participants {
 		participants[i] = &drawRequest.Participants[i]
 	}
+	clearDeliveryState(participants)
 
-	// Validate first
-	validation := draw.ValidateParticipants(participants)
-	if !validation.IsValid {
+	if _, err := checkDrawRequest(r.Context(), participants, &drawRequest); err != nil {
 		response := DrawResponse{
 			Success: false,
-			Error:   fmt.Sprintf("Validation failed: %v", validation.Errors),
+			Error:   err.Error(),
 		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
@@ -127,34 +164,43 @@ func (s *Server) HandleDraw(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Perform draw
-	result, err := draw.Names(participants)
-	if err != nil {
+	if drawRequest.Preview {
+		// Assignments are never revealed in a preview, so there is nothing to draw
 		response := DrawResponse{
-			Success: false,
-			Error:   err.Error(),
+			Success:  true,
+			Previews: notification.RenderPreviews(participants, drawConfig(&drawRequest)),
 		}
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(response)
 		return
 	}
 
-	// Send notifications
-	cfg := config.GetConfig()
-
-	// Set archive email from request if provided
-	if drawRequest.ArchiveEmail != "" {
-		log.Printf("Draw completed with archive email: %s", drawRequest.ArchiveEmail)
-		cfg.Notifier.ArchiveEmail = drawRequest.ArchiveEmail
+	release, err := s.limits.reserveNotifications(s.limits.organizer(r), participants, &drawRequest)
+	var limitErr *limitError
+	if errors.As(err, &limitErr) {
+		writeLimitError(w, limitErr)
+		return
+	}
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(DrawResponse{Success: false, Error: err.Error()})
+		return
 	}
 
-	// Send notifications using the notification service
-	if err := notification.Send(result, cfg); err != nil {
-		log.Printf("Failed to send notifications: %v", err)
-		// Don't fail the entire draw if notifications fail
-		// The user still gets the results in the response
+	outcome, err := s.executeDraw(r.Context(), participants, &drawRequest, nil)
+	if err != nil {
+		release()
+		response := DrawResponse{
+			Success: false,
+			Error:   err.Error(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(response)
+		return
 	}
+	result := outcome.participants
 
 	// Convert to response format
 	participantResponses := make([]*ParticipantResponse, len(result))
@@ -170,18 +216,211 @@ func (s *Server) HandleDraw(w http.ResponseWriter, r *http.Request) {
 			ContactInfo:      p.ContactInfo,
 			Exclusions:       p.Exclusions,
 			Recipient:        recipientName,
+			NoReminders:      p.NoReminders,
+			DeliveredVia:     p.DeliveredVia,
+			NotificationID:   p.NotificationID,
 		}
 	}
 
 	response := DrawResponse{
-		Success:      true,
-		Participants: participantResponses,
+		Success:            true,
+		Participants:       participantResponses,
+		ScheduledFor:       drawRequest.SendAt,
+		RemindersScheduled: outcome.remindersScheduled,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// requestError is a draw request problem the caller has to fix. Field names
+// the offending part of the request and Violations lists each problem.
+type requestError struct {
+	Field      string
+	Message    string
+	Violations []string
+}
+
+func (e *requestError) Error() string {
+	return e.Message
+}
+
+// validateParticipants checks whether a draw is possible, counting the
+// errors found for metrics
+func validateParticipants(ctx context.Context, participants []*participant.Participant) *draw.ValidationResult {
+	_, span := tracing.Start(ctx, "ValidateParticipants", attribute.Int("participants", len(participants)))
+	defer span.End()
+
+	result := draw.ValidateParticipants(participants)
+	metrics.ObserveValidation(result)
+	span.SetAttributes(attribute.Bool("valid", result.IsValid), attribute.StringSlice("error_kinds", result.ErrorKinds))
+	return result
+}
+
+// checkDrawRequest validates the participants, send time and event of a
+// draw request, filling in the default reminders. Problems are reported as
+// a *requestError; the participant validation is returned either way.
+func checkDrawRequest(ctx context.Context, participants []*participant.Participant, req *DrawRequest) (*draw.ValidationResult, error) {
+	if limit := config.GetConfig().Limits.MaxParticipants; limit > 0 && len(participants) > limit {
+		msg := fmt.Sprintf("A draw can have at most %d participants, got %d", limit, len(participants))
+		validation := &draw.ValidationResult{TotalParticipants: len(participants)}
+		validation.AddError(draw.ErrorKindTooManyParticipants, msg)
+		metrics.ObserveValidation(validation)
+		return validation, &requestError{Field: "participants", Message: msg, Violations: []string{msg}}
+	}
+
+	validation := validateParticipants(ctx, participants)
+	if !validation.IsValid {
+		return validation, &requestError{
+			Field:      "participants",
+			Message:    fmt.Sprintf("Validation failed: %v", validation.Errors),
+			Violations: validation.Errors,
+		}
+	}
+
+	if req.SendAt != nil && !req.SendAt.After(time.Now()) {
+		msg := "send_at must be in the future"
+		return validation, &requestError{Field: "send_at", Message: msg, Violations: []string{msg}}
+	}
+
+	if req.Event != nil {
+		if len(req.Event.Reminders) == 0 {
+			req.Event.Reminders = event.DefaultReminders()
+		}
+		if err := req.Event.Validate(); err != nil {
+			return validation, &requestError{Field: "event", Message: err.Error(), Violations: []string{err.Error()}}
+		}
+	}
+	return validation, nil
+}
+
+// drawStage is reported to executeDraw's progress callback as each step starts
+type drawStage int
+
+const (
+	stageDrawing drawStage = iota
+	stageSending
+	stageScheduling
+	stageReminders
+)
+
+// drawOutcome is the result of executeDraw
+type drawOutcome struct {
+	participants       []*participant.Participant
+	remindersScheduled int
+	// notificationErr is set when sending failed; the draw itself stands
+	notificationErr error
+}
+
+// clearDeliveryState drops delivery results a client sent along with its
+// participants, so a draw only tracks notifications it sent itself
+func clearDeliveryState(participants []*participant.Participant) {
+	for _, p := range participants {
+		p.NotificationID, p.DeliveredVia = "", ""
+	}
+}
+
+// executeDraw performs a checked draw request: it assigns recipients, sends
+// or schedules the notifications, and schedules the event reminders. Only
+// a failed draw or failed scheduling is an error, since nothing has been
+// sent yet in either case. progress may be nil. Sending isn't canceled with
+// ctx, so a client that goes away can't leave a draw half notified.
+func (s *Server) executeDraw(ctx context.Context, participants []*participant.Participant, req *DrawRequest, progress func(drawStage)) (*drawOutcome, error) {
+	defer s.batches.begin()()
+	ctx = context.WithoutCancel(ctx)
+
+	report := func(stage drawStage) {
+		if progress != nil {
+			progress(stage)
+		}
+	}
+
+	metrics.DrawsAttempted.Inc()
+	report(stageDrawing)
+	_, span := tracing.Start(ctx, "Draw", attribute.Int("participants", len(participants)))
+	start := time.Now()
+	result, err := draw.Names(participants)
+	tracing.End(span, err)
+	if err != nil {
+		metrics.DrawsFailed.WithLabelValues("no_assignment").Inc()
+		return nil, err
+	}
+	metrics.DrawDuration.WithLabelValues(metrics.ParticipantRange(len(participants))).Observe(time.Since(start).Seconds())
+
+	cfg := drawConfig(req)
+	slog.InfoContext(ctx, "Draw completed", "participants", len(result), "archive_email", logging.PII(req.ArchiveEmail))
+
+	outcome := &drawOutcome{participants: result}
+	if req.SendAt != nil {
+		report(stageScheduling)
+		if err := scheduleNotifications(ctx, result, cfg, *req.SendAt, s.scheduler); err != nil {
+			metrics.DrawsFailed.WithLabelValues("scheduling").Inc()
+			slog.ErrorContext(ctx, "Failed to schedule notifications", "error", logging.RedactError(err, notifier.PersonalData(result...)...))
+			return nil, fmt.Errorf("failed to schedule notifications: %w", err)
+		}
+	} else {
+		report(stageSending)
+		if err := sendNotifications(ctx, result, cfg); err != nil {
+			// Don't fail the entire draw if notifications fail
+			// The user still gets the results in the response
+			slog.ErrorContext(ctx, "Failed to send notifications", "error", logging.RedactError(err, notifier.PersonalData(result...)...))
+			outcome.notificationErr = err
+		}
+	}
+
+	if s.tracker != nil {
+		if err := s.tracker.Record(drawOwner(ctx), result); err != nil {
+			slog.ErrorContext(ctx, "Failed to record notification IDs", "error", err)
+		}
+	}
+
+	if req.Event != nil {
+		report(stageReminders)
+		// Assignments are already out, so reminder failures don't fail the draw
+		outcome.remindersScheduled, err = notification.ScheduleReminders(ctx, result, req.Event, cfg, s.scheduler)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to schedule reminders", "error", logging.RedactError(err, notifier.PersonalData(result...)...))
+		}
+	}
+	metrics.DrawsSucceeded.Inc()
+	return outcome, nil
+}
+
+// drawConfig returns the config for one draw, with the request's archive
+// address in place of the configured one. The shared config is left alone,
+// so one request's archive address never reaches another's.
+func drawConfig(req *DrawRequest) *config.Config {
+	cfg := *config.GetConfig()
+	if req.ArchiveEmail != "" {
+		cfg.Notifier.ArchiveEmail = req.ArchiveEmail
+	}
+	return &cfg
+}
+
+// sendNotifications is notification.Send in a span of its own
+func sendNotifications(ctx context.Context, participants []*participant.Participant, cfg *config.Config) error {
+	ctx, span := tracing.Start(ctx, "SendNotifications", attribute.Int("participants", len(participants)))
+	err := notification.Send(ctx, participants, cfg)
+	tracing.End(span, err)
+	return err
+}
+
+// scheduleNotifications is notification.Schedule in a span of its own
+func scheduleNotifications(ctx context.Context, participants []*participant.Participant, cfg *config.Config, sendAt time.Time, sched *scheduler.Scheduler) error {
+	ctx, span := tracing.Start(ctx, "ScheduleNotifications", attribute.Int("participants", len(participants)))
+	err := notification.Schedule(ctx, participants, cfg, sendAt, sched)
+	tracing.End(span, err)
+	return err
+}
+
+// UploadResponse is the parsed and validated content of an uploaded file
+type UploadResponse struct {
+	Success      bool                       `json:"success"`
+	Participants []*participant.Participant `json:"participants"`
+	Validation   ValidationResponse         `json:"validation"`
+	Format       string                     `json:"format"`
+}
+
 // HandleUpload handles file upload for participant data
 // Supports JSON, YAML, TOML, CSV, and TSV formats
 func (s *Server) HandleUpload(w http.ResponseWriter, r *http.Request) {
@@ -192,6 +431,11 @@ func (s *Server) HandleUpload(w http.ResponseWriter, r *http.Request) {
 
 	// Parse multipart form (10MB max)
 	err := r.ParseMultipartForm(10 << 20)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		rejectBodyLimit(w, tooLarge.Limit)
+		return
+	}
 	if err != nil {
 		http.Error(w, "File too large or invalid", http.StatusBadRequest)
 		return
@@ -225,17 +469,12 @@ func (s *Server) HandleUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("Uploaded file: %s (format: %s, %d participants)", header.Filename, format, len(participants))
+	slog.InfoContext(r.Context(), "Uploaded participants", "file", header.Filename, "format", format, "participants", len(participants))
 
 	// Validate uploaded data
-	validation := draw.ValidateParticipants(participants)
-
-	response := struct {
-		Success      bool                       `json:"success"`
-		Participants []*participant.Participant `json:"participants"`
-		Validation   ValidationResponse         `json:"validation"`
-		Format       string                     `json:"format"`
-	}{
+	validation := validateParticipants(r.Context(), participants)
+
+	response := UploadResponse{
 		Success:      true,
 		Participants: participants,
 		Format:       string(format),
@@ -262,8 +501,7 @@ func (s *Server) HandleExport(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var participants []*ParticipantResponse
-	if err := json.NewDecoder(r.Body).Decode(&participants); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+	if !decodeJSON(w, r, &participants) {
 		return
 	}
 
@@ -304,9 +542,29 @@ func (s *Server) HandleTemplate(w http.ResponseWriter, r *http.Request) {
 
 // NotifierTypeInfo contains information about a specific notification type
 type NotifierTypeInfo struct {
-	Type           string   `json:"type"`
-	Accounts       []string `json:"accounts,omitempty"`
-	DefaultAccount string   `json:"default_account,omitempty"`
+	Type           string                 `json:"type"`
+	Description    string                 `json:"description,omitempty"`
+	Capabilities   *notifier.Capabilities `json:"capabilities,omitempty"`
+	Accounts       []string               `json:"accounts,omitempty"`
+	DefaultAccount string                 `json:"default_account,omitempty"`
+}
+
+// newNotifierTypeInfo describes a registered channel
+func newNotifierTypeInfo(channel notifier.Channel) NotifierTypeInfo {
+	capabilities := channel.Capabilities
+	return NotifierTypeInfo{
+		Type:         channel.Name,
+		Description:  channel.Description,
+		Capabilities: &capabilities,
+	}
+}
+
+// stdoutTypeInfo describes the always-available stdout channel
+func stdoutTypeInfo() NotifierTypeInfo {
+	if channel, ok := notifier.Lookup("stdout"); ok {
+		return newNotifierTypeInfo(channel)
+	}
+	return NotifierTypeInfo{Type: "stdout"}
 }
 
 // NotificationStatusResponse contains information about available notification types
@@ -327,16 +585,14 @@ func (s *Server) HandleStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cfg := config.GetConfig()
-	response := NotificationStatusResponse{
-		Available: []NotifierTypeInfo{{Type: "stdout"}}, // stdout always available
-	}
+	response := NotificationStatusResponse{}
 
 	// Check if external notifier service is configured
 	if cfg.Notifier.ServiceAddr != "" {
 		response.UsingNotifier = true
 
 		// Try to query the notifier service for available types
-		notifierTypes, healthy, status, details := getNotifierInfo(cfg.Notifier.ServiceAddr, cfg.Notifier.APIKey)
+		notifierTypes, healthy, status, details := getNotifierInfo(cfg)
 		response.NotifierHealthy = healthy
 		response.NotifierStatus = status
 		response.NotifierDetails = details
@@ -345,19 +601,26 @@ func (s *Server) HandleStatus(w http.ResponseWriter, r *http.Request) {
 			// Use types from notifier service
 			response.Available = notifierTypes
 		} else if healthy {
-			// Notifier is healthy but didn't report types, use defaults
-			response.Available = []NotifierTypeInfo{
-				{Type: "email"},
-				{Type: "slack"},
-				{Type: "stdout"},
+			// Notifier is healthy but didn't report types, assume every channel it supports
+			for _, channel := range notifier.Channels() {
+				if channel.ServiceType != "" {
+					response.Available = append(response.Available, newNotifierTypeInfo(channel))
+				}
 			}
+		} else {
+			// If notifier is not healthy, only stdout is known to work
+			response.Available = []NotifierTypeInfo{stdoutTypeInfo()}
 		}
-		// If notifier is not healthy, keep the basic types we detected
 	} else {
-		// Fallback: Check if SMTP is configured locally
-		if cfg.SMTP.Host != "" && cfg.SMTP.FromAddress != "" {
-			response.Available = append(response.Available, NotifierTypeInfo{Type: "email"})
-			response.SMTPConfigured = true
+		// Fallback: report every locally configured channel
+		for _, channel := range notifier.Channels() {
+			if !channel.Local() || channel.Configured(cfg) != nil {
+				continue
+			}
+			response.Available = append(response.Available, newNotifierTypeInfo(channel))
+			if channel.Name == "email" {
+				response.SMTPConfigured = true
+			}
 		}
 	}
 
@@ -365,72 +628,249 @@ func (s *Server) HandleStatus(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// getNotifierInfo queries the notifier service for available notification types
-func getNotifierInfo(serviceAddr string, apiKey string) ([]NotifierTypeInfo, bool, string, map[string]string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	// Add API key to context if provided
-	if apiKey != "" {
-		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", fmt.Sprintf("Bearer %s", apiKey))
-	}
-
-	conn, err := grpc.DialContext(ctx, serviceAddr,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock())
+// getNotifierInfo queries the notifier service for available notification
+// types over the shared connection
+func getNotifierInfo(cfg *config.Config) ([]NotifierTypeInfo, bool, string, map[string]string) {
+	client, err := notification.SharedNotifier(cfg)
 	if err != nil {
-		return nil, false, "unreachable", map[string]string{"error": err.Error()}
+		return nil, false, "error", map[string]string{"error": err.Error()}
 	}
-	defer conn.Close()
-
-	client := pb.NewNotifierServiceClient(conn)
 
 	// First check health
-	healthResp, err := client.HealthCheck(ctx, &pb.HealthCheckRequest{})
+	healthResp, err := client.HealthCheck()
 	if err != nil {
-		log.Printf("Notifier health check failed: %v", err)
+		slog.Warn("Notifier health check failed", "error", err)
+		if status.Code(err) == codes.Unavailable {
+			return nil, false, "unreachable", map[string]string{"error": err.Error()}
+		}
 		return nil, false, "error", map[string]string{"grpc_error": err.Error()}
 	}
 
 	// Then get available notifiers
-	notifiersResp, err := client.GetNotifiers(ctx, &pb.GetNotifiersRequest{})
+	notifiers, err := client.GetNotifiers()
 	if err != nil {
-		log.Printf("GetNotifiers failed: %v", err)
+		slog.Warn("Listing notifier channels failed", "error", err)
 		// Return health info but no notifiers
 		return nil, healthResp.Healthy, healthResp.Status, healthResp.Components
 	}
 
 	// Convert proto NotifierInfo to API NotifierTypeInfo
 	var notifierTypes []NotifierTypeInfo
-	for _, n := range notifiersResp.Notifiers {
-		// Convert the enum to lowercase string
-		typeStr := convertNotifierTypeEnum(n.Type)
-		if typeStr != "" {
-			notifierTypes = append(notifierTypes, NotifierTypeInfo{
-				Type:           typeStr,
-				Accounts:       n.Accounts,
-				DefaultAccount: n.DefaultAccount,
-			})
+	for _, n := range notifiers {
+		// Map the enum back to a registered channel
+		channel, ok := notifier.LookupServiceType(n.Type.String())
+		if !ok {
+			continue
 		}
+		info := newNotifierTypeInfo(channel)
+		info.Accounts = n.Accounts
+		info.DefaultAccount = n.DefaultAccount
+		notifierTypes = append(notifierTypes, info)
 	}
 
 	return notifierTypes, healthResp.Healthy, healthResp.Status, healthResp.Components
 }
 
-// convertNotifierTypeEnum converts a NotificationType enum to lowercase format
-func convertNotifierTypeEnum(notifType pb.NotificationType) string {
-	switch notifType {
-	case pb.NotificationType_NOTIFICATION_TYPE_EMAIL:
-		return "email"
-	case pb.NotificationType_NOTIFICATION_TYPE_SLACK:
-		return "slack"
-	case pb.NotificationType_NOTIFICATION_TYPE_NTFY:
-		return "ntfy"
-	case pb.NotificationType_NOTIFICATION_TYPE_STDOUT:
-		return "stdout"
-	default:
-		return ""
+// NotificationsResponse lists the live status of tracked notifications
+type NotificationsResponse struct {
+	Success       bool                           `json:"success"`
+	Notifications []*notification.DeliveryStatus `json:"notifications,omitempty"`
+	Error         string                         `json:"error,omitempty"`
+}
+
+// NotificationActionRequest names the notification to retry or cancel
+type NotificationActionRequest struct {
+	ID string `json:"id"`
+}
+
+// NotificationActionResponse reports a retry or cancel. ID is the
+// notification's ID afterwards, which a retry may change.
+type NotificationActionResponse struct {
+	Success bool   `json:"success"`
+	ID      string `json:"id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// HandleNotifications returns the delivery status of every notification the
+// caller's draws sent through the notifier service, newest first
+func (s *Server) HandleNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var tracked []notification.TrackedNotification
+	if s.tracker != nil {
+		var err error
+		if tracked, err = s.tracker.List(drawOwner(r.Context())); err != nil {
+			writeNotificationsError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	slices.Reverse(tracked)
+
+	statuses, err := notification.DeliveryStatuses(config.GetConfig(), tracked)
+	if err != nil {
+		writeNotificationsError(w, notificationErrorStatus(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(NotificationsResponse{Success: true, Notifications: statuses})
+}
+
+// HandleRetryNotification resends a tracked notification. The resend counts
+// against the daily notification cap.
+func (s *Server) HandleRetryNotification(w http.ResponseWriter, r *http.Request) {
+	s.handleNotificationAction(w, r, func(id string) (string, error) {
+		release, err := s.limits.reserveMessages(s.limits.organizer(r), 1)
+		if err != nil {
+			return "", err
+		}
+		newID, err := notification.RetryNotification(config.GetConfig(), id)
+		if err != nil {
+			release()
+			return "", err
+		}
+		if err := s.tracker.Replace(id, newID); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to record retried notification ID", "error", err)
+		}
+		return newID, nil
+	})
+}
+
+// HandleCancelNotification cancels a tracked notification that has not been sent
+func (s *Server) HandleCancelNotification(w http.ResponseWriter, r *http.Request) {
+	s.handleNotificationAction(w, r, func(id string) (string, error) {
+		return id, notification.CancelNotification(config.GetConfig(), id)
+	})
+}
+
+// handleNotificationAction runs action for a notification sent by one of the
+// caller's draws on this server. Other IDs are rejected so the endpoints
+// can't be used on other organizers' notifications or unrelated ones held by
+// a shared notifier service.
+func (s *Server) handleNotificationAction(w http.ResponseWriter, r *http.Request, action func(id string) (string, error)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req NotificationActionRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !s.isTracked(drawOwner(r.Context()), req.ID) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(NotificationActionResponse{Error: fmt.Sprintf("unknown notification %q", req.ID)})
+		return
+	}
+
+	id, err := action(req.ID)
+	var limitErr *limitError
+	if errors.As(err, &limitErr) {
+		writeLimitError(w, limitErr)
+		return
+	}
+	if err != nil {
+		slog.WarnContext(r.Context(), "Notification action failed", "notification_id", req.ID, "error", err)
+		w.WriteHeader(notificationErrorStatus(err))
+		json.NewEncoder(w).Encode(NotificationActionResponse{ID: req.ID, Error: err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(NotificationActionResponse{Success: true, ID: id})
+}
+
+// isTracked reports whether id was recorded by one of owner's draws on this
+// server
+func (s *Server) isTracked(owner, id string) bool {
+	if s.tracker == nil || id == "" {
+		return false
 	}
+	tracked, err := s.tracker.List(owner)
+	if err != nil {
+		slog.Error("Failed to read tracked notifications", "error", err)
+		return false
+	}
+	for _, t := range tracked {
+		if t.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationErrorStatus maps a status operation error to an HTTP status
+func notificationErrorStatus(err error) int {
+	if errors.Is(err, notification.ErrNoNotifierService) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadGateway
+}
+
+func writeNotificationsError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(NotificationsResponse{Error: err.Error()})
+}
+
+// notifierStatsTTL is how long notifier service statistics are reused
+const notifierStatsTTL = 15 * time.Second
+
+// statsCache holds the last notifier service statistics so refreshing the
+// status panel doesn't query the service every time
+type statsCache struct {
+	mu        sync.Mutex
+	stats     *notification.ServiceStats
+	fetchedAt time.Time
+}
+
+// get returns the cached statistics, calling fetch when they are older than
+// ttl. Concurrent callers wait for a single fetch.
+func (c *statsCache) get(ttl time.Duration, fetch func() (*notification.ServiceStats, error)) (*notification.ServiceStats, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stats != nil && time.Since(c.fetchedAt) < ttl {
+		return c.stats, c.fetchedAt, nil
+	}
+
+	stats, err := fetch()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	c.stats, c.fetchedAt = stats, time.Now().UTC()
+	return c.stats, c.fetchedAt, nil
+}
+
+// NotifierStatsResponse contains the notifier service's delivery statistics
+type NotifierStatsResponse struct {
+	Success   bool                       `json:"success"`
+	Stats     *notification.ServiceStats `json:"stats,omitempty"`
+	FetchedAt *time.Time                 `json:"fetched_at,omitempty"`
+	Error     string                     `json:"error,omitempty"`
+}
+
+// HandleNotifierStats returns sent, failed and pending counts and average
+// latency from the notifier service, cached for notifierStatsTTL
+func (s *Server) HandleNotifierStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats, fetchedAt, err := s.stats.get(notifierStatsTTL, func() (*notification.ServiceStats, error) {
+		return notification.Stats(config.GetConfig())
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(notificationErrorStatus(err))
+		json.NewEncoder(w).Encode(NotifierStatsResponse{Error: err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(NotifierStatsResponse{Success: true, Stats: stats, FetchedAt: &fetchedAt})
 }
 
 // DownloadRequest contains the participants and desired format
@@ -447,8 +887,7 @@ func (s *Server) HandleDownload(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req DownloadRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
@@ -482,48 +921,123 @@ func (s *Server) HandleDownload(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
-// Start starts the HTTP server
-func (s *Server) Start() error {
-	mux := http.NewServeMux()
+// Start serves the web UI and API, and SecretSantaService when GRPCAddr is
+// set, until ctx is canceled. It then shuts down gracefully, waiting up to
+// the shutdown timeout for draws that are sending notifications.
+func (s *Server) Start(ctx context.Context) error {
+	// Deferred first so it runs last, after the scheduler has stopped and
+	// can no longer be sending through the notifier connection
+	defer notification.CloseSharedNotifier()
 
-	// API endpoints
-	mux.HandleFunc("/api/validate", s.HandleValidate)
-	mux.HandleFunc("/api/draw", s.HandleDraw)
-	mux.HandleFunc("/api/upload", s.HandleUpload)
-	mux.HandleFunc("/api/download", s.HandleDownload)
-	mux.HandleFunc("/api/export", s.HandleExport)
-	mux.HandleFunc("/api/template", s.HandleTemplate)
-	mux.HandleFunc("/api/status", s.HandleStatus)
-
-	// Static files
-	fs := http.FileServer(http.Dir("internal/web/static"))
-	mux.Handle("/static/", http.StripPrefix("/static/", fs))
-
-	// Serve index.html for root
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/" {
-			http.NotFound(w, r)
-			return
+	// Resume deliveries scheduled before the last shutdown
+	sched, err := notification.NewScheduler(config.GetConfig())
+	if err != nil {
+		return fmt.Errorf("failed to load scheduled notifications: %w", err)
+	}
+	if pending := len(sched.Jobs()); pending > 0 {
+		slog.Info("Resuming scheduled notification jobs", "jobs", pending)
+	}
+	sched.Start()
+	defer sched.Stop()
+	s.scheduler = sched
+	s.tracker = notification.NewTracker(config.GetConfig().Notifier.TrackingPath)
+
+	cfg := config.GetConfig()
+	tlsConfig, err := serverTLSConfig(cfg.Server.TLS)
+	if err != nil {
+		return fmt.Errorf("failed to set up TLS: %w", err)
+	}
+	if s.Auth == nil && cfg.Auth.Enabled {
+		auth, err := NewAuthenticator(context.Background(), cfg.Auth)
+		if err != nil {
+			return fmt.Errorf("failed to set up authentication: %w", err)
 		}
-		http.ServeFile(w, r, "internal/web/static/index.html")
-	})
+		s.Auth = auth
+	}
+	if s.Auth == nil {
+		slog.Warn("Authentication is disabled: anyone who can reach the server can draw and send notifications", "addr", s.addr)
+	}
+
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+	httpServer := s.newHTTPServer(cfg.Server, tlsConfig)
+
+	var grpcServer *grpc.Server
+	if s.GRPCAddr != "" {
+		grpcLis, err := net.Listen("tcp", s.GRPCAddr)
+		if err != nil {
+			lis.Close()
+			return fmt.Errorf("failed to listen for gRPC on %s: %w", s.GRPCAddr, err)
+		}
+		opts := s.grpcServerOptions()
+		if tlsConfig != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+		grpcServer = grpc.NewServer(opts...)
+		pb.RegisterSecretSantaServiceServer(grpcServer, NewDrawService(s))
+		go func() {
+			slog.Info("Starting SecretSantaService", "addr", s.GRPCAddr)
+			if err := grpcServer.Serve(grpcLis); err != nil {
+				slog.Error("gRPC server stopped", "error", err)
+			}
+		}()
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		slog.Info("Starting web server", "addr", s.addr, "tls", tlsConfig != nil)
+		if tlsConfig != nil {
+			served <- httpServer.ServeTLS(lis, "", "")
+		} else {
+			served <- httpServer.Serve(lis)
+		}
+	}()
 
-	log.Printf("Starting web server on %s", s.addr)
-	return http.ListenAndServe(s.addr, corsMiddleware(mux))
+	select {
+	case err := <-served:
+		if grpcServer != nil {
+			grpcServer.Stop()
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, waiting for requests in progress", "timeout", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	return s.shutdown(shutdownCtx, httpServer, grpcServer)
 }
 
-// corsMiddleware adds CORS headers for development
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+// Handler returns the API, UI and static file routes. Each API route is
+// wrapped with its body size limit, its rate limits and, when authentication
+// is enabled, the role it needs.
+func (s *Server) Handler() http.Handler {
+	cfg := config.GetConfig().Server
+	mux := http.NewServeMux()
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+	// API endpoints
+	for _, route := range s.routes() {
+		limit := cfg.MaxBodyBytes
+		if route.upload {
+			limit = cfg.MaxUploadBytes
 		}
+		handler := route.handler
+		if route.rateLimited {
+			handler = s.limits.rateLimit(handler)
+		}
+		mux.Handle(route.path, instrumentRoute(route.path, limitBody(limit, s.Auth.require(route.role, handler))))
+	}
 
-		next.ServeHTTP(w, r)
-	})
+	if config.GetConfig().Metrics.Enabled {
+		mux.Handle("/metrics", metrics.Handler())
+	}
+
+	// UI
+	assets := newStaticAssets(s.StaticDir)
+	mux.Handle("/static/", instrumentRoute("/static/", assets))
+	mux.Handle("/", instrumentRoute("/", http.HandlerFunc(assets.ServeIndex)))
+
+	return requestLog(securityHeaders(cfg.HSTSMaxAge, corsMiddleware(cfg.AllowedOrigins, mux)))
 }
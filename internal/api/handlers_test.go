@@ -2,11 +2,26 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/igodwin/secretsanta/internal/metrics"
+	"github.com/igodwin/secretsanta/internal/notification"
+	"github.com/igodwin/secretsanta/internal/scheduler"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/event"
 	"github.com/igodwin/secretsanta/pkg/participant"
 )
 
@@ -152,3 +167,447 @@ func TestMethodNotAllowed(t *testing.T) {
 		}
 	}
 }
+
+func TestHandleDrawPreview(t *testing.T) {
+	server := NewServer(":8080")
+
+	drawRequest := DrawRequest{
+		Participants: []participant.Participant{
+			{Name: "Alice", NotificationType: "stdout", Exclusions: []string{}},
+			{Name: "Bob", NotificationType: "stdout", Exclusions: []string{}},
+		},
+		Preview: true,
+	}
+
+	body, _ := json.Marshal(drawRequest)
+	req := httptest.NewRequest(http.MethodPost, "/api/draw", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	server.HandleDraw(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var response DrawResponse
+	json.NewDecoder(w.Body).Decode(&response)
+
+	if len(response.Participants) != 0 {
+		t.Errorf("Expected no assignments in a preview, got %d", len(response.Participants))
+	}
+	if len(response.Previews) != 2 {
+		t.Fatalf("Expected 2 previews, got %d", len(response.Previews))
+	}
+	for _, preview := range response.Previews {
+		if len(preview.Messages) != 1 || preview.Messages[0].Channel != "stdout" {
+			t.Errorf("Unexpected messages for %s: %+v", preview.Participant, preview.Messages)
+		}
+	}
+}
+
+func TestHandleDrawArchiveEmailIsPerRequest(t *testing.T) {
+	cfg := config.GetConfig()
+	saved := cfg.Notifier.ArchiveEmail
+	t.Cleanup(func() { cfg.Notifier.ArchiveEmail = saved })
+	cfg.Notifier.ArchiveEmail = "configured@example.com"
+
+	drawRequest := DrawRequest{
+		Participants: []participant.Participant{
+			{Name: "Alice", NotificationType: "stdout", Exclusions: []string{}},
+			{Name: "Bob", NotificationType: "stdout", Exclusions: []string{}},
+		},
+		ArchiveEmail: "request@example.com",
+		Preview:      true,
+	}
+	body, _ := json.Marshal(drawRequest)
+	w := httptest.NewRecorder()
+	NewServer(":8080").HandleDraw(w, httptest.NewRequest(http.MethodPost, "/api/draw", bytes.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if cfg.Notifier.ArchiveEmail != "configured@example.com" {
+		t.Errorf("Expected the shared config to keep its archive address, got %q", cfg.Notifier.ArchiveEmail)
+	}
+	if got := drawConfig(&drawRequest).Notifier.ArchiveEmail; got != "request@example.com" {
+		t.Errorf("Expected the draw's config to use the request's archive address, got %q", got)
+	}
+}
+
+func TestHandleDrawSendAtInPast(t *testing.T) {
+	server := NewServer(":8080")
+
+	sendAt := time.Now().Add(-time.Hour)
+	drawRequest := DrawRequest{
+		Participants: []participant.Participant{
+			{Name: "Alice", ContactInfo: []string{"alice@example.com"}, Exclusions: []string{}},
+			{Name: "Bob", ContactInfo: []string{"bob@example.com"}, Exclusions: []string{}},
+		},
+		SendAt: &sendAt,
+	}
+
+	body, _ := json.Marshal(drawRequest)
+	req := httptest.NewRequest(http.MethodPost, "/api/draw", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	server.HandleDraw(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestHandleDrawScheduled(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+
+	store := &scheduler.FileStore{Path: filepath.Join(t.TempDir(), "scheduled.json")}
+	sched, err := scheduler.New(store, func(a scheduler.Assignment) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{addr: ":8080", scheduler: sched}
+
+	sendAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	drawRequest := DrawRequest{
+		Participants: []participant.Participant{
+			{Name: "Alice", NotificationType: "stdout", Exclusions: []string{}},
+			{Name: "Bob", NotificationType: "stdout", Exclusions: []string{}},
+		},
+		SendAt: &sendAt,
+	}
+
+	body, _ := json.Marshal(drawRequest)
+	req := httptest.NewRequest(http.MethodPost, "/api/draw", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	server.HandleDraw(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response DrawResponse
+	json.NewDecoder(w.Body).Decode(&response)
+
+	if response.ScheduledFor == nil || !response.ScheduledFor.Equal(sendAt) {
+		t.Errorf("Expected scheduled_for %v, got %v", sendAt, response.ScheduledFor)
+	}
+	for _, p := range response.Participants {
+		if p.DeliveredVia != "" {
+			t.Errorf("Expected %s not to be notified yet, got delivered via %s", p.Name, p.DeliveredVia)
+		}
+	}
+
+	jobs, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || len(jobs[0].Assignments) != 2 || !jobs[0].SendAt.Equal(sendAt) {
+		t.Errorf("Expected one persisted job for 2 participants, got %+v", jobs)
+	}
+}
+
+func TestHandleDrawEventReminders(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+
+	store := &scheduler.FileStore{Path: filepath.Join(t.TempDir(), "scheduled.json")}
+	sched, err := scheduler.New(store, func(a scheduler.Assignment) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{addr: ":8080", scheduler: sched}
+
+	drawRequest := DrawRequest{
+		Participants: []participant.Participant{
+			{Name: "Alice", NotificationType: "stdout", Exclusions: []string{}},
+			{Name: "Bob", NotificationType: "stdout", Exclusions: []string{}},
+			{Name: "Carol", NotificationType: "stdout", Exclusions: []string{}, NoReminders: true},
+		},
+		Event: &event.Event{Name: "Office Party", ExchangeDate: time.Now().AddDate(0, 0, 14)},
+	}
+
+	body, _ := json.Marshal(drawRequest)
+	req := httptest.NewRequest(http.MethodPost, "/api/draw", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	server.HandleDraw(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response DrawResponse
+	json.NewDecoder(w.Body).Decode(&response)
+
+	// Default one-week and one-day reminders for the two participants who opted in
+	if response.RemindersScheduled != 4 {
+		t.Errorf("Expected 4 reminders, got %d", response.RemindersScheduled)
+	}
+	if jobs := sched.Jobs(); len(jobs) != 2 || jobs[0].Kind != scheduler.KindReminder {
+		t.Errorf("Expected two reminder jobs, got %+v", jobs)
+	}
+}
+
+func TestHandleDrawInvalidEvent(t *testing.T) {
+	server := NewServer(":8080")
+
+	drawRequest := DrawRequest{
+		Participants: []participant.Participant{
+			{Name: "Alice", NotificationType: "stdout", Exclusions: []string{}},
+			{Name: "Bob", NotificationType: "stdout", Exclusions: []string{}},
+		},
+		Event: &event.Event{Name: "Office Party"},
+	}
+
+	body, _ := json.Marshal(drawRequest)
+	req := httptest.NewRequest(http.MethodPost, "/api/draw", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	server.HandleDraw(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an event without a date, got %d", w.Code)
+	}
+}
+
+func TestHandleNotificationsWithoutNotifierService(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+
+	tracker := notification.NewTracker(filepath.Join(t.TempDir(), "notifications.json"))
+	tracked := &participant.Participant{Name: "Alice", NotificationID: "n-1"}
+	if err := tracker.Record("", []*participant.Participant{tracked}); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{addr: ":8080", tracker: tracker}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/notifications", nil)
+	w := httptest.NewRecorder()
+	server.HandleNotifications(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHandleDrawIgnoresClientNotificationIDs(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+
+	server := NewServer(":8080")
+	server.tracker = notification.NewTracker(filepath.Join(t.TempDir(), "notifications.json"))
+	drawRequest := DrawRequest{
+		Participants: []participant.Participant{
+			{Name: "Alice", NotificationType: "stdout", NotificationID: "victim-123", DeliveredVia: "email"},
+			{Name: "Bob", NotificationType: "stdout"},
+		},
+	}
+
+	body, _ := json.Marshal(drawRequest)
+	w := httptest.NewRecorder()
+	server.HandleDraw(w, httptest.NewRequest(http.MethodPost, "/api/draw", bytes.NewReader(body)))
+
+	var response DrawResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || response.Participants[0].NotificationID != "" || response.Participants[0].DeliveredVia != "stdout" {
+		t.Errorf("Expected the client's delivery results to be dropped, got %d %+v", w.Code, response.Participants)
+	}
+	if server.isTracked("", "victim-123") {
+		t.Error("Expected a client-supplied notification ID not to be tracked")
+	}
+}
+
+func TestNotificationsAreScopedToOrganizer(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+
+	tracker := notification.NewTracker(filepath.Join(t.TempDir(), "notifications.json"))
+	if err := tracker.Record("alice", []*participant.Participant{{Name: "Carol", NotificationID: "n-1"}}); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{addr: ":8080", tracker: tracker}
+	as := func(username string, r *http.Request) *http.Request {
+		return r.WithContext(context.WithValue(r.Context(), sessionKey{}, &session{username: username, role: RoleOrganizer}))
+	}
+
+	if !server.isTracked("alice", "n-1") || server.isTracked("bob", "n-1") || server.isTracked("", "n-1") {
+		t.Error("Expected n-1 to be tracked for alice only")
+	}
+
+	for _, path := range []string{"/api/notifications/retry", "/api/notifications/cancel"} {
+		body, _ := json.Marshal(NotificationActionRequest{ID: "n-1"})
+		w := httptest.NewRecorder()
+		req := as("bob", httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+		if path == "/api/notifications/retry" {
+			server.HandleRetryNotification(w, req)
+		} else {
+			server.HandleCancelNotification(w, req)
+		}
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected %s of another organizer's notification to be 404, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestHandleRetryUnknownNotification(t *testing.T) {
+	tracker := notification.NewTracker(filepath.Join(t.TempDir(), "notifications.json"))
+	server := &Server{addr: ":8080", tracker: tracker}
+
+	body, _ := json.Marshal(NotificationActionRequest{ID: "not-ours"})
+	req := httptest.NewRequest(http.MethodPost, "/api/notifications/retry", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	server.HandleRetryNotification(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an untracked notification, got %d", w.Code)
+	}
+}
+
+func TestHandleRetryCountsAgainstCap(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+
+	tracker := notification.NewTracker(filepath.Join(t.TempDir(), "notifications.json"))
+	if err := tracker.Record("", []*participant.Participant{{Name: "Alice", NotificationID: "n-1"}}); err != nil {
+		t.Fatal(err)
+	}
+	server := &Server{addr: ":8080", tracker: tracker, limits: newLimiter(config.LimitsConfig{DailyNotifications: 1})}
+	retry := func() int {
+		body, _ := json.Marshal(NotificationActionRequest{ID: "n-1"})
+		w := httptest.NewRecorder()
+		server.HandleRetryNotification(w, httptest.NewRequest(http.MethodPost, "/api/notifications/retry", bytes.NewReader(body)))
+		return w.Code
+	}
+
+	// Without a notifier service the retry fails, so it gives its slot back
+	for i := 0; i < 2; i++ {
+		if code := retry(); code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status 503, got %d", code)
+		}
+	}
+
+	server.limits.notifications.reserve("ip:192.0.2.1", 1, time.Now())
+	if code := retry(); code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 once the daily cap is spent, got %d", code)
+	}
+}
+
+func TestStatsCacheReusesRecentStats(t *testing.T) {
+	var cache statsCache
+	fetches := 0
+	fetch := func() (*notification.ServiceStats, error) {
+		fetches++
+		return &notification.ServiceStats{TotalSent: int64(fetches)}, nil
+	}
+
+	first, _, _ := cache.get(time.Minute, fetch)
+	second, _, _ := cache.get(time.Minute, fetch)
+	if fetches != 1 || second != first {
+		t.Errorf("Expected one fetch within the TTL, got %d", fetches)
+	}
+
+	if _, _, err := cache.get(0, fetch); err != nil || fetches != 2 {
+		t.Errorf("Expected a refetch once stale, got %d fetches (%v)", fetches, err)
+	}
+}
+
+func TestHandleNotifierStatsWithoutNotifierService(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+	server := NewServer(":8080")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/notifier/stats", nil)
+	w := httptest.NewRecorder()
+	server.HandleNotifierStats(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	cfg := config.GetConfig()
+	saved := *cfg
+	t.Cleanup(func() { *cfg = saved })
+
+	cfg.Metrics.Enabled = false
+	w := httptest.NewRecorder()
+	NewServer(":8080").Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if strings.Contains(w.Body.String(), "secretsanta_") {
+		t.Error("Expected /metrics to be off when metrics are disabled")
+	}
+
+	cfg.Metrics.Enabled = true
+	handler := NewServer(":8080").Handler()
+	succeeded := testutil.ToFloat64(metrics.DrawsSucceeded)
+
+	body, _ := json.Marshal(DrawRequest{
+		Participants: []participant.Participant{
+			{Name: "Alice", ContactInfo: []string{"alice@example.com"}},
+			{Name: "Bob", ContactInfo: []string{"bob@example.com"}},
+			{Name: "Carol", ContactInfo: []string{"carol@example.com"}},
+		},
+	})
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/draw", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the draw to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := testutil.ToFloat64(metrics.DrawsSucceeded) - succeeded; got != 1 {
+		t.Errorf("Expected one successful draw counted, got %v", got)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("BREW", "/api/draw", nil))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected /metrics to be served, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), `method="BREW"`) {
+		t.Error("Expected an unknown method to be counted as other")
+	}
+	for _, want := range []string{
+		`secretsanta_http_requests_total{code="200",method="POST",route="/api/draw"}`,
+		`method="other",route="/api/draw"`,
+		`secretsanta_draw_duration_seconds_count{participants="1-10"}`,
+		`secretsanta_notifications_total{channel="stdout",outcome="sent"}`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("Expected %s in the metrics", want)
+		}
+	}
+}
+
+func TestDrawSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	body, _ := json.Marshal(DrawRequest{
+		Participants: []participant.Participant{
+			{Name: "Alice", ContactInfo: []string{"alice@example.com"}},
+			{Name: "Bob", ContactInfo: []string{"bob@example.com"}},
+			{Name: "Carol", ContactInfo: []string{"carol@example.com"}},
+		},
+	})
+	w := httptest.NewRecorder()
+	NewServer(":8080").Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/draw", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the draw to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	spans := make(map[string]tracetest.SpanStub)
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	root, ok := spans["POST /api/draw"]
+	if !ok {
+		t.Fatalf("Expected a span for the handler, got %v", spans)
+	}
+	for _, name := range []string{"ValidateParticipants", "Draw", "SendNotifications"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("Expected a %s span", name)
+			continue
+		}
+		if span.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("Expected %s to be a child of the handler span", name)
+		}
+	}
+}
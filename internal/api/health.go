@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/igodwin/secretsanta/internal/notification"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+)
+
+// readinessTTL is how long notifier service and SMTP checks are reused
+const readinessTTL = 15 * time.Second
+
+// smtpDialTimeout bounds the readiness check's connection to the SMTP server
+const smtpDialTimeout = 3 * time.Second
+
+// componentOK is the status of a healthy component
+const componentOK = "ok"
+
+// HealthResponse reports whether the server is live or ready. Components
+// maps each checked part to "ok" or a word for what is wrong with it, like
+// "unreachable". Error details are logged rather than returned, since the
+// probe is unauthenticated.
+type HealthResponse struct {
+	Healthy    bool              `json:"healthy"`
+	Status     string            `json:"status"`
+	Components map[string]string `json:"components,omitempty"`
+	// CheckedAt is when the notifier service or SMTP server was last checked
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+}
+
+// HandleHealthz answers as long as the server is serving requests, without
+// checking anything else
+func (s *Server) HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(HealthResponse{Healthy: true, Status: componentOK})
+}
+
+// HandleReadyz reports whether the server can take draws: the config was
+// read, storage is writable and the notifier service or SMTP server is
+// reachable. Remote checks are cached for readinessTTL and refreshed in the
+// background, so a slow notifier doesn't make the probe time out.
+func (s *Server) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cfg := config.GetConfig()
+	components := map[string]string{"config": componentOK}
+	if err := config.LoadError(); err != nil {
+		components["config"] = componentFailed("config", "unreadable", err)
+	}
+
+	usingService := cfg.Notifier.ServiceAddr != ""
+	if usingService {
+		components["tracking_store"] = checkWritable("tracking_store", cfg.Notifier.TrackingPath)
+	} else {
+		components["scheduler_store"] = checkWritable("scheduler_store", cfg.Scheduler.StorePath)
+	}
+
+	remote, checkedAt := s.readiness.get(readinessTTL, func() map[string]string {
+		return checkRemote(cfg, usingService)
+	})
+	for name, status := range remote {
+		components[name] = status
+	}
+
+	response := HealthResponse{Healthy: true, Status: componentOK, Components: components}
+	if len(remote) > 0 {
+		response.CheckedAt = &checkedAt
+	}
+	for _, status := range components {
+		if status != componentOK {
+			response.Healthy, response.Status = false, "not ready"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !response.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// componentFailed logs why the named component failed its check and
+// returns status for the response
+func componentFailed(name, status string, err error) string {
+	slog.Warn("Readiness check failed", "component", name, "status", status, "error", err)
+	return status
+}
+
+// checkWritable reports whether a file can be created next to path, where
+// the scheduler or tracker saves its state
+func checkWritable(name, path string) string {
+	if path == "" {
+		return componentOK
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".readyz-*")
+	if err != nil {
+		return componentFailed(name, "not writable", err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return componentOK
+}
+
+// checkRemote checks the notifier service, or the SMTP server when email is
+// sent in process. Nothing is checked when neither is configured.
+func checkRemote(cfg *config.Config, usingService bool) map[string]string {
+	if usingService {
+		client, err := notification.SharedNotifier(cfg)
+		if err != nil {
+			return map[string]string{"notifier": componentFailed("notifier", "error", err)}
+		}
+		resp, err := client.HealthCheck()
+		switch {
+		case err != nil:
+			return map[string]string{"notifier": componentFailed("notifier", "unreachable", err)}
+		case !resp.Healthy:
+			return map[string]string{"notifier": componentFailed("notifier", "unhealthy", errors.New(resp.Status))}
+		}
+		return map[string]string{"notifier": componentOK}
+	}
+
+	if email, ok := notifier.Lookup("email"); !ok || email.Configured(cfg) != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(cfg.SMTP.Host, cfg.SMTP.Port), smtpDialTimeout)
+	if err != nil {
+		return map[string]string{"smtp": componentFailed("smtp", "unreachable", err)}
+	}
+	conn.Close()
+	return map[string]string{"smtp": componentOK}
+}
+
+// readinessCache holds the last remote readiness checks. The first check
+// runs while the caller waits; later ones serve the cached result and
+// refresh it in the background once it is older than the ttl.
+type readinessCache struct {
+	mu         sync.Mutex
+	components map[string]string
+	checkedAt  time.Time
+	refreshing bool
+}
+
+func (c *readinessCache) get(ttl time.Duration, check func() map[string]string) (map[string]string, time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.checkedAt.IsZero() {
+		c.components, c.checkedAt = check(), time.Now().UTC()
+		return c.components, c.checkedAt
+	}
+
+	if time.Since(c.checkedAt) >= ttl && !c.refreshing {
+		c.refreshing = true
+		go func() {
+			components := check()
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			for name, status := range components {
+				if status != c.components[name] {
+					slog.Info("Readiness changed", "component", name, "status", status)
+				}
+			}
+			c.components, c.checkedAt, c.refreshing = components, time.Now().UTC(), false
+		}()
+	}
+	return c.components, c.checkedAt
+}
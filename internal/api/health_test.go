@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+)
+
+func TestHandleHealthz(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewServer(":8080").Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	var response HealthResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if w.Code != http.StatusOK || !response.Healthy {
+		t.Errorf("Expected a healthy 200, got %d %+v", w.Code, response)
+	}
+}
+
+func TestHandleReadyz(t *testing.T) {
+	cfg := config.GetConfig()
+	saved := *cfg
+	t.Cleanup(func() { *cfg = saved })
+
+	readyz := func() (int, HealthResponse) {
+		w := httptest.NewRecorder()
+		NewServer(":8080").HandleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var response HealthResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+
+	cfg.Scheduler.StorePath = filepath.Join(t.TempDir(), "scheduled.json")
+	if code, response := readyz(); code != http.StatusOK || response.Components["scheduler_store"] != componentOK {
+		t.Errorf("Expected ready with a writable store, got %d %+v", code, response)
+	}
+
+	// The probe is unauthenticated, so the reason is logged, not returned
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	cfg.Scheduler.StorePath = filepath.Join(t.TempDir(), "missing", "scheduled.json")
+	if code, response := readyz(); code != http.StatusServiceUnavailable || response.Components["scheduler_store"] != "not writable" {
+		t.Errorf("Expected not ready without a store directory, got %d %+v", code, response)
+	}
+	if !strings.Contains(logs.String(), "component=scheduler_store") || !strings.Contains(logs.String(), "no such file or directory") {
+		t.Errorf("Expected the store error to be logged, got %q", logs.String())
+	}
+
+	// A closed port stands in for an SMTP server that is down
+	lis, _ := net.Listen("tcp", "127.0.0.1:0")
+	host, port, _ := net.SplitHostPort(lis.Addr().String())
+	lis.Close()
+	cfg.Scheduler.StorePath = filepath.Join(t.TempDir(), "scheduled.json")
+	cfg.SMTP = config.SMTPConfig{Host: host, Port: port, FromAddress: "santa@example.com"}
+	code, response := readyz()
+	if code != http.StatusServiceUnavailable || response.Components["smtp"] != "unreachable" || response.CheckedAt == nil {
+		t.Errorf("Expected not ready with SMTP down, got %d %+v", code, response)
+	}
+}
+
+func TestReadinessCacheRefreshesInBackground(t *testing.T) {
+	var cache readinessCache
+	var checks atomic.Int32
+	check := func() map[string]string {
+		if checks.Add(1) > 1 {
+			return map[string]string{"notifier": componentOK}
+		}
+		return map[string]string{"notifier": "unreachable"}
+	}
+
+	if got, _ := cache.get(time.Hour, check); got["notifier"] != "unreachable" {
+		t.Fatalf("Expected the first check's result, got %v", got)
+	}
+	if got, _ := cache.get(time.Hour, check); got["notifier"] != "unreachable" || checks.Load() != 1 {
+		t.Errorf("Expected a fresh result to be reused, got %v after %d checks", got, checks.Load())
+	}
+
+	// Once stale, the old result is served while the check runs again
+	if got, _ := cache.get(0, check); got["notifier"] != "unreachable" {
+		t.Errorf("Expected the stale result while refreshing, got %v", got)
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		got, _ := cache.get(time.Hour, check)
+		if got["notifier"] == componentOK {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the refreshed result, got %v", got)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/igodwin/secretsanta/internal/logging"
+	"github.com/igodwin/secretsanta/internal/metrics"
+	"github.com/igodwin/secretsanta/internal/tracing"
+)
+
+// Rejection codes returned by the middleware
+const (
+	rejectOriginNotAllowed   = "origin_not_allowed"
+	rejectBodyTooLarge       = "body_too_large"
+	rejectAuthRequired       = "authentication_required"
+	rejectInvalidCSRFToken   = "invalid_csrf_token"
+	rejectRoleRequired       = "role_required"
+	rejectInvalidCredentials = "invalid_credentials"
+	rejectRateLimited        = "rate_limited"
+	rejectNotificationCap    = "notification_cap_reached"
+)
+
+// contentSecurityPolicy allows only the server's own scripts. Inline styles
+// are allowed because the UI sets style attributes in rendered HTML.
+const contentSecurityPolicy = "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; connect-src 'self'; object-src 'none'; base-uri 'none'; form-action 'self'; frame-ancestors 'none'"
+
+const (
+	corsAllowedMethods = "GET, POST, OPTIONS"
+	corsAllowedHeaders = "Content-Type, " + csrfHeader + ", " + requestIDHeader
+	corsMaxAge         = "600"
+)
+
+// Rejection is the JSON body of a request refused before it reached its
+// handler. Code is stable for programs; Error is for people.
+type Rejection struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+func writeRejection(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Rejection{Error: message, Code: code})
+}
+
+// requestIDHeader carries the request ID, from a proxy or back to the client
+const requestIDHeader = "X-Request-ID"
+
+// requestLog gives each request an ID, kept from X-Request-ID when a proxy
+// sent a valid one, and logs the request once it completes. API requests
+// are logged at info, static files at debug.
+func requestLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !logging.ValidRequestID(id) {
+			id = logging.NewRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx := logging.WithRequestID(r.Context(), id)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			level = slog.LevelDebug
+		}
+		slog.LogAttrs(ctx, level, "Request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Duration("duration", time.Since(start)))
+	})
+}
+
+// instrumentRoute counts, times and traces requests to the route registered
+// as pattern. Requests rejected by auth, body or rate limits are included.
+func instrumentRoute(pattern string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.StartHTTP(r, pattern)
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		tracing.EndHTTP(span, rec.status)
+		method := methodLabel(r.Method)
+		metrics.HTTPRequests.WithLabelValues(pattern, method, strconv.Itoa(rec.status)).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(pattern, method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// methodLabel is method for the standard HTTP methods and "other" for
+// anything else, so clients can't create a metric series per made-up method
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
+
+// statusRecorder remembers the status code written through it
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// securityHeaders sets the CSP and other hardening headers on every
+// response. HSTS is only sent over HTTPS, including behind a TLS-terminating
+// proxy, and is disabled by a zero hstsMaxAge.
+func securityHeaders(hstsMaxAge time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Content-Security-Policy", contentSecurityPolicy)
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("Referrer-Policy", "same-origin")
+		h.Set("Cross-Origin-Opener-Policy", "same-origin")
+		if hstsMaxAge > 0 && (r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https") {
+			h.Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d; includeSubDomains", int(hstsMaxAge.Seconds())))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsMiddleware lets the listed origins call the API from the browser,
+// with cookies. "*" allows any origin, without cookies. Cross-origin
+// preflights and unsafe requests from other origins are rejected, so
+// another site can't post to the API even without authentication.
+func corsMiddleware(allowedOrigins []string, next http.Handler) http.Handler {
+	wildcard := slices.Contains(allowedOrigins, "*")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || sameOrigin(origin, r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+
+		allowed := wildcard || slices.Contains(allowedOrigins, origin)
+		if !allowed {
+			if r.Method == http.MethodOptions || !safeMethod(r.Method) {
+				writeRejection(w, http.StatusForbidden, rejectOriginNotAllowed, fmt.Sprintf("Origin %s is not allowed", origin))
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if wildcard {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		w.Header().Set("Access-Control-Expose-Headers", requestIDHeader)
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			w.Header().Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sameOrigin reports whether origin is the host r was sent to
+func sameOrigin(origin string, r *http.Request) bool {
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// limitBody rejects request bodies larger than limit bytes with 413. Bodies
+// without a Content-Length are cut off at the limit while being read.
+func limitBody(limit int64, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			rejectBodyLimit(w, limit)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next(w, r)
+	}
+}
+
+func rejectBodyLimit(w http.ResponseWriter, limit int64) {
+	writeRejection(w, http.StatusRequestEntityTooLarge, rejectBodyTooLarge, fmt.Sprintf("Request body is larger than %d bytes", limit))
+}
+
+// decodeJSON decodes r's body into v. On failure it writes a 413 rejection
+// for bodies over the limit, or a 400, and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		rejectBodyLimit(w, tooLarge.Limit)
+		return false
+	}
+	http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+	return false
+}
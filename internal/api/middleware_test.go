@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igodwin/secretsanta/internal/logging"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name            string
+		allowed         []string
+		method          string
+		origin          string
+		wantStatus      int
+		wantAllowOrigin string
+		wantCredentials bool
+	}{
+		{name: "no origin", method: http.MethodPost, wantStatus: http.StatusOK},
+		{name: "same origin", method: http.MethodPost, origin: "http://example.com", wantStatus: http.StatusOK},
+		{name: "allowed preflight", allowed: []string{"https://app.example.org"}, method: http.MethodOptions, origin: "https://app.example.org",
+			wantStatus: http.StatusNoContent, wantAllowOrigin: "https://app.example.org", wantCredentials: true},
+		{name: "allowed post", allowed: []string{"https://app.example.org"}, method: http.MethodPost, origin: "https://app.example.org",
+			wantStatus: http.StatusOK, wantAllowOrigin: "https://app.example.org", wantCredentials: true},
+		{name: "other origin preflight", allowed: []string{"https://app.example.org"}, method: http.MethodOptions, origin: "https://evil.example",
+			wantStatus: http.StatusForbidden},
+		{name: "other origin post", method: http.MethodPost, origin: "https://evil.example", wantStatus: http.StatusForbidden},
+		{name: "other origin get", method: http.MethodGet, origin: "https://evil.example", wantStatus: http.StatusOK},
+		{name: "wildcard", allowed: []string{"*"}, method: http.MethodPost, origin: "https://evil.example",
+			wantStatus: http.StatusOK, wantAllowOrigin: "*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "http://example.com/api/draw", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+
+			corsMiddleware(tt.allowed, ok).ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantAllowOrigin {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tt.wantAllowOrigin, got)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCredentials {
+				t.Errorf("Expected credentials allowed %v, got %v", tt.wantCredentials, got)
+			}
+			if w.Code == http.StatusForbidden {
+				var rejection Rejection
+				json.NewDecoder(w.Body).Decode(&rejection)
+				if rejection.Code != rejectOriginNotAllowed {
+					t.Errorf("Expected code %s, got %+v", rejectOriginNotAllowed, rejection)
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	handler := securityHeaders(time.Hour, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if csp := w.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "script-src 'self'") {
+		t.Errorf("Expected a CSP restricting scripts, got %q", csp)
+	}
+	if got := w.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("Expected nosniff, got %q", got)
+	}
+	if got := w.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("Expected no HSTS over plain HTTP, got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	handler.ServeHTTP(w, req)
+	if got := w.Header().Get("Strict-Transport-Security"); got != "max-age=3600; includeSubDomains" {
+		t.Errorf("Expected HSTS behind an HTTPS proxy, got %q", got)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	server := NewServer(":8080")
+	body := `[{"name":"Alice","notification_type":"stdout"},{"name":"Bob","notification_type":"stdout"}]`
+
+	tests := []struct {
+		name       string
+		limit      int64
+		chunked    bool
+		wantStatus int
+	}{
+		{"within limit", 1024, false, http.StatusOK},
+		{"content length over limit", 16, false, http.StatusRequestEntityTooLarge},
+		{"chunked body over limit", 16, true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reader io.Reader = strings.NewReader(body)
+			if tt.chunked {
+				// Hide the length so only the limited reader can catch it
+				reader = io.MultiReader(reader)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/validate", reader)
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+
+			limitBody(tt.limit, server.HandleValidate)(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, w.Code, w.Body)
+			}
+			if w.Code == http.StatusRequestEntityTooLarge {
+				var rejection Rejection
+				json.NewDecoder(w.Body).Decode(&rejection)
+				if rejection.Code != rejectBodyTooLarge {
+					t.Errorf("Expected code %s, got %+v", rejectBodyTooLarge, rejection)
+				}
+			}
+		})
+	}
+}
+
+func TestUploadBodyLimit(t *testing.T) {
+	server := NewServer(":8080")
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "participants.json")
+	part.Write(bytes.Repeat([]byte(" "), 4096))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/upload", io.MultiReader(&body))
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+
+	limitBody(1024, server.HandleUpload)(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestRequestLog(t *testing.T) {
+	var seen string
+	handler := requestLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	id := w.Header().Get(requestIDHeader)
+	if !logging.ValidRequestID(id) || seen != id {
+		t.Errorf("Expected a generated request ID in the header and context, got %q and %q", id, seen)
+	}
+
+	for header, want := range map[string]bool{"proxy-abc.123": true, "bad id\n": false, strings.Repeat("a", 65): false} {
+		req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+		req.Header.Set(requestIDHeader, header)
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if got := w.Header().Get(requestIDHeader) == header; got != want {
+			t.Errorf("Request ID %q kept = %v, want %v", header, got, want)
+		}
+	}
+}
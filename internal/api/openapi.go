@@ -0,0 +1,426 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+// apiVersion is the version of the REST API reported in the OpenAPI document
+const apiVersion = "1.0.0"
+
+// route is a REST API endpoint. The mux and the OpenAPI document are both
+// built from the route table, so the document lists every endpoint.
+type route struct {
+	path    string
+	method  string
+	summary string
+	handler http.HandlerFunc
+	// request and response are zero values of the JSON body types, or nil
+	request  any
+	response any
+	// errorStatuses return response with its error field set; other errors
+	// are plain text
+	errorStatuses []int
+	// textStatuses are further plain text errors, rejectStatuses further
+	// Rejection errors
+	textStatuses   []int
+	rejectStatuses []int
+	// query lists required query parameters
+	query []string
+	// upload is set for multipart file uploads, download for file responses
+	upload   bool
+	download bool
+	// role is needed to call the route when authentication is enabled;
+	// empty routes are public
+	role string
+	// redirect is set for browser login routes that answer with a redirect
+	redirect bool
+	// rateLimited routes are subject to the per-IP and per-account limits
+	rateLimited bool
+}
+
+func (s *Server) routes() []route {
+	return []route{
+		{
+			path: "/api/validate", role: RoleViewer, method: http.MethodPost, handler: s.HandleValidate,
+			summary:  "Check whether a draw is possible without drawing",
+			request:  []*participant.Participant{},
+			response: ValidationResponse{},
+		},
+		{
+			path: "/api/draw", role: RoleOrganizer, method: http.MethodPost, handler: s.HandleDraw,
+			summary:       "Draw names and send, schedule or preview the notifications",
+			rateLimited:   true,
+			request:       DrawRequest{},
+			response:      DrawResponse{},
+			errorStatuses: []int{http.StatusBadRequest, http.StatusInternalServerError},
+		},
+		{
+			path: "/api/upload", role: RoleViewer, method: http.MethodPost, handler: s.HandleUpload,
+			summary:     "Parse and validate a participants file",
+			upload:      true,
+			response:    UploadResponse{},
+			rateLimited: true,
+		},
+		{
+			path: "/api/download", role: RoleViewer, method: http.MethodPost, handler: s.HandleDownload,
+			summary:  "Export participants as a json, yaml, toml, csv or tsv file",
+			request:  DownloadRequest{},
+			download: true,
+		},
+		{
+			path: "/api/export", role: RoleViewer, method: http.MethodPost, handler: s.HandleExport,
+			summary:  "Download draw results as a JSON attachment",
+			request:  []*ParticipantResponse{},
+			response: []*ParticipantResponse{},
+		},
+		{
+			path: "/api/template", role: RoleViewer, method: http.MethodGet, handler: s.HandleTemplate,
+			summary:  "Download a sample participants file",
+			query:    []string{"format"},
+			download: true,
+		},
+		{
+			path: "/api/status", role: RoleViewer, method: http.MethodGet, handler: s.HandleStatus,
+			summary:  "List the available notification channels and notifier service health",
+			response: NotificationStatusResponse{},
+		},
+		{
+			path: "/api/notifications", role: RoleOrganizer, method: http.MethodGet, handler: s.HandleNotifications,
+			summary:       "Delivery status of notifications sent through the notifier service",
+			response:      NotificationsResponse{},
+			errorStatuses: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable},
+		},
+		{
+			path: "/api/notifications/retry", role: RoleOrganizer, method: http.MethodPost, handler: s.HandleRetryNotification,
+			summary:       "Resend a failed notification",
+			request:       NotificationActionRequest{},
+			response:      NotificationActionResponse{},
+			errorStatuses: []int{http.StatusNotFound, http.StatusBadGateway, http.StatusServiceUnavailable},
+			rateLimited:   true,
+		},
+		{
+			path: "/api/notifications/cancel", role: RoleOrganizer, method: http.MethodPost, handler: s.HandleCancelNotification,
+			summary:       "Cancel a notification that has not been sent yet",
+			request:       NotificationActionRequest{},
+			response:      NotificationActionResponse{},
+			errorStatuses: []int{http.StatusNotFound, http.StatusBadGateway, http.StatusServiceUnavailable},
+		},
+		{
+			path: "/api/smtp/test", role: RoleOrganizer, method: http.MethodPost, handler: s.HandleSMTPTest,
+			summary:       "Check the SMTP settings by connecting, authenticating and optionally sending a test message",
+			request:       SMTPTestRequest{},
+			response:      SMTPTestResponse{},
+			errorStatuses: []int{http.StatusBadRequest},
+			rateLimited:   true,
+		},
+		{
+			path: "/api/notifier/stats", role: RoleViewer, method: http.MethodGet, handler: s.HandleNotifierStats,
+			summary:       "Notifier service delivery statistics, cached for 15 seconds",
+			response:      NotifierStatsResponse{},
+			errorStatuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable},
+		},
+		{
+			path: "/api/auth/session", method: http.MethodGet, handler: s.HandleSession,
+			summary:  "Whether the caller is logged in, with the CSRF token for POST requests",
+			response: SessionResponse{},
+		},
+		{
+			path: "/api/auth/login", method: http.MethodPost, handler: s.HandleLogin,
+			summary:        "Log in with a local account and set the session cookie",
+			request:        LoginRequest{},
+			response:       SessionResponse{},
+			textStatuses:   []int{http.StatusNotFound},
+			rejectStatuses: []int{http.StatusUnauthorized},
+			rateLimited:    true,
+		},
+		{
+			path: "/api/auth/logout", role: RoleViewer, method: http.MethodPost, handler: s.HandleLogout,
+			summary:  "End the session",
+			response: SessionResponse{},
+		},
+		{
+			path: "/api/auth/oidc/login", method: http.MethodGet, handler: s.HandleOIDCLogin,
+			summary:      "Redirect to the OIDC provider to log in",
+			redirect:     true,
+			textStatuses: []int{http.StatusNotFound},
+		},
+		{
+			path: "/api/auth/oidc/callback", method: http.MethodGet, handler: s.HandleOIDCCallback,
+			summary:      "Complete an OIDC login and redirect to the UI",
+			query:        []string{"code", "state"},
+			redirect:     true,
+			textStatuses: []int{http.StatusUnauthorized, http.StatusNotFound},
+		},
+		{
+			path: "/healthz", method: http.MethodGet, handler: s.HandleHealthz,
+			summary:  "Liveness: answers while the server is serving requests",
+			response: HealthResponse{},
+		},
+		{
+			path: "/readyz", method: http.MethodGet, handler: s.HandleReadyz,
+			summary:       "Readiness: config, storage and notifier service or SMTP reachability, with remote checks cached for 15 seconds",
+			response:      HealthResponse{},
+			errorStatuses: []int{http.StatusServiceUnavailable},
+		},
+		{
+			path: "/api/openapi.json", method: http.MethodGet, handler: s.HandleOpenAPI,
+			summary:  "This OpenAPI document",
+			response: map[string]any{},
+		},
+	}
+}
+
+// HandleOpenAPI serves the OpenAPI 3 description of the REST API
+func (s *Server) HandleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(openAPIDocument(s.routes()))
+}
+
+// openAPIDocument describes routes. Schemas are generated from the request
+// and response types, so the document can't drift from the handlers.
+func openAPIDocument(routes []route) map[string]any {
+	g := newSchemaGenerator()
+	paths := make(map[string]any, len(routes))
+
+	for _, rt := range routes {
+		op := map[string]any{
+			"summary":     rt.summary,
+			"operationId": operationID(rt.path),
+		}
+
+		if len(rt.query) > 0 {
+			var params []any
+			for _, name := range rt.query {
+				params = append(params, map[string]any{
+					"name": name, "in": "query", "required": true, "schema": map[string]any{"type": "string"},
+				})
+			}
+			op["parameters"] = params
+		}
+
+		switch {
+		case rt.upload:
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{"multipart/form-data": map[string]any{"schema": map[string]any{
+					"type":       "object",
+					"required":   []string{"file"},
+					"properties": map[string]any{"file": map[string]any{"type": "string", "format": "binary"}},
+				}}},
+			}
+		case rt.request != nil:
+			op["requestBody"] = map[string]any{"required": true, "content": jsonContent(g.schema(reflect.TypeOf(rt.request)))}
+		}
+
+		success := map[string]any{"description": "OK"}
+		successStatus := "200"
+		switch {
+		case rt.redirect:
+			successStatus = "302"
+			success["description"] = "Redirect"
+		case rt.download:
+			success["content"] = map[string]any{"application/octet-stream": map[string]any{
+				"schema": map[string]any{"type": "string", "format": "binary"},
+			}}
+		case rt.response != nil:
+			success["content"] = jsonContent(g.schema(reflect.TypeOf(rt.response)))
+		}
+		responses := map[string]any{
+			successStatus: success,
+			"400":         textResponse("Invalid request"),
+			"405":         textResponse("Method not allowed"),
+		}
+		if rt.role != "" {
+			op["description"] = fmt.Sprintf("Requires a session with the %s role when authentication is enabled. POST requests must send the session's CSRF token in the %s header.", rt.role, csrfHeader)
+			if rt.role == RoleViewer {
+				op["description"] = fmt.Sprintf("Requires a session when authentication is enabled. POST requests must send the session's CSRF token in the %s header.", csrfHeader)
+			}
+			op["security"] = []any{map[string]any{"sessionCookie": []string{}}}
+			responses["401"] = rejectionResponse(g, "Authentication required")
+			responses["403"] = rejectionResponse(g, "Origin not allowed, missing CSRF token or insufficient role")
+		} else if rt.method == http.MethodPost {
+			responses["403"] = rejectionResponse(g, "Origin not allowed")
+		}
+		if rt.request != nil || rt.upload {
+			responses["413"] = rejectionResponse(g, "Request body too large")
+		}
+		if rt.rateLimited {
+			tooMany := rejectionResponse(g, "Rate limit or daily notification cap reached")
+			tooMany["headers"] = map[string]any{"Retry-After": map[string]any{
+				"description": "Seconds until the request may be retried",
+				"schema":      map[string]any{"type": "integer"},
+			}}
+			responses["429"] = tooMany
+		}
+		for _, code := range rt.textStatuses {
+			responses[strconv.Itoa(code)] = textResponse(http.StatusText(code))
+		}
+		for _, code := range rt.rejectStatuses {
+			responses[strconv.Itoa(code)] = rejectionResponse(g, http.StatusText(code))
+		}
+		for _, code := range rt.errorStatuses {
+			responses[strconv.Itoa(code)] = map[string]any{
+				"description": http.StatusText(code),
+				"content":     jsonContent(g.schema(reflect.TypeOf(rt.response))),
+			}
+		}
+		op["responses"] = responses
+
+		paths[rt.path] = map[string]any{strings.ToLower(rt.method): op}
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Secret Santa API",
+			"version": apiVersion,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.components,
+			"securitySchemes": map[string]any{
+				"sessionCookie": map[string]any{"type": "apiKey", "in": "cookie", "name": sessionCookie},
+			},
+		},
+	}
+}
+
+// operationID turns /api/notifications/retry into notificationsRetry
+func operationID(path string) string {
+	parts := strings.FieldsFunc(strings.TrimPrefix(path, "/api/"), func(r rune) bool {
+		return r == '/' || r == '.'
+	})
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// rejectionResponse is an error written by the middleware
+func rejectionResponse(g *schemaGenerator, description string) map[string]any {
+	return map[string]any{"description": description, "content": jsonContent(g.schema(reflect.TypeOf(Rejection{})))}
+}
+
+func textResponse(description string) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content":     map[string]any{"text/plain": map[string]any{"schema": map[string]any{"type": "string"}}},
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaGenerator builds OpenAPI schemas for Go types from their json tags.
+// Named structs become components referenced by $ref, which also handles
+// recursive types such as Participant.Recipient.
+type schemaGenerator struct {
+	components map[string]any
+	names      map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{components: make(map[string]any), names: make(map[reflect.Type]string)}
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		return map[string]any{"$ref": "#/components/schemas/" + g.component(t)}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.object(t)
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// component registers a named struct and returns its component name. Types
+// with the same name from different packages are prefixed with the package.
+func (g *schemaGenerator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := g.components[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	g.names[t] = name
+	// Reserve the name before recursing so self-references resolve
+	g.components[name] = nil
+	g.components[name] = g.object(t)
+	return name
+}
+
+func (g *schemaGenerator) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	g.addFields(t, properties)
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+// addFields adds the JSON properties of t, flattening embedded structs
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.addFields(embedded, properties)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schema(field.Type)
+	}
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/igodwin/secretsanta/pkg/client"
+)
+
+func TestHandleOpenAPI(t *testing.T) {
+	server := NewServer(":8080")
+	req := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+	w := httptest.NewRecorder()
+
+	server.HandleOpenAPI(w, req)
+
+	body := w.Body.Bytes()
+	var doc struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("Expected OpenAPI 3.0.3, got %q", doc.OpenAPI)
+	}
+	for _, rt := range server.routes() {
+		if _, ok := doc.Paths[rt.path][strings.ToLower(rt.method)]; !ok {
+			t.Errorf("Expected %s %s in the document", rt.method, rt.path)
+		}
+	}
+	if _, ok := doc.Components.Schemas["DrawRequest"]; !ok {
+		t.Errorf("Expected a DrawRequest schema, got %v", doc.Components.Schemas)
+	}
+
+	// Every $ref must resolve to a component
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				name := ref[len("#/components/schemas/"):]
+				if _, ok := doc.Components.Schemas[name]; !ok {
+					t.Errorf("Unresolved reference %s", ref)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	var raw any
+	json.Unmarshal(body, &raw)
+	walk(raw)
+}
+
+// TestClientTypesMatchHandlers keeps the pkg/client SDK in step with the
+// handler types by comparing their generated schemas
+func TestClientTypesMatchHandlers(t *testing.T) {
+	pairs := []struct{ handler, client any }{
+		{ValidationResponse{}, client.ValidationResponse{}},
+		{DrawRequest{}, client.DrawRequest{}},
+		{DrawResponse{}, client.DrawResponse{}},
+		{UploadResponse{}, client.UploadResponse{}},
+		{DownloadRequest{}, client.DownloadRequest{}},
+		{ParticipantResponse{}, client.ParticipantResponse{}},
+		{NotificationStatusResponse{}, client.NotificationStatusResponse{}},
+		{NotificationsResponse{}, client.NotificationsResponse{}},
+		{NotificationActionRequest{}, client.NotificationActionRequest{}},
+		{NotificationActionResponse{}, client.NotificationActionResponse{}},
+		{NotifierStatsResponse{}, client.NotifierStatsResponse{}},
+		{SMTPTestRequest{}, client.SMTPTestRequest{}},
+		{SMTPTestResponse{}, client.SMTPTestResponse{}},
+		{SessionResponse{}, client.SessionResponse{}},
+		{LoginRequest{}, client.LoginRequest{}},
+	}
+
+	for _, pair := range pairs {
+		handlerSchemas, clientSchemas := newSchemaGenerator(), newSchemaGenerator()
+		handlerSchemas.schema(reflect.TypeOf(pair.handler))
+		clientSchemas.schema(reflect.TypeOf(pair.client))
+		if !reflect.DeepEqual(handlerSchemas.components, clientSchemas.components) {
+			t.Errorf("client.%s does not match the handler type:\nhandler: %v\nclient:  %v",
+				reflect.TypeOf(pair.handler).Name(), handlerSchemas.components, clientSchemas.components)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/peer"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+// maxBuckets is how many client buckets are kept. Full buckets are dropped
+// first, since a full bucket behaves exactly like a new one; if that isn't
+// enough the least recently used bucket goes.
+const maxBuckets = 10000
+
+// limitError is a request refused until RetryAfter has passed
+type limitError struct {
+	Code       string
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *limitError) Error() string {
+	return e.Message
+}
+
+// writeLimitError answers 429 with a Retry-After header in whole seconds
+func writeLimitError(w http.ResponseWriter, err *limitError) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	writeRejection(w, http.StatusTooManyRequests, err.Code, err.Message)
+}
+
+// limiter enforces the request rate limits and the daily notification cap.
+// A nil *limiter, or a nil part of one, enforces nothing.
+type limiter struct {
+	ips               *tokenBuckets
+	accounts          *tokenBuckets
+	notifications     *dailyCap
+	trustForwardedFor bool
+}
+
+func newLimiter(cfg config.LimitsConfig) *limiter {
+	return &limiter{
+		ips:               newTokenBuckets(cfg.IPRequestsPerMinute, cfg.IPBurst),
+		accounts:          newTokenBuckets(cfg.AccountRequestsPerMinute, cfg.AccountBurst),
+		notifications:     newDailyCap(cfg.DailyNotifications),
+		trustForwardedFor: cfg.TrustForwardedFor,
+	}
+}
+
+// rateLimit wraps next with the per-IP limit and, for logged-in users, the
+// per-account limit
+func (l *limiter) rateLimit(next http.HandlerFunc) http.HandlerFunc {
+	if l == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := l.allow(r.Context(), l.clientIP(r)); err != nil {
+			writeLimitError(w, err)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// allowRPC applies the same limits as rateLimit to a SecretSantaService call
+func (l *limiter) allowRPC(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	ip := "unknown"
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = hostOnly(p.Addr.String())
+	}
+	if err := l.allow(ctx, ip); err != nil {
+		return err
+	}
+	return nil
+}
+
+// allow spends a token from ip's bucket and, when ctx carries a session,
+// from the account's bucket
+func (l *limiter) allow(ctx context.Context, ip string) *limitError {
+	now := time.Now()
+	if wait, ok := l.ips.take(ip, now); !ok {
+		return &limitError{Code: rejectRateLimited, Message: "Too many requests from this address", RetryAfter: wait}
+	}
+	if sess := sessionFromContext(ctx); sess != nil {
+		if wait, ok := l.accounts.take(sess.username, now); !ok {
+			return &limitError{Code: rejectRateLimited, Message: "Too many requests for this account", RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// clientIP is the address r came from. Behind a trusted proxy it is the
+// last X-Forwarded-For entry, which the proxy itself appended.
+func (l *limiter) clientIP(r *http.Request) string {
+	if l.trustForwardedFor {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			hops := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	return hostOnly(r.RemoteAddr)
+}
+
+func hostOnly(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// organizer names who a draw's notifications count against: the logged-in
+// user, or the client address when authentication is disabled
+func (l *limiter) organizer(r *http.Request) string {
+	if sess := sessionFromContext(r.Context()); sess != nil {
+		return "user:" + sess.username
+	}
+	if l == nil {
+		return "ip:" + hostOnly(r.RemoteAddr)
+	}
+	return "ip:" + l.clientIP(r)
+}
+
+// grpcOrganizer is organizer for a SecretSantaService call
+func grpcOrganizer(ctx context.Context) string {
+	if sess := sessionFromContext(ctx); sess != nil {
+		return "user:" + sess.username
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		return "ip:" + hostOnly(p.Addr.String())
+	}
+	return "ip:unknown"
+}
+
+// reserveNotifications counts a draw's assignments and reminders against
+// organizer's daily cap. Call release if the draw fails before sending.
+func (l *limiter) reserveNotifications(organizer string, participants []*participant.Participant, req *DrawRequest) (release func(), err error) {
+	return l.reserveMessages(organizer, notificationCount(participants, req))
+}
+
+// reserveMessages counts count messages, such as a retry or an SMTP test
+// message, against organizer's daily cap. Call release if they aren't sent.
+func (l *limiter) reserveMessages(organizer string, count int) (release func(), err error) {
+	if l == nil || l.notifications == nil {
+		return func() {}, nil
+	}
+	wait, ok := l.notifications.reserve(organizer, count, time.Now())
+	if !ok {
+		return nil, &limitError{
+			Code:       rejectNotificationCap,
+			Message:    fmt.Sprintf("This would send %d notifications, over the daily limit of %d", count, l.notifications.limit),
+			RetryAfter: wait,
+		}
+	}
+	return func() { l.notifications.release(organizer, count) }, nil
+}
+
+// notificationCount counts every recipient a draw could message: each
+// contact on every route of a participant's delivery chain, once for the
+// assignment and once per reminder rule for those who get reminders, plus
+// the archive copy of each assignment. A route without contacts, such as
+// stdout, counts as one recipient.
+func notificationCount(participants []*participant.Participant, req *DrawRequest) int {
+	archive := drawConfig(req).Notifier.ArchiveEmail != ""
+	count := 0
+	for _, p := range participants {
+		recipients := 0
+		for _, route := range notifier.Routes(p) {
+			recipients += max(len(route.ContactInfo), 1)
+		}
+		messages := 1
+		if req.Event != nil && !p.NoReminders {
+			messages += len(req.Event.Reminders)
+		}
+		count += recipients * messages
+		if archive {
+			count++
+		}
+	}
+	return count
+}
+
+// tokenBuckets holds a token bucket per key. Each bucket refills at rate
+// tokens per second up to burst.
+type tokenBuckets struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// newTokenBuckets returns nil, which allows everything, when perMinute is 0
+func newTokenBuckets(perMinute float64, burst int) *tokenBuckets {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBuckets{rate: perMinute / 60, burst: float64(max(burst, 1)), buckets: make(map[string]*tokenBucket)}
+}
+
+// take spends a token from key's bucket, or reports how long until one is
+// available
+func (b *tokenBuckets) take(key string, now time.Time) (time.Duration, bool) {
+	if b == nil {
+		return 0, true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	bucket, ok := b.buckets[key]
+	if !ok {
+		if len(b.buckets) >= maxBuckets {
+			b.prune(now)
+		}
+		bucket = &tokenBucket{tokens: b.burst, updated: now}
+		b.buckets[key] = bucket
+	}
+	bucket.tokens = b.refilled(bucket, now)
+	bucket.updated = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - bucket.tokens) / b.rate * float64(time.Second)), false
+}
+
+func (b *tokenBuckets) refilled(bucket *tokenBucket, now time.Time) float64 {
+	return min(b.burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*b.rate)
+}
+
+// prune drops the full buckets, or the least recently used one when none
+// is full, so the map never grows past maxBuckets
+func (b *tokenBuckets) prune(now time.Time) {
+	var oldestKey string
+	var oldest time.Time
+	for key, bucket := range b.buckets {
+		if b.refilled(bucket, now) >= b.burst {
+			delete(b.buckets, key)
+			continue
+		}
+		if oldestKey == "" || bucket.updated.Before(oldest) {
+			oldestKey, oldest = key, bucket.updated
+		}
+	}
+	if len(b.buckets) >= maxBuckets {
+		delete(b.buckets, oldestKey)
+	}
+}
+
+// dailyCap counts notifications per organizer for the current UTC day. The
+// counts are kept in memory only, so a restart resets them: the cap limits
+// runaway use between restarts, not a determined organizer who can restart
+// the server.
+type dailyCap struct {
+	limit int
+
+	mu   sync.Mutex
+	day  time.Time
+	used map[string]int
+}
+
+// newDailyCap returns nil, which allows everything, when limit is 0
+func newDailyCap(limit int) *dailyCap {
+	if limit <= 0 {
+		return nil
+	}
+	return &dailyCap{limit: limit, used: make(map[string]int)}
+}
+
+// reserve adds count to key's total, or reports how long until the cap
+// resets at midnight UTC
+func (c *dailyCap) reserve(key string, count int, now time.Time) (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	day := now.UTC().Truncate(24 * time.Hour)
+	if !day.Equal(c.day) {
+		c.day = day
+		c.used = make(map[string]int)
+	}
+	if c.used[key]+count > c.limit {
+		return day.Add(24 * time.Hour).Sub(now), false
+	}
+	c.used[key] += count
+	return 0, true
+}
+
+func (c *dailyCap) release(key string, count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.used[key] = max(c.used[key]-count, 0)
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/event"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+func TestTokenBuckets(t *testing.T) {
+	buckets := newTokenBuckets(60, 2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if _, ok := buckets.take("a", now); !ok {
+			t.Fatalf("Expected request %d within the burst to pass", i+1)
+		}
+	}
+	wait, ok := buckets.take("a", now)
+	if ok || wait != time.Second {
+		t.Errorf("Expected to wait 1s for a token, got %v, %v", wait, ok)
+	}
+	if _, ok := buckets.take("b", now); !ok {
+		t.Error("Expected another key to have its own bucket")
+	}
+	if _, ok := buckets.take("a", now.Add(time.Second)); !ok {
+		t.Error("Expected a token after waiting")
+	}
+
+	if _, ok := newTokenBuckets(0, 0).take("a", now); !ok {
+		t.Error("Expected a zero rate to allow everything")
+	}
+}
+
+func TestTokenBucketsBound(t *testing.T) {
+	buckets := newTokenBuckets(1, 2)
+	now := time.Now()
+
+	// Every bucket has spent a token, so none can be dropped as full
+	for i := 0; i < maxBuckets; i++ {
+		buckets.take(fmt.Sprintf("client-%d", i), now.Add(time.Duration(i)*time.Millisecond))
+	}
+	buckets.take("newcomer", now.Add(maxBuckets*time.Millisecond))
+
+	if len(buckets.buckets) > maxBuckets {
+		t.Errorf("Expected at most %d buckets, got %d", maxBuckets, len(buckets.buckets))
+	}
+	if _, ok := buckets.buckets["client-0"]; ok {
+		t.Error("Expected the least recently used bucket to be dropped")
+	}
+	if _, ok := buckets.buckets["newcomer"]; !ok {
+		t.Error("Expected the new client to get a bucket")
+	}
+}
+
+func TestDailyCap(t *testing.T) {
+	limit := newDailyCap(5)
+	now := time.Date(2024, 12, 1, 18, 0, 0, 0, time.UTC)
+
+	if _, ok := limit.reserve("alice", 3, now); !ok {
+		t.Fatal("Expected the first reservation to pass")
+	}
+	wait, ok := limit.reserve("alice", 3, now)
+	if ok || wait != 6*time.Hour {
+		t.Errorf("Expected to wait until midnight UTC, got %v, %v", wait, ok)
+	}
+	if _, ok := limit.reserve("bob", 5, now); !ok {
+		t.Error("Expected another organizer to have their own cap")
+	}
+
+	limit.release("alice", 3)
+	if _, ok := limit.reserve("alice", 5, now); !ok {
+		t.Error("Expected released notifications to be available again")
+	}
+	if _, ok := limit.reserve("alice", 5, now.Add(6*time.Hour)); !ok {
+		t.Error("Expected the cap to reset the next day")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/draw", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+
+	if got := (&limiter{}).clientIP(req); got != "10.0.0.1" {
+		t.Errorf("Expected the remote address without a trusted proxy, got %s", got)
+	}
+	if got := (&limiter{trustForwardedFor: true}).clientIP(req); got != "198.51.100.7" {
+		t.Errorf("Expected the address the proxy appended, got %s", got)
+	}
+}
+
+func TestRateLimitedRoutes(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+	server := NewServer(":8080")
+	server.limits = newLimiter(config.LimitsConfig{IPRequestsPerMinute: 1, IPBurst: 1})
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	body, _ := json.Marshal(DrawRequest{Participants: []participant.Participant{
+		{Name: "Alice", NotificationType: "stdout"},
+		{Name: "Bob", NotificationType: "stdout"},
+	}})
+	for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		resp, err := http.Post(ts.URL+"/api/draw", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("Request %d: expected status %d, got %d", i+1, want, resp.StatusCode)
+		}
+		if want != http.StatusTooManyRequests {
+			continue
+		}
+		if got := resp.Header.Get("Retry-After"); got != "60" {
+			t.Errorf("Expected Retry-After 60, got %q", got)
+		}
+		var rejection Rejection
+		json.NewDecoder(resp.Body).Decode(&rejection)
+		if rejection.Code != rejectRateLimited {
+			t.Errorf("Expected code %s, got %+v", rejectRateLimited, rejection)
+		}
+	}
+
+	// Unlimited routes are unaffected
+	resp, err := http.Get(ts.URL + "/api/template?format=json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the template route to stay open, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandleDrawNotificationCap(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+	server := NewServer(":8080")
+	server.limits = newLimiter(config.LimitsConfig{DailyNotifications: 7})
+
+	drawRequest := DrawRequest{
+		Participants: []participant.Participant{
+			{Name: "Alice", NotificationType: "stdout"},
+			{Name: "Bob", NotificationType: "stdout"},
+			{Name: "Carol", NotificationType: "stdout", NoReminders: true},
+		},
+		Event: &event.Event{Name: "Office Party", ExchangeDate: time.Now().AddDate(0, 0, 14)},
+	}
+	body, _ := json.Marshal(drawRequest)
+
+	// Three assignments and two default reminders each for Alice and Bob
+	w := httptest.NewRecorder()
+	server.HandleDraw(w, httptest.NewRequest(http.MethodPost, "/api/draw", bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	server.HandleDraw(w, httptest.NewRequest(http.MethodPost, "/api/draw", bytes.NewReader(body)))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d: %s", w.Code, w.Body)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+	var rejection Rejection
+	json.NewDecoder(w.Body).Decode(&rejection)
+	if rejection.Code != rejectNotificationCap {
+		t.Errorf("Expected code %s, got %+v", rejectNotificationCap, rejection)
+	}
+}
+
+func TestNotificationCountCountsRecipients(t *testing.T) {
+	cfg := config.GetConfig()
+	saved := cfg.Notifier.ArchiveEmail
+	cfg.Notifier.ArchiveEmail = ""
+	t.Cleanup(func() { cfg.Notifier.ArchiveEmail = saved })
+
+	bulk := &participant.Participant{Name: "Alice", NotificationType: "email", ContactInfo: []string{"a@example.com", "b@example.com", "c@example.com"}}
+	fallback := &participant.Participant{Name: "Bob", NotificationType: "email", ContactInfo: []string{"bob@example.com", "sms:+15555550123"}}
+	console := &participant.Participant{Name: "Carol", NotificationType: "stdout"}
+	participants := []*participant.Participant{bulk, fallback, console}
+
+	if got := notificationCount(participants, &DrawRequest{}); got != 6 {
+		t.Errorf("Expected one per contact on every route, got %d", got)
+	}
+	if got := notificationCount(participants, &DrawRequest{ArchiveEmail: "archive@example.com"}); got != 9 {
+		t.Errorf("Expected an archive copy per assignment, got %d", got)
+	}
+	req := &DrawRequest{Event: &event.Event{Reminders: []event.ReminderRule{{}, {}}}}
+	if got := notificationCount(participants, req); got != 18 {
+		t.Errorf("Expected every contact to count for each reminder, got %d", got)
+	}
+}
+
+func TestHandleDrawMaxParticipants(t *testing.T) {
+	limits := &config.GetConfig().Limits
+	saved := limits.MaxParticipants
+	limits.MaxParticipants = 2
+	t.Cleanup(func() { limits.MaxParticipants = saved })
+
+	body, _ := json.Marshal(DrawRequest{Participants: []participant.Participant{
+		{Name: "Alice", NotificationType: "stdout"},
+		{Name: "Bob", NotificationType: "stdout"},
+		{Name: "Carol", NotificationType: "stdout"},
+	}})
+	w := httptest.NewRecorder()
+	NewServer(":8080").HandleDraw(w, httptest.NewRequest(http.MethodPost, "/api/draw", bytes.NewReader(body)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", w.Code)
+	}
+	var response DrawResponse
+	json.NewDecoder(w.Body).Decode(&response)
+	if response.Success || response.Error != "A draw can have at most 2 participants, got 3" {
+		t.Errorf("Unexpected response %+v", response)
+	}
+}
+
+func TestGRPCDrawRateLimited(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+	server := NewServer(":8080")
+	server.limits = newLimiter(config.LimitsConfig{DailyNotifications: 3})
+	client := serveDrawService(t, server)
+	ctx := context.Background()
+
+	if _, err := client.Draw(ctx, &pb.DrawRequest{Participants: stdoutParticipants("Alice", "Bob", "Carol")}); err != nil {
+		t.Fatalf("Draw() error = %v", err)
+	}
+	_, err := client.Draw(ctx, &pb.DrawRequest{Participants: stdoutParticipants("Alice", "Bob")})
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("Expected ResourceExhausted, got %v", err)
+	}
+	var retry *errdetails.RetryInfo
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if retry == nil || retry.RetryDelay.AsDuration() <= 0 {
+		t.Errorf("Expected a RetryInfo detail, got %v", st.Details())
+	}
+}
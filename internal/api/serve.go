@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+)
+
+// newHTTPServer returns the web server for s with the configured timeouts
+func (s *Server) newHTTPServer(cfg config.ServerConfig, tlsConfig *tls.Config) *http.Server {
+	return &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// serverTLSConfig loads the certificate files or sets up ACME. It returns
+// nil when TLS is not configured.
+func serverTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	files := cfg.CertFile != "" || cfg.KeyFile != ""
+	switch {
+	case files && len(cfg.ACMEDomains) > 0:
+		return nil, fmt.Errorf("set either a certificate file or ACME domains, not both")
+	case files:
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, fmt.Errorf("both cert_file and key_file are required")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load certificate: %w", err)
+		}
+		return &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}, nil
+	case len(cfg.ACMEDomains) > 0:
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.ACMEDomains...),
+			Cache:      autocert.DirCache(cfg.ACMECacheDir),
+			Email:      cfg.ACMEEmail,
+		}
+		// Certificates are requested with the TLS-ALPN challenge on the
+		// HTTPS port, so no plain HTTP listener is needed
+		tlsConfig := manager.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return tlsConfig, nil
+	}
+	return nil, nil
+}
+
+// shutdown stops the web and gRPC servers from taking new requests, then
+// waits for requests and notification batches in progress until ctx is done
+func (s *Server) shutdown(ctx context.Context, httpServer *http.Server, grpcServer *grpc.Server) error {
+	if err := httpServer.Shutdown(ctx); err != nil {
+		httpServer.Close()
+		slog.Warn("Web server did not stop in time", "error", err)
+	}
+
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+		}
+	}
+
+	if err := s.batches.wait(ctx); err != nil {
+		return fmt.Errorf("shutdown interrupted notification batches: %w", err)
+	}
+	return nil
+}
+
+// batchTracker counts draws that are sending or scheduling notifications,
+// so shutdown doesn't cut a batch off half sent
+type batchTracker struct {
+	mu     sync.Mutex
+	active int
+	// idle is closed when active drops to zero
+	idle chan struct{}
+}
+
+// begin records a batch starting; call the returned function when it ends
+func (b *batchTracker) begin() (end func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.active == 0 {
+		b.idle = make(chan struct{})
+	}
+	b.active++
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.active--
+		if b.active == 0 {
+			close(b.idle)
+		}
+	}
+}
+
+// wait blocks until no batch is in progress or ctx is done. Batches still
+// running when ctx is done are logged as abandoned: the notifier connection
+// closes next, so some of their participants may not be notified.
+func (b *batchTracker) wait(ctx context.Context) error {
+	b.mu.Lock()
+	active, idle := b.active, b.idle
+	b.mu.Unlock()
+	if active == 0 {
+		return nil
+	}
+
+	slog.Info("Waiting for notification batches to finish", "batches", active)
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		active = b.active
+		b.mu.Unlock()
+		slog.Error("Abandoning notification batches still in progress at shutdown; check the notification status for participants who weren't notified", "batches", active)
+		return fmt.Errorf("%d batch(es) still in progress: %w", active, ctx.Err())
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+)
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1
+func writeTestCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "secretsanta test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+func TestServerTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t)
+
+	tests := []struct {
+		name    string
+		cfg     config.TLSConfig
+		wantTLS bool
+		wantErr bool
+	}{
+		{name: "disabled"},
+		{name: "certificate files", cfg: config.TLSConfig{CertFile: certFile, KeyFile: keyFile}, wantTLS: true},
+		{name: "missing key", cfg: config.TLSConfig{CertFile: certFile}, wantErr: true},
+		{name: "unreadable certificate", cfg: config.TLSConfig{CertFile: keyFile, KeyFile: keyFile}, wantErr: true},
+		{name: "acme", cfg: config.TLSConfig{ACMEDomains: []string{"santa.example.com"}, ACMECacheDir: t.TempDir()}, wantTLS: true},
+		{name: "files and acme", cfg: config.TLSConfig{CertFile: certFile, KeyFile: keyFile, ACMEDomains: []string{"santa.example.com"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig, err := serverTLSConfig(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serverTLSConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (tlsConfig != nil) != tt.wantTLS {
+				t.Errorf("Expected TLS config %v, got %v", tt.wantTLS, tlsConfig)
+			}
+		})
+	}
+}
+
+func TestNewHTTPServerTimeouts(t *testing.T) {
+	cfg := config.ServerConfig{ReadHeaderTimeout: time.Second, ReadTimeout: 2 * time.Second, WriteTimeout: 3 * time.Second, IdleTimeout: 4 * time.Second}
+	server := NewServer(":8080").newHTTPServer(cfg, nil)
+
+	if server.ReadHeaderTimeout != time.Second || server.ReadTimeout != 2*time.Second ||
+		server.WriteTimeout != 3*time.Second || server.IdleTimeout != 4*time.Second {
+		t.Errorf("Timeouts not applied: %+v", server)
+	}
+}
+
+func TestShutdownWaitsForNotificationBatches(t *testing.T) {
+	server := NewServer("127.0.0.1:0")
+	httpServer := server.newHTTPServer(config.ServerConfig{}, nil)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go httpServer.Serve(lis)
+
+	end := server.batches.begin()
+	done := make(chan error, 1)
+	go func() {
+		done <- server.shutdown(context.Background(), httpServer, nil)
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Shutdown returned during a batch: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if _, err := http.Get("http://" + lis.Addr().String()); err == nil {
+		t.Error("Expected new requests to be refused while shutting down")
+	}
+
+	end()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("shutdown() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown did not return after the batch ended")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	server := NewServer("127.0.0.1:0")
+	httpServer := server.newHTTPServer(config.ServerConfig{}, nil)
+	defer server.batches.begin()()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	if err := server.shutdown(ctx, httpServer, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to interrupt shutdown, got %v", err)
+	}
+	if !strings.Contains(logs.String(), "Abandoning notification batches") || !strings.Contains(logs.String(), "batches=1") {
+		t.Errorf("Expected the abandoned batch to be logged, got %q", logs.String())
+	}
+}
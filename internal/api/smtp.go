@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/igodwin/secretsanta/internal/notification"
+	"github.com/igodwin/secretsanta/internal/tracing"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+)
+
+// SMTPTestRequest optionally names an address to send a test message to
+type SMTPTestRequest struct {
+	SendTo string `json:"send_to,omitempty"`
+}
+
+// SMTPTestResponse reports each step of an SMTP connection test: dial, tls,
+// auth and send. Success is false when any step failed.
+type SMTPTestResponse struct {
+	Success bool                      `json:"success"`
+	Steps   []notifier.ConnectionStep `json:"steps,omitempty"`
+	Error   string                    `json:"error,omitempty"`
+}
+
+// HandleSMTPTest checks the SMTP settings by connecting, negotiating TLS,
+// authenticating and optionally sending a test message. A failed step is
+// reported in the response rather than as an error status. The test message
+// can go to the configured addresses or the logged-in user's own, and counts
+// against the daily notification cap.
+func (s *Server) HandleSMTPTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req SMTPTestRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	var allowed []string
+	if sess := sessionFromContext(r.Context()); sess != nil {
+		// A username is an address when the identity provider verified it
+		allowed = append(allowed, sess.username)
+	}
+
+	release := func() {}
+	if req.SendTo != "" {
+		var err error
+		release, err = s.limits.reserveMessages(s.limits.organizer(r), 1)
+		var limitErr *limitError
+		if errors.As(err, &limitErr) {
+			writeLimitError(w, limitErr)
+			return
+		}
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(SMTPTestResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	ctx, span := tracing.Start(r.Context(), "TestSMTP")
+	steps, err := notification.TestSMTP(ctx, config.GetConfig(), req.SendTo, allowed...)
+	tracing.End(span, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		release()
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(SMTPTestResponse{Error: err.Error()})
+		return
+	}
+
+	response := SMTPTestResponse{Success: notifier.ConnectionSucceeded(steps), Steps: steps}
+	for _, step := range steps {
+		if !step.OK && !step.Skipped {
+			slog.WarnContext(r.Context(), "SMTP connection test failed", "step", step.Name, "error", step.Detail)
+		}
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/igodwin/secretsanta/internal/notification"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+)
+
+// serveFakeSMTP answers connections on lis with a server that offers no
+// extensions, enough for a connection test without credentials
+func serveFakeSMTP(lis net.Listener) {
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			conn.Write([]byte("220 fake ESMTP\r\n"))
+			reader := bufio.NewReader(conn)
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				switch {
+				case strings.HasPrefix(line, "EHLO"):
+					conn.Write([]byte("250 fake\r\n"))
+				case strings.HasPrefix(line, "QUIT"):
+					conn.Write([]byte("221 Bye\r\n"))
+					return
+				default:
+					conn.Write([]byte("502 Not implemented\r\n"))
+				}
+			}
+		}()
+	}
+}
+
+func TestHandleSMTPTest(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+	cfg := config.GetConfig()
+	saved := *cfg
+	t.Cleanup(func() { *cfg = saved })
+
+	server := NewServer(":8080")
+	server.limits = newLimiter(config.LimitsConfig{DailyNotifications: 1})
+	smtpTest := func(req SMTPTestRequest) (int, SMTPTestResponse) {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		server.HandleSMTPTest(w, httptest.NewRequest(http.MethodPost, "/api/smtp/test", bytes.NewReader(body)))
+		var response SMTPTestResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+
+	cfg.Notifier.ServiceAddr = ""
+	cfg.SMTP = config.SMTPConfig{Host: "smtp.example.com"}
+	if code, response := smtpTest(SMTPTestRequest{}); code != http.StatusBadRequest || !strings.Contains(response.Error, "smtp.port") {
+		t.Errorf("Expected 400 for incomplete settings, got %d %+v", code, response)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go serveFakeSMTP(lis)
+	host, port, _ := net.SplitHostPort(lis.Addr().String())
+	cfg.SMTP = config.SMTPConfig{Host: host, Port: port, FromAddress: "santa@example.com"}
+
+	if code, response := smtpTest(SMTPTestRequest{SendTo: "@handle"}); code != http.StatusBadRequest || response.Error == "" {
+		t.Errorf("Expected 400 for a recipient that isn't an email address, got %d %+v", code, response)
+	}
+
+	if code, response := smtpTest(SMTPTestRequest{SendTo: "stranger@example.com"}); code != http.StatusBadRequest || response.Error != notification.ErrSMTPRecipient.Error() {
+		t.Errorf("Expected 400 for a recipient that isn't a configured address, got %d %+v", code, response)
+	}
+
+	// The fake server refuses MAIL, so the send step fails, but the message
+	// still counts against the daily cap
+	if code, response := smtpTest(SMTPTestRequest{SendTo: "Santa <SANTA@example.com>"}); code != http.StatusOK || response.Steps[3].Skipped {
+		t.Errorf("Expected the from address to be accepted as a recipient, got %d %+v", code, response)
+	}
+	if code, _ := smtpTest(SMTPTestRequest{SendTo: "santa@example.com"}); code != http.StatusTooManyRequests {
+		t.Errorf("Expected 429 once the daily cap is spent, got %d", code)
+	}
+
+	code, response := smtpTest(SMTPTestRequest{})
+	if code != http.StatusOK || !response.Success || len(response.Steps) != 4 {
+		t.Fatalf("Expected a successful test, got %d %+v", code, response)
+	}
+	if dial := response.Steps[0]; dial.Name != notifier.StepDial || !dial.OK {
+		t.Errorf("Expected the dial step to pass, got %+v", dial)
+	}
+	if auth := response.Steps[2]; !auth.Skipped {
+		t.Errorf("Expected auth to be skipped without a username, got %+v", auth)
+	}
+
+	cfg.Notifier.ServiceAddr = "localhost:50051"
+	if code, response := smtpTest(SMTPTestRequest{}); code != http.StatusBadRequest || !strings.Contains(response.Error, "notifier service") {
+		t.Errorf("Expected 400 when email goes through the notifier service, got %d %+v", code, response)
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/igodwin/secretsanta/internal/web"
+)
+
+// versionedAssets are linked from index.html with a ?v= content hash, so
+// browsers can cache them until they change
+var versionedAssets = []string{"js/app.js", "css/styles.css"}
+
+// immutableMaxAge is the cache lifetime of an asset requested by its hash
+const immutableMaxAge = "public, max-age=31536000, immutable"
+
+// staticAssets serves the UI with ETags. Files are read from the embedded
+// copy and cached, or read from disk on every request in development.
+type staticAssets struct {
+	files fs.FS
+	dev   bool
+
+	mu    sync.Mutex
+	cache map[string]*asset
+}
+
+type asset struct {
+	content []byte
+	// version is a hash of content, used as the ETag and the ?v= value
+	version string
+}
+
+// newStaticAssets serves dir, or the embedded UI when dir is empty
+func newStaticAssets(dir string) *staticAssets {
+	if dir != "" {
+		slog.Info("Serving the UI from disk", "dir", dir)
+		return &staticAssets{files: os.DirFS(dir), dev: true}
+	}
+	files, err := fs.Sub(web.Static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return &staticAssets{files: files, cache: make(map[string]*asset)}
+}
+
+func (a *staticAssets) load(name string) (*asset, error) {
+	if !a.dev {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if cached, ok := a.cache[name]; ok {
+			return cached, nil
+		}
+	}
+
+	content, err := fs.ReadFile(a.files, name)
+	if err != nil {
+		return nil, err
+	}
+	if name == "index.html" {
+		content = a.versionLinks(content)
+	}
+	sum := sha256.Sum256(content)
+	loaded := &asset{content: content, version: hex.EncodeToString(sum[:8])}
+	if !a.dev {
+		a.cache[name] = loaded
+	}
+	return loaded, nil
+}
+
+// versionLinks adds each versioned asset's hash to its link in index.html
+func (a *staticAssets) versionLinks(index []byte) []byte {
+	for _, name := range versionedAssets {
+		content, err := fs.ReadFile(a.files, name)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(content)
+		link := []byte(`"/static/` + name + `"`)
+		versioned := []byte(`"/static/` + name + `?v=` + hex.EncodeToString(sum[:8]) + `"`)
+		index = bytes.ReplaceAll(index, link, versioned)
+	}
+	return index
+}
+
+// ServeHTTP serves the files under /static/
+func (a *staticAssets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean(r.URL.Path), "/static/")
+	a.serve(w, r, name, r.URL.Query().Get("v"))
+}
+
+// ServeIndex serves index.html for the root path
+func (a *staticAssets) ServeIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	a.serve(w, r, "index.html", "")
+}
+
+// serve writes a file with its ETag. It is cached for good only when
+// version is the file's current hash, so an old link is never cached as
+// new content.
+func (a *staticAssets) serve(w http.ResponseWriter, r *http.Request, name, version string) {
+	loaded, err := a.load(name)
+	if err != nil {
+		// Missing files, directories and invalid paths
+		http.NotFound(w, r)
+		return
+	}
+	cacheControl := "no-cache"
+	if version != "" && version == loaded.version {
+		cacheControl = immutableMaxAge
+	}
+	w.Header().Set("ETag", `"`+loaded.version+`"`)
+	w.Header().Set("Cache-Control", cacheControl)
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(loaded.content))
+}
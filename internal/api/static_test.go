@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestStaticAssetsEmbedded(t *testing.T) {
+	handler := NewServer(":8080").Handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("Expected index.html with no-cache, got %d %q", w.Code, w.Header().Get("Cache-Control"))
+	}
+	link := regexp.MustCompile(`/static/js/app\.js\?v=[0-9a-f]+`).FindString(w.Body.String())
+	if link == "" {
+		t.Fatalf("Expected a versioned app.js link in index.html")
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, link, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected app.js, got %d", w.Code)
+	}
+	if got := w.Header().Get("Cache-Control"); got != immutableMaxAge {
+		t.Errorf("Expected a versioned asset to be cached, got %q", got)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "javascript") {
+		t.Errorf("Expected a JavaScript content type, got %q", ct)
+	}
+	etag := w.Header().Get("ETag")
+	if etag != `"`+strings.SplitN(link, "=", 2)[1]+`"` {
+		t.Errorf("Expected the ETag to match the link's version, got %s for %s", etag, link)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/static/js/app.js", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for a matching ETag, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/static/js/app.js?v=stale", nil))
+	if got := w.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Expected a stale version not to be cached, got %q", got)
+	}
+
+	for _, path := range []string{"/static/missing.js", "/static/js/", "/other"} {
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestStaticAssetsDir(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "css"), 0o755)
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte(`<link rel="stylesheet" href="/static/css/styles.css">`), 0o644)
+	os.WriteFile(filepath.Join(dir, "css", "styles.css"), []byte("body { color: red; }"), 0o644)
+
+	assets := newStaticAssets(dir)
+	get := func(path string) string {
+		w := httptest.NewRecorder()
+		assets.ServeIndex(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Body.String()
+	}
+
+	before := get("/")
+	os.WriteFile(filepath.Join(dir, "css", "styles.css"), []byte("body { color: green; }"), 0o644)
+	after := get("/")
+	if before == after || !strings.Contains(after, "/static/css/styles.css?v=") {
+		t.Errorf("Expected the link to change with the file, got %q then %q", before, after)
+	}
+
+	w := httptest.NewRecorder()
+	assets.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/static/css/styles.css", nil))
+	if w.Body.String() != "body { color: green; }" {
+		t.Errorf("Expected the edited file, got %q", w.Body.String())
+	}
+}
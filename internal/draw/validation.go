@@ -3,6 +3,7 @@ package draw
 import (
 	"fmt"
 
+	"github.com/igodwin/secretsanta/pkg/notifier"
 	"github.com/igodwin/secretsanta/pkg/participant"
 )
 
@@ -10,6 +11,7 @@ import (
 type ValidationResult struct {
 	IsValid                   bool
 	Errors                    []string
+	ErrorKinds                []string // kind of each entry in Errors, an ErrorKind constant
 	Warnings                  []string
 	ParticipantsWithNoOptions []string
 	MinCompatibility          int
@@ -17,6 +19,24 @@ type ValidationResult struct {
 	TotalParticipants         int
 }
 
+// Kinds of validation errors, for counting them without parsing messages
+const (
+	ErrorKindNoParticipants      = "no_participants"
+	ErrorKindTooFewParticipants  = "too_few_participants"
+	ErrorKindDuplicateName       = "duplicate_name"
+	ErrorKindInvalidContact      = "invalid_contact"
+	ErrorKindNoValidRecipients   = "no_valid_recipients"
+	ErrorKindInfeasible          = "infeasible"
+	ErrorKindTooManyParticipants = "too_many_participants"
+)
+
+// AddError records an error of the given kind and marks the result invalid
+func (r *ValidationResult) AddError(kind, msg string) {
+	r.IsValid = false
+	r.Errors = append(r.Errors, msg)
+	r.ErrorKinds = append(r.ErrorKinds, kind)
+}
+
 // ValidateParticipants performs fast O(N²) validation of participant constraints
 // This should be called BEFORE attempting a draw to catch impossible configurations
 // Returns detailed validation results including any errors or warnings
@@ -32,15 +52,13 @@ func ValidateParticipants(participants []*participant.Participant) *ValidationRe
 
 	// Check for empty list
 	if n == 0 {
-		result.IsValid = false
-		result.Errors = append(result.Errors, "no participants provided")
+		result.AddError(ErrorKindNoParticipants, "no participants provided")
 		return result
 	}
 
 	// Check for minimum participants
 	if n < 2 {
-		result.IsValid = false
-		result.Errors = append(result.Errors, "need at least 2 participants for Secret Santa")
+		result.AddError(ErrorKindTooFewParticipants, "need at least 2 participants for Secret Santa")
 		return result
 	}
 
@@ -51,8 +69,7 @@ func ValidateParticipants(participants []*participant.Participant) *ValidationRe
 	nameMap := make(map[string]bool)
 	for _, p := range participants {
 		if nameMap[p.Name] {
-			result.IsValid = false
-			result.Errors = append(result.Errors, fmt.Sprintf("duplicate participant name: %s", p.Name))
+			result.AddError(ErrorKindDuplicateName, fmt.Sprintf("duplicate participant name: %s", p.Name))
 		}
 		nameMap[p.Name] = true
 	}
@@ -70,6 +87,27 @@ func ValidateParticipants(participants []*participant.Participant) *ValidationRe
 				fmt.Sprintf("participant %s has no contact info", giver.Name))
 		}
 
+		// Check each channel in the delivery chain and its contact info format
+		for _, route := range notifier.Routes(giver) {
+			if route.Channel == "" {
+				continue
+			}
+			channel, ok := notifier.Lookup(route.Channel)
+			if !ok {
+				result.Warnings = append(result.Warnings,
+					fmt.Sprintf("participant %s uses unknown notification type %s (delivery on it will fail)", giver.Name, route.Channel))
+				continue
+			}
+			if channel.ValidateContact == nil {
+				continue
+			}
+			for _, contact := range route.ContactInfo {
+				if err := channel.ValidateContact(contact); err != nil {
+					result.AddError(ErrorKindInvalidContact, fmt.Sprintf("participant %s: %v", giver.Name, err))
+				}
+			}
+		}
+
 		// Check for invalid exclusions (non-existent participants)
 		for _, excluded := range giver.Exclusions {
 			if !nameMap[excluded] {
@@ -101,9 +139,8 @@ func ValidateParticipants(participants []*participant.Participant) *ValidationRe
 
 		// If any participant has no valid recipients, assignment is impossible
 		if compatibleCount == 0 {
-			result.IsValid = false
 			result.ParticipantsWithNoOptions = append(result.ParticipantsWithNoOptions, giver.Name)
-			result.Errors = append(result.Errors,
+			result.AddError(ErrorKindNoValidRecipients,
 				fmt.Sprintf("participant %s has no valid recipients (excluded everyone or too many exclusions)", giver.Name))
 		}
 	}
@@ -133,15 +170,13 @@ func ValidateParticipants(participants []*participant.Participant) *ValidationRe
 	if result.IsValid && n <= 10 {
 		graph := buildCompatibilityGraph(participants, exclusionMap)
 		if !checkHallsTheorem(graph, n) {
-			result.IsValid = false
-			result.Errors = append(result.Errors,
+			result.AddError(ErrorKindInfeasible,
 				"impossible configuration detected: constraints are too restrictive (Hall's Marriage Theorem violation)")
 		}
 	} else if result.IsValid && n > 10 {
 		// For larger groups, use heuristic check
 		if !checkHeuristicFeasibility(participants, exclusionMap) {
-			result.IsValid = false
-			result.Errors = append(result.Errors,
+			result.AddError(ErrorKindInfeasible,
 				"impossible configuration detected: constraints appear too restrictive")
 		}
 	}
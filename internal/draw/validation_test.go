@@ -78,6 +78,10 @@ func TestValidateParticipants_DuplicateNames(t *testing.T) {
 	if len(result.Errors) == 0 {
 		t.Error("Expected errors for duplicate names")
 	}
+
+	if len(result.ErrorKinds) != len(result.Errors) || result.ErrorKinds[0] != ErrorKindDuplicateName {
+		t.Errorf("Expected a %s kind for each error, got %v", ErrorKindDuplicateName, result.ErrorKinds)
+	}
 }
 
 func TestValidateParticipants_NoValidRecipients(t *testing.T) {
@@ -121,6 +125,76 @@ func TestValidateParticipants_MissingContactInfo(t *testing.T) {
 	}
 }
 
+func TestValidateParticipants_SMSPhoneNumbers(t *testing.T) {
+	participants := []*participant.Participant{
+		{Name: "Alice", NotificationType: "sms", ContactInfo: []string{"+1 (555) 555-0123"}, Exclusions: []string{}},
+		{Name: "Bob", NotificationType: "sms", ContactInfo: []string{"555-0199"}, Exclusions: []string{}},
+		{Name: "Carol", NotificationType: "email", ContactInfo: []string{"carol@example.com"}, Exclusions: []string{}},
+	}
+
+	result := ValidateParticipants(participants)
+
+	if result.IsValid {
+		t.Error("Expected invalid for non-E.164 phone number")
+	}
+
+	if len(result.Errors) != 1 {
+		t.Errorf("Expected exactly 1 error for Bob's phone number, got: %v", result.Errors)
+	}
+}
+
+func TestValidateParticipants_FallbackChainContacts(t *testing.T) {
+	participants := []*participant.Participant{
+		{Name: "Alice", NotificationType: "email", ContactInfo: []string{"alice@example.com", "sms:+15555550123"}, Exclusions: []string{}},
+		{Name: "Bob", NotificationType: "email", ContactInfo: []string{"bob@example.com", "sms:not-a-phone"}, Exclusions: []string{}},
+	}
+
+	result := ValidateParticipants(participants)
+
+	if result.IsValid {
+		t.Error("Expected invalid for Bob's typed sms contact")
+	}
+
+	if len(result.Errors) != 1 {
+		t.Errorf("Expected exactly 1 error, got: %v", result.Errors)
+	}
+}
+
+func TestValidateParticipants_ContactChannelMismatch(t *testing.T) {
+	participants := []*participant.Participant{
+		{Name: "Alice", NotificationType: "email", ContactInfo: []string{"alice@example.com"}, Exclusions: []string{}},
+		{Name: "Carol", NotificationType: "email", ContactInfo: []string{"@carol"}, Exclusions: []string{}},
+		{Name: "Dave", NotificationType: "slack", ContactInfo: []string{"@dave"}, Exclusions: []string{}},
+	}
+
+	result := ValidateParticipants(participants)
+
+	if result.IsValid {
+		t.Error("Expected invalid for Slack handle on an email participant")
+	}
+
+	if len(result.Errors) != 1 {
+		t.Errorf("Expected exactly 1 error for Carol, got: %v", result.Errors)
+	}
+}
+
+func TestValidateParticipants_UnknownNotificationType(t *testing.T) {
+	participants := []*participant.Participant{
+		{Name: "Alice", NotificationType: "carrier-pigeon", ContactInfo: []string{"loft 3"}, Exclusions: []string{}},
+		{Name: "Bob", NotificationType: "email:work", ContactInfo: []string{"bob@example.com"}, Exclusions: []string{}},
+	}
+
+	result := ValidateParticipants(participants)
+
+	if !result.IsValid {
+		t.Errorf("Expected valid (unknown type is a warning): %v", result.Errors)
+	}
+
+	if len(result.Warnings) == 0 {
+		t.Error("Expected warning for unknown notification type")
+	}
+}
+
 func TestValidateParticipants_InvalidExclusion(t *testing.T) {
 	participants := []*participant.Participant{
 		{Name: "Alice", ContactInfo: []string{"alice@example.com"}, Exclusions: []string{"NonExistent"}},
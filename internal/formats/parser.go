@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/igodwin/secretsanta/pkg/participant"
@@ -113,6 +114,14 @@ func parseCSV(data []byte, delimiter rune) ([]*participant.Participant, error) {
 		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
 	}
 
+	// Optional columns are found by name
+	noRemindersCol := -1
+	for i, h := range header {
+		if h == "no_reminders" {
+			noRemindersCol = i
+		}
+	}
+
 	var participants []*participant.Participant
 	lineNum := 1 // Start from 1 since we already read the header
 
@@ -150,11 +159,22 @@ func parseCSV(data []byte, delimiter rune) ([]*participant.Participant, error) {
 			exclusions = parseListField(strings.TrimSpace(record[3]))
 		}
 
+		var noReminders bool
+		if noRemindersCol >= 0 && noRemindersCol < len(record) {
+			if value := strings.TrimSpace(record[noRemindersCol]); value != "" {
+				noReminders, err = strconv.ParseBool(value)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid no_reminders value %q", lineNum, value)
+				}
+			}
+		}
+
 		participants = append(participants, &participant.Participant{
 			Name:             name,
 			NotificationType: notificationType,
 			ContactInfo:      contactInfo,
 			Exclusions:       exclusions,
+			NoReminders:      noReminders,
 		})
 	}
 
@@ -241,6 +261,10 @@ func exportCSV(participants []*participant.Participant) ([]byte, string, error)
 
 	// Write header
 	header := []string{"name", "notification_type", "contact_info", "exclusions"}
+	withOptOut := anyNoReminders(participants)
+	if withOptOut {
+		header = append(header, "no_reminders")
+	}
 	if err := writer.Write(header); err != nil {
 		return nil, "", fmt.Errorf("failed to write CSV header: %w", err)
 	}
@@ -253,6 +277,9 @@ func exportCSV(participants []*participant.Participant) ([]byte, string, error)
 			strings.Join(p.ContactInfo, ","),
 			strings.Join(p.Exclusions, ","),
 		}
+		if withOptOut {
+			record = append(record, strconv.FormatBool(p.NoReminders))
+		}
 		if err := writer.Write(record); err != nil {
 			return nil, "", fmt.Errorf("failed to write CSV record: %w", err)
 		}
@@ -274,6 +301,10 @@ func exportTSV(participants []*participant.Participant) ([]byte, string, error)
 
 	// Write header
 	header := []string{"name", "notification_type", "contact_info", "exclusions"}
+	withOptOut := anyNoReminders(participants)
+	if withOptOut {
+		header = append(header, "no_reminders")
+	}
 	if err := writer.Write(header); err != nil {
 		return nil, "", fmt.Errorf("failed to write TSV header: %w", err)
 	}
@@ -286,6 +317,9 @@ func exportTSV(participants []*participant.Participant) ([]byte, string, error)
 			strings.Join(p.ContactInfo, ","),
 			strings.Join(p.Exclusions, ","),
 		}
+		if withOptOut {
+			record = append(record, strconv.FormatBool(p.NoReminders))
+		}
 		if err := writer.Write(record); err != nil {
 			return nil, "", fmt.Errorf("failed to write TSV record: %w", err)
 		}
@@ -298,3 +332,13 @@ func exportTSV(participants []*participant.Participant) ([]byte, string, error)
 
 	return []byte(sb.String()), "text/tab-separated-values", nil
 }
+
+// anyNoReminders reports whether the no_reminders column is needed
+func anyNoReminders(participants []*participant.Participant) bool {
+	for _, p := range participants {
+		if p.NoReminders {
+			return true
+		}
+	}
+	return false
+}
@@ -188,6 +188,36 @@ Alice,email,alice@example.com; alice.alt@example.com,Bob; Carol`)
 	}
 }
 
+func TestCSVNoReminders(t *testing.T) {
+	data := []byte(`name,notification_type,contact_info,exclusions,no_reminders
+Alice,email,alice@example.com,,true
+Bob,email,bob@example.com,,`)
+
+	participants, err := Parse(data, FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+
+	if !participants[0].NoReminders {
+		t.Error("Expected Alice to opt out of reminders")
+	}
+	if participants[1].NoReminders {
+		t.Error("Expected Bob to receive reminders")
+	}
+
+	exported, _, err := ExportParticipants(participants, FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to export CSV: %v", err)
+	}
+	roundTrip, err := Parse(exported, FormatCSV)
+	if err != nil {
+		t.Fatalf("Failed to parse exported CSV: %v", err)
+	}
+	if !roundTrip[0].NoReminders {
+		t.Errorf("Expected no_reminders to survive export, got %s", exported)
+	}
+}
+
 // Test export functions
 func TestExportJSON(t *testing.T) {
 	testParticipants := []*participant.Participant{
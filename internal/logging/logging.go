@@ -0,0 +1,113 @@
+// Package logging sets up the web server's structured logs: slog in text or
+// JSON, request IDs carried in contexts, and redaction of personal data
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+)
+
+// New returns a logger for cfg writing to out. Records logged with a
+// context carrying a request ID get a request_id attribute.
+func New(cfg config.LoggingConfig, out io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: use debug, info, warn or error", cfg.Level)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(out, opts)
+	case "json":
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q: use text or json", cfg.Format)
+	}
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+// Setup makes the logger for cfg the default, which the log package also
+// writes to, and sets whether personal data is logged
+func Setup(cfg config.LoggingConfig, out io.Writer) error {
+	logger, err := New(cfg, out)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	showPII.Store(cfg.LogPII && logger.Enabled(context.Background(), slog.LevelDebug))
+	if showPII.Load() {
+		logger.Warn("Logging participant names and contact addresses in full")
+	}
+	return nil
+}
+
+// LogConfig logs the configuration with secrets replaced and personal data
+// redacted like PII. Call it after Setup.
+func LogConfig(cfg *config.Config) {
+	slog.Info("Configuration", "config", config.Redacted(cfg, redact))
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns ctx carrying id for log records
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID in ctx, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random 16 character hex ID
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ValidRequestID reports whether an ID sent by a client or proxy is safe to
+// log and echo: up to 64 letters, digits, dashes, dots and underscores
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// contextHandler adds the context's request ID to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+)
+
+func TestNewRejectsBadConfig(t *testing.T) {
+	for _, cfg := range []config.LoggingConfig{
+		{Level: "verbose", Format: "text"},
+		{Level: "info", Format: "xml"},
+	} {
+		if _, err := New(cfg, &bytes.Buffer{}); err == nil {
+			t.Errorf("Expected an error for %+v", cfg)
+		}
+	}
+}
+
+func TestRequestIDAttribute(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(config.LoggingConfig{Level: "info", Format: "json"}, &out)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ctx := WithRequestID(context.Background(), "abc123")
+	logger.InfoContext(ctx, "Request", "status", 200)
+	logger.DebugContext(ctx, "Hidden")
+
+	var record map[string]any
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("Expected one JSON record, got %q: %v", out.String(), err)
+	}
+	if record["request_id"] != "abc123" || record["msg"] != "Request" {
+		t.Errorf("Expected the request ID on the record, got %v", record)
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	tests := map[string]bool{
+		"":                      false,
+		NewRequestID():          true,
+		"req-1.a_B":             true,
+		"with space":            false,
+		"new\nline":             false,
+		strings.Repeat("a", 64): true,
+		strings.Repeat("a", 65): false,
+	}
+	for id, want := range tests {
+		if got := ValidRequestID(id); got != want {
+			t.Errorf("ValidRequestID(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestPIIRedaction(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	defer showPII.Store(false)
+
+	tests := []struct {
+		name   string
+		cfg    config.LoggingConfig
+		redact bool
+	}{
+		{name: "default", cfg: config.LoggingConfig{Level: "info"}, redact: true},
+		{name: "log_pii above debug", cfg: config.LoggingConfig{Level: "info", LogPII: true}, redact: true},
+		{name: "debug without log_pii", cfg: config.LoggingConfig{Level: "debug"}, redact: true},
+		{name: "log_pii at debug", cfg: config.LoggingConfig{Level: "debug", LogPII: true}, redact: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := Setup(tt.cfg, &out); err != nil {
+				t.Fatalf("Setup() error = %v", err)
+			}
+			out.Reset()
+			slog.Info("Sent", "participant", PII("Alice"), "contacts", PIIList{"alice@example.com"})
+
+			leaked := strings.Contains(out.String(), "Alice") || strings.Contains(out.String(), "alice@example.com")
+			if leaked == tt.redact {
+				t.Errorf("Expected redact = %v, got %q", tt.redact, out.String())
+			}
+		})
+	}
+
+	showPII.Store(false)
+	if PII("Alice").LogValue().String() != PII("Alice").LogValue().String() {
+		t.Errorf("Expected redacted values to be stable within a run")
+	}
+	if PII("Alice").LogValue().String() == PII("Bob").LogValue().String() {
+		t.Errorf("Expected different values to redact differently")
+	}
+}
+
+func TestLogConfigRedactsPersonalData(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	var out bytes.Buffer
+	if err := Setup(config.LoggingConfig{Level: "info", Format: "json"}, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{}
+	cfg.SMTP.Username, cfg.SMTP.Password = "santa@example.com", "hunter2"
+	cfg.Notifier.ArchiveEmail = "archive@example.com"
+	cfg.Auth.OIDC.OrganizerEmails = []string{"carol@example.com"}
+	LogConfig(cfg)
+
+	for _, leaked := range []string{"santa@example.com", "hunter2", "archive@example.com", "carol@example.com"} {
+		if strings.Contains(out.String(), leaked) {
+			t.Errorf("Expected %q to be redacted, got %s", leaked, out.String())
+		}
+	}
+}
+
+func TestPIIErrorRedactsValues(t *testing.T) {
+	showPII.Store(false)
+	err := errors.New("failed to send sms to +15555550100 for Alice: status 400")
+	got := RedactError(err, "Alice", "+15555550100").LogValue().String()
+	if strings.Contains(got, "Alice") || strings.Contains(got, "+15555550100") {
+		t.Errorf("Expected personal data to be redacted, got %q", got)
+	}
+	if !strings.Contains(got, "status 400") {
+		t.Errorf("Expected the rest of the message to be kept, got %q", got)
+	}
+
+	showPII.Store(true)
+	defer showPII.Store(false)
+	if got := RedactError(err, "Alice").LogValue().String(); got != err.Error() {
+		t.Errorf("Expected the full message with log_pii, got %q", got)
+	}
+}
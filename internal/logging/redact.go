@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// showPII is set by Setup when personal data may be logged
+var showPII atomic.Bool
+
+// redactionKey keys the hashes of redacted values. It changes on every
+// start, so hashes correlate log lines of one run but can't be looked up
+// from a list of known names.
+var redactionKey = func() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}()
+
+// PII is a participant name, contact address or other personal data. It
+// logs as "redacted:" and a short hash unless Setup enabled log_pii at the
+// debug level.
+type PII string
+
+// LogValue implements slog.LogValuer
+func (p PII) LogValue() slog.Value {
+	return slog.StringValue(redact(string(p)))
+}
+
+// PIIList is a list of personal data, such as a participant's contact info
+type PIIList []string
+
+// LogValue implements slog.LogValuer
+func (l PIIList) LogValue() slog.Value {
+	values := make([]string, len(l))
+	for i, s := range l {
+		values[i] = redact(s)
+	}
+	return slog.AnyValue(values)
+}
+
+// PIIError is an error whose message may contain the personal data in
+// Values, such as the names and addresses of the participants it is about.
+// It logs with each of those values redacted like PII and the rest of the
+// message intact.
+type PIIError struct {
+	Err    error
+	Values []string
+}
+
+// RedactError returns err as a PIIError that hides values when logged
+func RedactError(err error, values ...string) PIIError {
+	return PIIError{Err: err, Values: values}
+}
+
+// LogValue implements slog.LogValuer
+func (e PIIError) LogValue() slog.Value {
+	if e.Err == nil {
+		return slog.AnyValue(nil)
+	}
+	message := e.Err.Error()
+	if showPII.Load() {
+		return slog.StringValue(message)
+	}
+	// Longest first, so a name inside a longer address is not replaced first
+	values := slices.Clone(e.Values)
+	slices.SortFunc(values, func(a, b string) int { return len(b) - len(a) })
+	for _, value := range values {
+		if value != "" {
+			message = strings.ReplaceAll(message, value, redact(value))
+		}
+	}
+	return slog.StringValue(message)
+}
+
+func redact(s string) string {
+	if s == "" || showPII.Load() {
+		return s
+	}
+	mac := hmac.New(sha256.New, redactionKey)
+	mac.Write([]byte(s))
+	return "redacted:" + hex.EncodeToString(mac.Sum(nil)[:4])
+}
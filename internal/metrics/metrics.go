@@ -0,0 +1,153 @@
+// Package metrics defines the web server's Prometheus metrics, served at
+// /metrics: draws, validation errors, notifications, notifier service calls
+// and HTTP requests
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/igodwin/secretsanta/internal/draw"
+)
+
+const namespace = "secretsanta"
+
+// Registry holds the metrics below and the Go runtime and process metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	// DrawsAttempted counts draws started after the request was checked
+	DrawsAttempted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "draws_attempted_total",
+		Help:      "Draws started after the request passed validation.",
+	})
+
+	// DrawsSucceeded counts draws whose assignments were made and sent or
+	// scheduled. Individual notification failures don't fail a draw.
+	DrawsSucceeded = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "draws_succeeded_total",
+		Help:      "Draws whose assignments were made and sent or scheduled.",
+	})
+
+	// DrawsFailed counts failed draws by reason: "no_assignment" when no
+	// valid assignment was found, "scheduling" when it couldn't be scheduled
+	DrawsFailed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "draws_failed_total",
+		Help:      "Draws that failed, by reason.",
+	}, []string{"reason"})
+
+	// DrawDuration is the time taken to find an assignment, by participant
+	// count range
+	DrawDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "draw_duration_seconds",
+		Help:      "Time taken to find an assignment, by participant count range.",
+		Buckets:   []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5},
+	}, []string{"participants"})
+
+	// ValidationErrors counts participant validation errors by kind, each
+	// time participants are validated
+	ValidationErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "validation_errors_total",
+		Help:      "Participant validation errors, by kind.",
+	}, []string{"kind"})
+
+	// Notifications counts notifications by channel and outcome: "sent",
+	// "scheduled" or "failed". A participant whose first channel fails and
+	// whose fallback succeeds counts once for each.
+	Notifications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Notifications by channel and outcome.",
+	}, []string{"channel", "outcome"})
+
+	// NotifierRequestDuration is the latency of notifier service calls by
+	// gRPC method and status code
+	NotifierRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "notifier_request_duration_seconds",
+		Help:      "Latency of notifier service calls, by method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	// NotifierUp is 1 when the notifier service's last health check passed
+	NotifierUp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "notifier_up",
+		Help:      "Whether the notifier service's last health check passed.",
+	})
+
+	// HTTPRequests counts HTTP requests by route pattern, method and status
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests, by route, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	// HTTPRequestDuration is the latency of HTTP requests by route pattern
+	// and method
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests, by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+)
+
+func init() {
+	Registry.MustRegister(
+		DrawsAttempted, DrawsSucceeded, DrawsFailed, DrawDuration,
+		ValidationErrors, Notifications,
+		NotifierRequestDuration, NotifierUp,
+		HTTPRequests, HTTPRequestDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ParticipantRange returns the participant count range of a draw, used as
+// a label so each draw size doesn't get its own series
+func ParticipantRange(n int) string {
+	switch {
+	case n <= 10:
+		return "1-10"
+	case n <= 50:
+		return "11-50"
+	case n <= 200:
+		return "51-200"
+	case n <= 1000:
+		return "201-1000"
+	}
+	return "1001+"
+}
+
+// ObserveValidation counts the errors of a participant validation by kind
+func ObserveValidation(result *draw.ValidationResult) {
+	for _, kind := range result.ErrorKinds {
+		ValidationErrors.WithLabelValues(kind).Inc()
+	}
+}
+
+// UnaryClientInterceptor times each notifier service call
+func UnaryClientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	NotifierRequestDuration.WithLabelValues(method, status.Code(err).String()).Observe(time.Since(start).Seconds())
+	return err
+}
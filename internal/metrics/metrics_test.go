@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/igodwin/secretsanta/internal/draw"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+func TestParticipantRange(t *testing.T) {
+	tests := map[int]string{2: "1-10", 10: "1-10", 11: "11-50", 200: "51-200", 1000: "201-1000", 1001: "1001+"}
+	for n, want := range tests {
+		if got := ParticipantRange(n); got != want {
+			t.Errorf("ParticipantRange(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestObserveValidation(t *testing.T) {
+	duplicates := ValidationErrors.WithLabelValues(draw.ErrorKindDuplicateName)
+	before := testutil.ToFloat64(duplicates)
+
+	ObserveValidation(draw.ValidateParticipants([]*participant.Participant{
+		{Name: "Alice", ContactInfo: []string{"alice@example.com"}},
+		{Name: "Alice", ContactInfo: []string{"alice2@example.com"}},
+		{Name: "Bob", ContactInfo: []string{"bob@example.com"}},
+	}))
+
+	if got := testutil.ToFloat64(duplicates) - before; got != 1 {
+		t.Errorf("Expected one duplicate_name error counted, got %v", got)
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	method := "/notifier.v1.NotifierService/HealthCheck"
+	before := testutil.CollectAndCount(NotifierRequestDuration)
+
+	err := UnaryClientInterceptor(context.Background(), method, nil, nil, nil,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return status.Error(codes.Unavailable, "down")
+		})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected the call's error to be returned, got %v", err)
+	}
+	if got := testutil.CollectAndCount(NotifierRequestDuration); got != before+1 {
+		t.Errorf("Expected a series for the failed call, got %d series (was %d)", got, before)
+	}
+}
@@ -0,0 +1,163 @@
+package notification
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+)
+
+// maxReconnectDelay caps the backoff between reconnection attempts, so a
+// restarted notifier service is picked up quickly
+const maxReconnectDelay = 30 * time.Second
+
+// shared is the long-lived notifier service client used by the web server and
+// notification paths. It is keyed by the settings it was created with.
+var shared struct {
+	mu       sync.Mutex
+	key      string
+	notifier *GRPCNotifier
+}
+
+// SharedNotifier returns the notifier service client for appConfig, connecting
+// on first use and reusing the connection afterwards. The client is replaced
+// when the address, API key or TLS settings change. Callers must not Close it;
+// use CloseSharedNotifier on shutdown.
+func SharedNotifier(appConfig *config.Config) (*GRPCNotifier, error) {
+	addr := serviceAddr(appConfig)
+	if addr == "" {
+		return nil, ErrNoNotifierService
+	}
+	key := fmt.Sprintf("%s|%s|%+v|%s|%s", addr, appConfig.Notifier.APIKey, appConfig.Notifier.TLS,
+		appConfig.Notifier.KeepaliveTime, appConfig.Notifier.KeepaliveTimeout)
+
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+
+	if shared.notifier != nil && shared.key == key {
+		shared.notifier.reconnectIfFailed()
+		return shared.notifier, nil
+	}
+
+	opts, err := dialOptions(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	g, err := NewGRPCNotifierWithOptions(addr, appConfig.Notifier.APIKey, &PapaElfTemplate{}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if appConfig.Notifier.APIKey != "" && !tlsEnabled(appConfig.Notifier.TLS) {
+		slog.Warn("The notifier API key is sent without TLS; set notifier.tls.enabled", "addr", addr)
+	}
+
+	if shared.notifier != nil {
+		shared.notifier.Close()
+	}
+	shared.key, shared.notifier = key, g
+	return g, nil
+}
+
+// CloseSharedNotifier closes the shared client, if one was created
+func CloseSharedNotifier() error {
+	shared.mu.Lock()
+	defer shared.mu.Unlock()
+
+	if shared.notifier == nil {
+		return nil
+	}
+	err := shared.notifier.Close()
+	shared.key, shared.notifier = "", nil
+	return err
+}
+
+// reconnectIfFailed skips the remaining backoff when the connection is down,
+// so a request made after the service recovers doesn't wait for the next
+// scheduled attempt
+func (g *GRPCNotifier) reconnectIfFailed() {
+	switch g.conn.GetState() {
+	case connectivity.TransientFailure:
+		g.conn.ResetConnectBackoff()
+	case connectivity.Idle:
+		g.conn.Connect()
+	}
+}
+
+// dialOptions returns the transport security, keepalive and reconnection
+// settings for the notifier service connection
+func dialOptions(appConfig *config.Config) ([]grpc.DialOption, error) {
+	creds := insecure.NewCredentials()
+	if tlsEnabled(appConfig.Notifier.TLS) {
+		tlsConfig, err := clientTLSConfig(appConfig.Notifier.TLS)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	backoffConfig := backoff.DefaultConfig
+	backoffConfig.MaxDelay = maxReconnectDelay
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: backoffConfig, MinConnectTimeout: 5 * time.Second}),
+	}
+	if appConfig.Notifier.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    appConfig.Notifier.KeepaliveTime,
+			Timeout: appConfig.Notifier.KeepaliveTimeout,
+		}))
+	}
+	return opts, nil
+}
+
+// tlsEnabled reports whether TLS is on, either explicitly or because
+// certificates were configured
+func tlsEnabled(tlsConfig config.NotifierTLSConfig) bool {
+	return tlsConfig.Enabled || tlsConfig.CAFile != "" || tlsConfig.CertFile != ""
+}
+
+// clientTLSConfig builds the TLS configuration, loading a custom CA and a
+// client certificate for mutual TLS when they are set
+func clientTLSConfig(tlsConfig config.NotifierTLSConfig) (*tls.Config, error) {
+	result := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: tlsConfig.ServerName,
+	}
+
+	if tlsConfig.CAFile != "" {
+		pem, err := os.ReadFile(tlsConfig.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read notifier CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in notifier CA file %s", tlsConfig.CAFile)
+		}
+		result.RootCAs = pool
+	}
+
+	if tlsConfig.CertFile != "" || tlsConfig.KeyFile != "" {
+		if tlsConfig.CertFile == "" || tlsConfig.KeyFile == "" {
+			return nil, fmt.Errorf("notifier tls cert_file and key_file must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(tlsConfig.CertFile, tlsConfig.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load notifier client certificate: %w", err)
+		}
+		result.Certificates = []tls.Certificate{cert}
+	}
+	return result, nil
+}
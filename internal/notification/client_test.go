@@ -0,0 +1,157 @@
+package notification
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+)
+
+// testCA issues certificates for TLS tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for 127.0.0.1 with the given usage
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "secretsanta-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSharedNotifierMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	serverCertPEM, serverKeyPEM := ca.issue(t, 2, x509.ExtKeyUsageServerAuth)
+	clientCertPEM, clientKeyPEM := ca.issue(t, 3, x509.ExtKeyUsageClientAuth)
+
+	serverCert, err := tls.X509KeyPair(serverCertPEM, serverKeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(ca.pem)
+	serverCreds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	addr := startFakeNotifierService(t, &fakeNotifierService{}, grpc.Creds(serverCreds))
+
+	cfg := &config.Config{}
+	cfg.Notifier.ServiceAddr = addr
+	cfg.Notifier.TLS = config.NotifierTLSConfig{
+		CAFile:   writeTestFile(t, dir, "ca.pem", ca.pem),
+		CertFile: writeTestFile(t, dir, "client.pem", clientCertPEM),
+		KeyFile:  writeTestFile(t, dir, "client-key.pem", clientKeyPEM),
+	}
+
+	client, err := SharedNotifier(cfg)
+	if err != nil {
+		t.Fatalf("SharedNotifier() error = %v", err)
+	}
+	if resp, err := client.HealthCheck(); err != nil || !resp.Healthy {
+		t.Fatalf("HealthCheck() over mTLS = %v, %v", resp, err)
+	}
+
+	again, _ := SharedNotifier(cfg)
+	if again != client {
+		t.Error("Expected the connection to be reused")
+	}
+
+	// Without a client certificate the server rejects the handshake
+	cfg.Notifier.TLS.CertFile, cfg.Notifier.TLS.KeyFile = "", ""
+	client, err = SharedNotifier(cfg)
+	if err != nil {
+		t.Fatalf("SharedNotifier() error = %v", err)
+	}
+	if client == again {
+		t.Error("Expected a new connection after the TLS settings changed")
+	}
+	if _, err := client.HealthCheck(); err == nil {
+		t.Error("Expected the health check to fail without a client certificate")
+	}
+}
+
+func TestClientTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := clientTLSConfig(config.NotifierTLSConfig{CAFile: filepath.Join(dir, "missing.pem")}); err == nil {
+		t.Error("Expected an error for a missing CA file")
+	}
+	if _, err := clientTLSConfig(config.NotifierTLSConfig{CAFile: writeTestFile(t, dir, "empty.pem", []byte("not a cert"))}); err == nil {
+		t.Error("Expected an error for a CA file without certificates")
+	}
+	if _, err := clientTLSConfig(config.NotifierTLSConfig{CertFile: "client.pem"}); err == nil {
+		t.Error("Expected an error for a certificate without a key")
+	}
+}
@@ -2,16 +2,22 @@ package notification
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"strings"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+	"github.com/igodwin/secretsanta/internal/logging"
+	"github.com/igodwin/secretsanta/internal/metrics"
+	"github.com/igodwin/secretsanta/internal/tracing"
+	"github.com/igodwin/secretsanta/pkg/notifier"
 	"github.com/igodwin/secretsanta/pkg/participant"
 )
 
@@ -19,6 +25,9 @@ import (
 type MessageTemplate interface {
 	Subject(giverName, recipientName string) string
 	Body(giverName, recipientName string) string
+	// ShortBody is the message for channels without long-form capability,
+	// such as SMS
+	ShortBody(giverName, recipientName string) string
 }
 
 // PapaElfTemplate is the default Secret Santa message template
@@ -52,6 +61,18 @@ Secret Santa Coordinator (Current)`,
 		giverName, recipientName)
 }
 
+// ShortBody fits a single 160 character SMS segment for typical name lengths
+func (t *PapaElfTemplate) ShortBody(giverName, recipientName string) string {
+	return fmt.Sprintf("Hi %s! Papa Elf here: you're Secret Santa for %s this year. Keep it secret & save this text!", giverName, recipientName)
+}
+
+// shortForm reports whether channelName is registered as needing a
+// short-form message
+func shortForm(channelName string) bool {
+	channel, ok := notifier.Lookup(channelName)
+	return ok && !channel.Capabilities.LongForm
+}
+
 type GRPCNotifier struct {
 	client   pb.NotifierServiceClient
 	conn     *grpc.ClientConn
@@ -65,7 +86,15 @@ func NewGRPCNotifier(serverAddr string) (*GRPCNotifier, error) {
 
 // NewGRPCNotifierWithAPIKey creates a notifier with an optional API key
 func NewGRPCNotifierWithAPIKey(serverAddr string, apiKey string, template MessageTemplate) (*GRPCNotifier, error) {
-	conn, err := grpc.NewClient(serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	return NewGRPCNotifierWithOptions(serverAddr, apiKey, template, grpc.WithTransportCredentials(insecure.NewCredentials()))
+}
+
+// NewGRPCNotifierWithOptions creates a notifier whose connection uses opts,
+// which must include transport credentials. Calls are timed for metrics and
+// traced, with the trace context sent in the call's metadata.
+func NewGRPCNotifierWithOptions(serverAddr string, apiKey string, template MessageTemplate, opts ...grpc.DialOption) (*GRPCNotifier, error) {
+	opts = append(opts, grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor, metrics.UnaryClientInterceptor))
+	conn, err := grpc.NewClient(serverAddr, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to notifier service: %w", err)
 	}
@@ -101,153 +130,296 @@ func (g *GRPCNotifier) contextWithAPIKey(ctx context.Context) context.Context {
 	return metadata.AppendToOutgoingContext(ctx, "authorization", fmt.Sprintf("Bearer %s", g.apiKey))
 }
 
-// parseNotificationType parses a notification type string that may include an account
-// Format: "type" or "type:account" (e.g., "email:notify")
-// Returns: (type, account)
-func parseNotificationType(notifType string) (string, string) {
-	parts := strings.SplitN(notifType, ":", 2)
-	if len(parts) == 2 {
-		return parts[0], parts[1]
+// serviceNotificationType maps a notification_type value to the notifier
+// service enum using the channel registry, defaulting to stdout. Registered
+// channels the service doesn't support map to unspecified, which the
+// service rejects, so delivery moves on to the next channel.
+func serviceNotificationType(notifType string) pb.NotificationType {
+	channel, ok := notifier.Lookup(notifType)
+	if !ok {
+		return pb.NotificationType_NOTIFICATION_TYPE_STDOUT
 	}
-	return parts[0], ""
+	if value, ok := pb.NotificationType_value[channel.ServiceType]; ok && channel.ServiceType != "" {
+		return pb.NotificationType(value)
+	}
+	return pb.NotificationType_NOTIFICATION_TYPE_UNSPECIFIED
 }
 
-func (g *GRPCNotifier) SendNotification(p *participant.Participant, archiveEmail string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	ctx = g.contextWithAPIKey(ctx)
+// buildRequest renders the assignment message for p into a notifier service
+// request addressed to one route of the participant's delivery chain.
+// A non-zero sendAt asks the service to hold the notification until then.
+// Short-form channels get the template's short body.
+func (g *GRPCNotifier) buildRequest(p *participant.Participant, route notifier.Route, archiveEmail string, contentType string, sendAt time.Time) *pb.SendNotificationRequest {
+	subject := g.template.Subject(p.Name, p.Recipient.Name)
+	body := g.template.Body(p.Name, p.Recipient.Name)
+	if shortForm(route.Channel) {
+		body = g.template.ShortBody(p.Name, p.Recipient.Name)
+	}
 
-	// Parse notification type and account
-	notifType, account := parseNotificationType(p.NotificationType)
-
-	notificationType := pb.NotificationType_NOTIFICATION_TYPE_STDOUT
-	switch notifType {
-	case "email":
-		notificationType = pb.NotificationType_NOTIFICATION_TYPE_EMAIL
-	case "slack":
-		notificationType = pb.NotificationType_NOTIFICATION_TYPE_SLACK
-	case "ntfy":
-		notificationType = pb.NotificationType_NOTIFICATION_TYPE_NTFY
+	// Build BCC list with archive email if provided
+	var bcc []string
+	if archiveEmail != "" {
+		bcc = []string{archiveEmail}
 	}
 
-	subject := g.template.Subject(p.Name, p.Recipient.Name)
-	body := g.template.Body(p.Name, p.Recipient.Name)
+	return newRequest(p, route, "secret_santa", subject, body, bcc, contentType, sendAt)
+}
 
+// newRequest builds a notifier service request carrying subject and body
+func newRequest(p *participant.Participant, route notifier.Route, eventType, subject, body string, bcc []string, contentType string, sendAt time.Time) *pb.SendNotificationRequest {
 	// Build recipients list - support multiple contact methods
-	recipients := make([]string, len(p.ContactInfo))
-	copy(recipients, p.ContactInfo)
+	recipients := make([]string, len(route.ContactInfo))
+	copy(recipients, route.ContactInfo)
 
 	// Add metadata
 	metadata := map[string]string{
 		"participant_name": p.Name,
 		"recipient_name":   p.Recipient.Name,
-		"event_type":       "secret_santa",
-	}
-
-	// Build BCC list with archive email if provided
-	var bcc []string
-	if archiveEmail != "" {
-		bcc = []string{archiveEmail}
+		"event_type":       eventType,
 	}
 
 	req := &pb.SendNotificationRequest{
-		Type:       notificationType,
-		Account:    account, // Set the account if specified
-		Priority:   pb.Priority_PRIORITY_NORMAL,
-		Subject:    subject,
-		Body:       body,
-		Recipients: recipients,
-		Bcc:        bcc,
-		Metadata:   metadata,
+		Type:        serviceNotificationType(route.Channel),
+		Account:     route.Account, // Set the account if specified
+		Priority:    pb.Priority_PRIORITY_NORMAL,
+		Subject:     subject,
+		Body:        body,
+		Recipients:  recipients,
+		Bcc:         bcc,
+		Metadata:    metadata,
+		ContentType: contentType,
+	}
+	if !sendAt.IsZero() {
+		req.ScheduledFor = timestamppb.New(sendAt)
 	}
+	return req
+}
 
-	resp, err := g.client.SendNotification(ctx, req)
-	if err != nil {
-		return fmt.Errorf("failed to send notification: %w", err)
+// SendNotification delivers one participant's assignment, trying each
+// channel in their delivery chain until the notifier service accepts one
+func (g *GRPCNotifier) SendNotification(ctx context.Context, p *participant.Participant, archiveEmail string) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	ctx = g.contextWithAPIKey(ctx)
+
+	var failures []string
+	for _, route := range notifier.Routes(p) {
+		req := g.buildRequest(p, route, archiveEmail, "", time.Time{})
+
+		resp, err := g.client.SendNotification(ctx, req)
+		if err == nil && !resp.Result.Success {
+			err = fmt.Errorf("notification failed: %s", resp.Result.Error)
+		}
+		if err != nil {
+			countNotification(route.Channel, "failed")
+			failures = append(failures, fmt.Sprintf("%s: %v", route.Channel, err))
+			continue
+		}
+
+		countNotification(route.Channel, "sent")
+		p.DeliveredVia = route.Channel
+		p.NotificationID = resp.Result.NotificationId
+		slog.InfoContext(ctx, "Notification sent", "recipients", logging.PIIList(req.Recipients), "channel", route.Channel, "notification_id", resp.Result.NotificationId)
+		return nil
 	}
+	return fmt.Errorf("failed to send notification: %s", strings.Join(failures, "; "))
+}
+
+// SendBatchNotifications delivers every participant's assignment in batches.
+// Participants whose channel fails are retried on the next channel of their
+// delivery chain in a follow-up batch.
+func (g *GRPCNotifier) SendBatchNotifications(ctx context.Context, participants []*participant.Participant, archiveEmail string, contentType string) error {
+	return g.ScheduleBatchNotifications(ctx, participants, archiveEmail, contentType, time.Time{})
+}
 
-	if !resp.Result.Success {
-		return fmt.Errorf("notification failed: %s", resp.Result.Error)
+// ScheduleBatchNotifications is SendBatchNotifications with the notifier
+// service asked to hold every notification until sendAt. A zero sendAt
+// delivers immediately. The service accepts scheduled notifications up
+// front, so fallback channels are only tried if it rejects one.
+func (g *GRPCNotifier) ScheduleBatchNotifications(ctx context.Context, participants []*participant.Participant, archiveEmail string, contentType string, sendAt time.Time) error {
+	build := func(p *participant.Participant, route notifier.Route) *pb.SendNotificationRequest {
+		return g.buildRequest(p, route, archiveEmail, contentType, sendAt)
 	}
+	return g.sendBatch(ctx, participants, build, func(p *participant.Participant, channel, id string) {
+		p.DeliveredVia = channel
+		p.NotificationID = id
+	})
+}
 
-	log.Printf("Notification sent successfully to %v (ID: %s)", recipients, resp.Result.NotificationId)
-	return nil
+// ScheduleMessages sends a free-form message, such as a reminder, to each
+// participant, held by the notifier service until sendAt. render returns the
+// subject and body for a participant.
+func (g *GRPCNotifier) ScheduleMessages(ctx context.Context, participants []*participant.Participant, render func(p *participant.Participant) (string, string), contentType string, sendAt time.Time) error {
+	build := func(p *participant.Participant, route notifier.Route) *pb.SendNotificationRequest {
+		subject, body := render(p)
+		return newRequest(p, route, "secret_santa_reminder", subject, body, nil, contentType, sendAt)
+	}
+	return g.sendBatch(ctx, participants, build, func(*participant.Participant, string, string) {})
 }
 
-func (g *GRPCNotifier) SendBatchNotifications(participants []*participant.Participant, archiveEmail string, contentType string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+// sendBatch sends one request per participant in batches, retrying
+// participants whose channel fails on the next route of their delivery chain.
+// delivered is called with the channel that accepted each participant's
+// request and the notification ID the service assigned. Participants whose
+// last channel fails too are reported together in the returned error.
+func (g *GRPCNotifier) sendBatch(ctx context.Context, participants []*participant.Participant, build func(p *participant.Participant, route notifier.Route) *pb.SendNotificationRequest, delivered func(p *participant.Participant, channel, id string)) error {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
 	defer cancel()
 	ctx = g.contextWithAPIKey(ctx)
 
-	var requests []*pb.SendNotificationRequest
+	type pending struct {
+		participant *participant.Participant
+		routes      []notifier.Route
+	}
+	var queue []pending
+	var failures []string
 	for _, p := range participants {
-		// Parse notification type and account
-		notifType, account := parseNotificationType(p.NotificationType)
-
-		notificationType := pb.NotificationType_NOTIFICATION_TYPE_STDOUT
-		switch notifType {
-		case "email":
-			notificationType = pb.NotificationType_NOTIFICATION_TYPE_EMAIL
-		case "slack":
-			notificationType = pb.NotificationType_NOTIFICATION_TYPE_SLACK
-		case "ntfy":
-			notificationType = pb.NotificationType_NOTIFICATION_TYPE_NTFY
-		}
-
-		subject := g.template.Subject(p.Name, p.Recipient.Name)
-		body := g.template.Body(p.Name, p.Recipient.Name)
+		queue = append(queue, pending{participant: p, routes: notifier.Routes(p)})
+	}
 
-		// Build recipients list - support multiple contact methods
-		recipients := make([]string, len(p.ContactInfo))
-		copy(recipients, p.ContactInfo)
+	for len(queue) > 0 {
+		var requests []*pb.SendNotificationRequest
+		for _, item := range queue {
+			requests = append(requests, build(item.participant, item.routes[0]))
+		}
 
-		// Add metadata
-		metadata := map[string]string{
-			"participant_name": p.Name,
-			"recipient_name":   p.Recipient.Name,
-			"event_type":       "secret_santa",
+		batchReq := &pb.SendBatchNotificationsRequest{
+			Notifications: requests,
 		}
 
-		// Build BCC list with archive email if provided
-		var bcc []string
-		if archiveEmail != "" {
-			bcc = []string{archiveEmail}
+		resp, err := g.client.SendBatchNotifications(ctx, batchReq)
+		if err != nil {
+			return fmt.Errorf("failed to send batch notifications: %w", err)
 		}
 
-		req := &pb.SendNotificationRequest{
-			Type:        notificationType,
-			Account:     account, // Set the account if specified
-			Priority:    pb.Priority_PRIORITY_NORMAL,
-			Subject:     subject,
-			Body:        body,
-			Recipients:  recipients,
-			Bcc:         bcc,
-			Metadata:    metadata,
-			ContentType: contentType,
+		var next []pending
+		for i, item := range queue {
+			channel := item.routes[0].Channel
+			if i < len(resp.Results) && resp.Results[i].Success {
+				outcome := "sent"
+				if requests[i].ScheduledFor != nil {
+					outcome = "scheduled"
+				}
+				countNotification(channel, outcome)
+				delivered(item.participant, channel, resp.Results[i].NotificationId)
+				slog.InfoContext(ctx, "Notification sent", "recipients", logging.PIIList(item.routes[0].ContactInfo), "channel", channel, "notification_id", resp.Results[i].NotificationId)
+				continue
+			}
+
+			reason := "no result returned"
+			if i < len(resp.Results) {
+				reason = resp.Results[i].Error
+			}
+			countNotification(channel, "failed")
+			slog.WarnContext(ctx, "Notification failed", "recipients", logging.PIIList(item.routes[0].ContactInfo), "channel", channel,
+				"reason", logging.RedactError(errors.New(reason), notifier.PersonalData(item.participant)...))
+			if len(item.routes) > 1 {
+				next = append(next, pending{participant: item.participant, routes: item.routes[1:]})
+				continue
+			}
+			failures = append(failures, fmt.Sprintf("%s: %s: %s", item.participant.Name, channel, reason))
 		}
-		requests = append(requests, req)
+		queue = next
 	}
 
-	batchReq := &pb.SendBatchNotificationsRequest{
-		Notifications: requests,
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to notify %d of %d participants: %s", len(failures), len(participants), strings.Join(failures, "; "))
 	}
+	return nil
+}
+
+// GetNotification returns the notification with the given ID
+func (g *GRPCNotifier) GetNotification(id string) (*pb.Notification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = g.contextWithAPIKey(ctx)
 
-	resp, err := g.client.SendBatchNotifications(ctx, batchReq)
+	resp, err := g.client.GetNotification(ctx, &pb.GetNotificationRequest{Id: id})
 	if err != nil {
-		return fmt.Errorf("failed to send batch notifications: %w", err)
+		return nil, fmt.Errorf("failed to get notification %s: %w", id, err)
 	}
+	return resp.Notification, nil
+}
 
-	for i, result := range resp.Results {
-		if !result.Success {
-			log.Printf("Failed to send notification to %v: %s", participants[i].ContactInfo, result.Error)
-		} else {
-			log.Printf("Notification sent successfully to %v (ID: %s)", participants[i].ContactInfo, result.NotificationId)
-		}
+// ListNotifications returns the notifications with the given IDs
+func (g *GRPCNotifier) ListNotifications(ids []string) ([]*pb.Notification, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = g.contextWithAPIKey(ctx)
+
+	resp, err := g.client.ListNotifications(ctx, &pb.ListNotificationsRequest{
+		Filter: &pb.NotificationFilter{Ids: ids, Limit: int32(len(ids))},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	return resp.Notifications, nil
+}
+
+// RetryNotification asks the service to send a failed notification again
+func (g *GRPCNotifier) RetryNotification(id string) (*pb.NotificationResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ctx = g.contextWithAPIKey(ctx)
+
+	resp, err := g.client.RetryNotification(ctx, &pb.RetryNotificationRequest{Id: id})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry notification %s: %w", id, err)
 	}
+	if resp.Result != nil && !resp.Result.Success {
+		return resp.Result, fmt.Errorf("retry failed: %s", resp.Result.Error)
+	}
+	return resp.Result, nil
+}
 
+// CancelNotification cancels a notification that has not been sent yet
+func (g *GRPCNotifier) CancelNotification(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = g.contextWithAPIKey(ctx)
+
+	resp, err := g.client.CancelNotification(ctx, &pb.CancelNotificationRequest{Id: id})
+	if err != nil {
+		return fmt.Errorf("failed to cancel notification %s: %w", id, err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("cancel failed: %s", resp.Message)
+	}
 	return nil
 }
 
+// HealthCheck asks the notifier service whether it and its components are healthy
+func (g *GRPCNotifier) HealthCheck() (*pb.HealthCheckResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = g.contextWithAPIKey(ctx)
+
+	resp, err := g.client.HealthCheck(ctx, &pb.HealthCheckRequest{})
+	if err != nil {
+		metrics.NotifierUp.Set(0)
+		return nil, fmt.Errorf("health check failed: %w", err)
+	}
+	if resp.Healthy {
+		metrics.NotifierUp.Set(1)
+	} else {
+		metrics.NotifierUp.Set(0)
+	}
+	return resp, nil
+}
+
+// GetStats returns the notifier service's delivery statistics
+func (g *GRPCNotifier) GetStats() (*pb.GetStatsResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = g.contextWithAPIKey(ctx)
+
+	resp, err := g.client.GetStats(ctx, &pb.GetStatsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+	return resp, nil
+}
+
 // GetNotifiers queries the notifier service for available notification types
 func (g *GRPCNotifier) GetNotifiers() ([]*pb.NotifierInfo, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
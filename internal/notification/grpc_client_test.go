@@ -0,0 +1,121 @@
+package notification
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+func TestBuildRequestSetsScheduledFor(t *testing.T) {
+	g := &GRPCNotifier{template: &PapaElfTemplate{}}
+	p := &participant.Participant{Name: "Alice", NotificationType: "email", Recipient: &participant.Participant{Name: "Bob"}}
+	route := notifier.Route{Channel: "email", ContactInfo: []string{"alice@example.com"}}
+
+	if req := g.buildRequest(p, route, "", "", time.Time{}); req.ScheduledFor != nil {
+		t.Errorf("Expected no scheduled_for for immediate delivery, got %v", req.ScheduledFor)
+	}
+
+	sendAt := time.Date(2026, 12, 1, 9, 0, 0, 0, time.UTC)
+	req := g.buildRequest(p, route, "", "", sendAt)
+	if req.ScheduledFor == nil || !req.ScheduledFor.AsTime().Equal(sendAt) {
+		t.Errorf("Expected scheduled_for %v, got %v", sendAt, req.ScheduledFor)
+	}
+}
+
+func TestBuildRequestForSMS(t *testing.T) {
+	g := &GRPCNotifier{template: &PapaElfTemplate{}}
+	p := &participant.Participant{Name: "Alice", NotificationType: "sms", Recipient: &participant.Participant{Name: "Bob"}}
+
+	req := g.buildRequest(p, notifier.Route{Channel: "sms", ContactInfo: []string{"+15555550123"}}, "", "", time.Time{})
+	if req.Body != g.template.ShortBody("Alice", "Bob") {
+		t.Errorf("Expected the short body, got %q", req.Body)
+	}
+	// The service has no SMS type, so it must reject the request rather
+	// than print it to stdout
+	if req.Type != pb.NotificationType_NOTIFICATION_TYPE_UNSPECIFIED {
+		t.Errorf("Expected an unspecified type, got %v", req.Type)
+	}
+}
+
+func TestSendBatchPropagatesTraceContext(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	var traceparent []string
+	capture := grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		traceparent = md.Get("traceparent")
+		return handler(ctx, req)
+	})
+	addr := startFakeNotifierService(t, &fakeNotifierService{notifications: make(map[string]*pb.Notification)}, capture)
+
+	client, err := NewGRPCNotifier(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "draw")
+	bob := &participant.Participant{Name: "Bob", NotificationType: "email", ContactInfo: []string{"bob@example.com"}}
+	alice := &participant.Participant{Name: "Alice", NotificationType: "email", ContactInfo: []string{"alice@example.com"}, Recipient: bob}
+	if err := client.SendBatchNotifications(ctx, []*participant.Participant{alice}, "", ""); err != nil {
+		t.Fatalf("SendBatchNotifications() error = %v", err)
+	}
+	parent.End()
+
+	traceID := parent.SpanContext().TraceID().String()
+	if len(traceparent) != 1 || !strings.Contains(traceparent[0], traceID) {
+		t.Errorf("Expected the service to receive trace %s, got %v", traceID, traceparent)
+	}
+	var calls int
+	for _, span := range exporter.GetSpans() {
+		if span.Name == pb.NotifierService_SendBatchNotifications_FullMethodName && span.Parent.SpanID() == parent.SpanContext().SpanID() {
+			calls++
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected one client span for the batch under the draw span, got %d", calls)
+	}
+}
+
+func TestSendBatchReportsParticipantsWithNoChannelLeft(t *testing.T) {
+	fake := &fakeNotifierService{
+		notifications: make(map[string]*pb.Notification),
+		rejected:      map[pb.NotificationType]bool{pb.NotificationType_NOTIFICATION_TYPE_EMAIL: true},
+	}
+	client, err := NewGRPCNotifier(startFakeNotifierService(t, fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	carol := &participant.Participant{Name: "Carol"}
+	alice := &participant.Participant{Name: "Alice", NotificationType: "email", ContactInfo: []string{"alice@example.com", "slack:@alice"}, Recipient: carol}
+	bob := &participant.Participant{Name: "Bob", NotificationType: "email", ContactInfo: []string{"bob@example.com"}, Recipient: carol}
+
+	err = client.SendBatchNotifications(context.Background(), []*participant.Participant{alice, bob}, "", "")
+	if err == nil || !strings.Contains(err.Error(), "1 of 2") || !strings.Contains(err.Error(), "Bob") || strings.Contains(err.Error(), "Alice") {
+		t.Fatalf("Expected an error naming only Bob, got %v", err)
+	}
+	if alice.DeliveredVia != "slack" {
+		t.Errorf("Expected Alice to fall back to slack, got %q", alice.DeliveredVia)
+	}
+	if bob.DeliveredVia != "" {
+		t.Errorf("Expected no delivery for Bob, got %q", bob.DeliveredVia)
+	}
+}
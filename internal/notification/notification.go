@@ -1,67 +1,161 @@
 package notification
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/igodwin/secretsanta/internal/logging"
+	"github.com/igodwin/secretsanta/internal/metrics"
 	"github.com/igodwin/secretsanta/pkg/config"
 	"github.com/igodwin/secretsanta/pkg/notifier"
 	"github.com/igodwin/secretsanta/pkg/participant"
 )
 
-func Send(participants []*participant.Participant, appConfig *config.Config) error {
-	// Check for service address from config first, then environment
-	notifierServiceAddr := appConfig.Notifier.ServiceAddr
-	if notifierServiceAddr == "" {
-		notifierServiceAddr = os.Getenv("NOTIFIER_SERVICE_ADDR")
+// Send delivers the drawn participants' assignments now. ctx carries the
+// request ID for logs.
+func Send(ctx context.Context, participants []*participant.Participant, appConfig *config.Config) error {
+	if serviceAddr(appConfig) != "" {
+		return sendViaGRPC(ctx, participants, appConfig)
 	}
 
-	if notifierServiceAddr != "" {
-		return sendViaGRPC(participants, notifierServiceAddr, appConfig.Notifier.ArchiveEmail, appConfig.Notifier.APIKey, appConfig.SMTP.ContentType)
+	return sendViaLegacy(ctx, participants, appConfig)
+}
+
+// serviceAddr returns the notifier service address from config first, then environment
+func serviceAddr(appConfig *config.Config) string {
+	if appConfig.Notifier.ServiceAddr != "" {
+		return appConfig.Notifier.ServiceAddr
 	}
+	return os.Getenv("NOTIFIER_SERVICE_ADDR")
+}
 
-	return sendViaLegacy(participants, appConfig)
+func sendViaGRPC(ctx context.Context, participants []*participant.Participant, appConfig *config.Config) error {
+	return scheduleViaGRPC(ctx, participants, appConfig, time.Time{})
 }
 
-func sendViaGRPC(participants []*participant.Participant, serverAddr, archiveEmail, apiKey string, contentType string) error {
-	grpcNotifier, err := NewGRPCNotifierWithAPIKey(serverAddr, apiKey, &PapaElfTemplate{})
+func scheduleViaGRPC(ctx context.Context, participants []*participant.Participant, appConfig *config.Config, sendAt time.Time) error {
+	grpcNotifier, err := SharedNotifier(appConfig)
 	if err != nil {
 		return fmt.Errorf("failed to create gRPC notifier: %w", err)
 	}
-	defer grpcNotifier.Close()
 
-	return grpcNotifier.SendBatchNotifications(participants, archiveEmail, contentType)
+	return grpcNotifier.ScheduleBatchNotifications(ctx, participants, appConfig.Notifier.ArchiveEmail, appConfig.SMTP.ContentType, sendAt)
 }
 
-func sendViaLegacy(participants []*participant.Participant, appConfig *config.Config) error {
-	var notifierInstance notifier.Notifier
-	var emailNotifier = &notifier.EmailNotifier{
-		Host:        appConfig.SMTP.Host,
-		Port:        appConfig.SMTP.Port,
-		Identity:    appConfig.SMTP.Identity,
-		Username:    appConfig.SMTP.Username,
-		Password:    appConfig.SMTP.Password,
-		FromAddress: appConfig.SMTP.FromAddress,
-		FromName:    appConfig.SMTP.FromName,
-		ContentType: appConfig.SMTP.ContentType,
+func scheduleMessagesViaGRPC(ctx context.Context, participants []*participant.Participant, render func(p *participant.Participant) (string, string), appConfig *config.Config, sendAt time.Time) error {
+	grpcNotifier, err := SharedNotifier(appConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create gRPC notifier: %w", err)
 	}
 
-	for _, participant := range participants {
-		switch participant.NotificationType {
-		case "email":
-			notifierInstance = emailNotifier
-		default:
-			notifierInstance = &notifier.Stdout{}
-		}
+	return grpcNotifier.ScheduleMessages(ctx, participants, render, appConfig.SMTP.ContentType, sendAt)
+}
 
-		err := notifierInstance.IsConfigured()
-		if err != nil {
+func sendViaLegacy(ctx context.Context, participants []*participant.Participant, appConfig *config.Config) error {
+	// Reuse one notifier per channel for the whole draw
+	instances := make(map[string]notifier.Notifier)
+
+	for _, p := range participants {
+		if err := deliverLegacy(ctx, p, appConfig, instances); err != nil {
 			return err
 		}
-		err = notifierInstance.SendNotification(participant)
+	}
+	return nil
+}
+
+// deliverLegacy tries each channel in the participant's delivery chain until
+// one succeeds, recording the delivering channel on the participant.
+// Short-form channels are sent the template's short body.
+func deliverLegacy(ctx context.Context, p *participant.Participant, appConfig *config.Config, instances map[string]notifier.Notifier) error {
+	template := &PapaElfTemplate{}
+	return deliverLegacyWith(ctx, p, appConfig, instances, func(n notifier.Notifier, routed *participant.Participant) error {
+		if channel, _ := notifier.SplitNotificationType(routed.NotificationType); shortForm(channel) {
+			sender, ok := n.(notifier.MessageSender)
+			if !ok {
+				return fmt.Errorf("channel does not support messages")
+			}
+			return sender.SendMessage(routed, "", template.ShortBody(routed.Name, routed.Recipient.Name))
+		}
+		return n.SendNotification(routed)
+	})
+}
+
+// deliverMessageLegacy is deliverLegacy for a free-form message, skipping
+// channels whose notifier cannot send one
+func deliverMessageLegacy(ctx context.Context, p *participant.Participant, subject, body string, appConfig *config.Config, instances map[string]notifier.Notifier) error {
+	return deliverLegacyWith(ctx, p, appConfig, instances, func(n notifier.Notifier, routed *participant.Participant) error {
+		sender, ok := n.(notifier.MessageSender)
+		if !ok {
+			return fmt.Errorf("channel does not support messages")
+		}
+		return sender.SendMessage(routed, subject, body)
+	})
+}
+
+func deliverLegacyWith(ctx context.Context, p *participant.Participant, appConfig *config.Config, instances map[string]notifier.Notifier, send func(notifier.Notifier, *participant.Participant) error) error {
+	var failures []string
+	for _, route := range notifier.Routes(p) {
+		notifierInstance, channelName, err := localNotifier(route.Channel, appConfig, instances)
+		if err == nil {
+			err = notifierInstance.IsConfigured()
+		}
+		if err == nil {
+			err = send(notifierInstance, notifier.ForRoute(p, route))
+		}
 		if err != nil {
-			return err
+			slog.WarnContext(ctx, "Delivery failed", "participant", logging.PII(p.Name), "channel", route.Channel, "error", logging.RedactError(err, notifier.PersonalData(p)...))
+			countNotification(channelName, "failed")
+			failures = append(failures, fmt.Sprintf("%s: %v", route.Channel, err))
+			continue
 		}
+
+		p.DeliveredVia = channelName
+		countNotification(channelName, "sent")
+		return nil
 	}
-	return nil
-}
\ No newline at end of file
+	return fmt.Errorf("failed to notify %s: %s", p.Name, strings.Join(failures, "; "))
+}
+
+// countNotification records a notification outcome under the channel's
+// registered name, so account suffixes and unknown channels don't each get
+// their own series
+func countNotification(channel, outcome string) {
+	name, _ := notifier.SplitNotificationType(channel)
+	if _, ok := notifier.Lookup(name); !ok {
+		name = "unknown"
+	}
+	metrics.Notifications.WithLabelValues(name, outcome).Inc()
+}
+
+// localNotifier returns the registered in-process notifier for notifType and
+// its channel name. A participant without a notification type gets stdout.
+// Unknown and service-only channels are an error, so the delivery chain moves
+// on to the participant's next channel instead of counting stdout as a
+// delivery.
+func localNotifier(notifType string, appConfig *config.Config, instances map[string]notifier.Notifier) (notifier.Notifier, string, error) {
+	name, _ := notifier.SplitNotificationType(notifType)
+	if name == "" {
+		name = "stdout"
+	}
+	channel, ok := notifier.Lookup(name)
+	if !ok {
+		return nil, name, fmt.Errorf("unknown notification channel %q", name)
+	}
+	if !channel.Local() {
+		return nil, name, fmt.Errorf("%s is only available through the notifier service", name)
+	}
+
+	if instance, ok := instances[name]; ok {
+		return instance, name, nil
+	}
+	instance, err := channel.New(appConfig)
+	if err != nil {
+		return nil, name, err
+	}
+	instances[name] = instance
+	return instance, name, nil
+}
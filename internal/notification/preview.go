@@ -0,0 +1,149 @@
+package notification
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+// PlaceholderRecipient stands in for the drawn recipient in previews, so
+// rendering messages never reveals an assignment
+const PlaceholderRecipient = "[Secret Recipient]"
+
+var unsafeFilenameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Preview holds the messages one participant would receive, one for each
+// channel in their delivery chain
+type Preview struct {
+	Participant string              `json:"participant"`
+	Messages    []*notifier.Message `json:"messages"`
+	// Errors lists channels that could not be rendered or would fail to send
+	Errors []string `json:"errors,omitempty"`
+}
+
+// RenderPreviews renders every message Send would deliver, using the same
+// templates and channel selection, without sending anything. Recipients are
+// replaced with PlaceholderRecipient.
+func RenderPreviews(participants []*participant.Participant, appConfig *config.Config) []*Preview {
+	previews := make([]*Preview, 0, len(participants))
+
+	if serviceAddr(appConfig) != "" {
+		g := &GRPCNotifier{template: &PapaElfTemplate{}}
+		for _, p := range participants {
+			previews = append(previews, g.preview(withPlaceholder(p), appConfig))
+		}
+		return previews
+	}
+
+	instances := make(map[string]notifier.Notifier)
+	for _, p := range participants {
+		previews = append(previews, previewLegacy(withPlaceholder(p), appConfig, instances))
+	}
+	return previews
+}
+
+// preview renders the notifier service requests for each route of p
+func (g *GRPCNotifier) preview(p *participant.Participant, appConfig *config.Config) *Preview {
+	preview := &Preview{Participant: p.Name}
+	contentType := appConfig.SMTP.ContentType
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+
+	for _, route := range notifier.Routes(p) {
+		req := g.buildRequest(p, route, appConfig.Notifier.ArchiveEmail, contentType, time.Time{})
+		preview.Messages = append(preview.Messages, &notifier.Message{
+			Channel:     route.Channel,
+			Recipients:  req.Recipients,
+			BCC:         req.Bcc,
+			Subject:     req.Subject,
+			Body:        req.Body,
+			ContentType: req.ContentType,
+		})
+	}
+	return preview
+}
+
+// previewLegacy renders the message of each in-process notifier in the
+// participant's delivery chain, with the template's short body for
+// short-form channels as deliverLegacy sends
+func previewLegacy(p *participant.Participant, appConfig *config.Config, instances map[string]notifier.Notifier) *Preview {
+	preview := &Preview{Participant: p.Name}
+
+	for _, route := range notifier.Routes(p) {
+		notifierInstance, channelName, err := localNotifier(route.Channel, appConfig, instances)
+		if err != nil {
+			preview.Errors = append(preview.Errors, fmt.Sprintf("%s: %v", route.Channel, err))
+			continue
+		}
+		previewer, ok := notifierInstance.(notifier.Previewer)
+		if !ok {
+			preview.Errors = append(preview.Errors, fmt.Sprintf("%s: preview is not supported", channelName))
+			continue
+		}
+
+		message, err := previewer.Preview(notifier.ForRoute(p, route))
+		if err != nil {
+			preview.Errors = append(preview.Errors, fmt.Sprintf("%s: %v", channelName, err))
+			continue
+		}
+		if shortForm(route.Channel) {
+			message.Body = (&PapaElfTemplate{}).ShortBody(p.Name, p.Recipient.Name)
+		}
+		preview.Messages = append(preview.Messages, message)
+
+		if err := notifierInstance.IsConfigured(); err != nil {
+			preview.Errors = append(preview.Errors, fmt.Sprintf("%s: %v (delivery would fail)", channelName, err))
+		}
+	}
+	return preview
+}
+
+// withPlaceholder returns a copy of p whose recipient is PlaceholderRecipient
+func withPlaceholder(p *participant.Participant) *participant.Participant {
+	placeholder := *p
+	placeholder.Recipient = &participant.Participant{Name: PlaceholderRecipient}
+	return &placeholder
+}
+
+// safeFilename reduces value to lowercase letters, digits and dashes, so
+// names and channels from a participants file can't leave the directory
+func safeFilename(value string) string {
+	return strings.Trim(unsafeFilenameChars.ReplaceAllString(strings.ToLower(value), "-"), "-")
+}
+
+// WritePreviews writes every previewed message to dir as an .eml file and
+// returns the paths written. Files are readable only by the current user.
+func WritePreviews(dir string, previews []*Preview) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create preview directory: %w", err)
+	}
+
+	var paths []string
+	for i, preview := range previews {
+		name := safeFilename(preview.Participant)
+		for _, message := range preview.Messages {
+			path := filepath.Join(dir, fmt.Sprintf("%03d-%s-%s.eml", i+1, name, safeFilename(message.Channel)))
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+			if err != nil {
+				return paths, fmt.Errorf("failed to write preview: %w", err)
+			}
+			err = message.WriteEML(f)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return paths, fmt.Errorf("failed to write preview %s: %w", path, err)
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
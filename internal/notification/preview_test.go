@@ -0,0 +1,125 @@
+package notification
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+func TestRenderPreviewsUsesPlaceholderRecipient(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+
+	bob := &participant.Participant{Name: "Bob", NotificationType: "stdout"}
+	alice := &participant.Participant{Name: "Alice", NotificationType: "stdout", Recipient: bob}
+
+	previews := RenderPreviews([]*participant.Participant{alice}, &config.Config{})
+	if len(previews) != 1 || len(previews[0].Messages) != 1 {
+		t.Fatalf("Expected one preview with one message, got %+v", previews)
+	}
+
+	body := previews[0].Messages[0].Body
+	if strings.Contains(body, "Bob") {
+		t.Errorf("Preview revealed the recipient: %q", body)
+	}
+	if !strings.Contains(body, PlaceholderRecipient) {
+		t.Errorf("Expected placeholder recipient in %q", body)
+	}
+	if alice.Recipient != bob {
+		t.Error("RenderPreviews modified the participant")
+	}
+}
+
+func TestRenderPreviewsReportsUnconfiguredChannels(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+
+	p := &participant.Participant{Name: "Alice", NotificationType: "email", ContactInfo: []string{"alice@example.com"}}
+
+	previews := RenderPreviews([]*participant.Participant{p}, &config.Config{})
+	if len(previews[0].Messages) != 1 {
+		t.Fatalf("Expected the email to be rendered, got %+v", previews[0])
+	}
+	if len(previews[0].Errors) != 1 || !strings.Contains(previews[0].Errors[0], "delivery would fail") {
+		t.Errorf("Expected a configuration error, got %v", previews[0].Errors)
+	}
+}
+
+func TestRenderPreviewsViaNotifierService(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Notifier.ServiceAddr = "localhost:50051"
+	cfg.Notifier.ArchiveEmail = "archive@example.com"
+
+	p := &participant.Participant{Name: "Alice", NotificationType: "email", ContactInfo: []string{"alice@example.com"}}
+
+	previews := RenderPreviews([]*participant.Participant{p}, cfg)
+	message := previews[0].Messages[0]
+	if message.Subject != (&PapaElfTemplate{}).Subject("Alice", PlaceholderRecipient) {
+		t.Errorf("Unexpected subject %q", message.Subject)
+	}
+	if len(message.BCC) != 1 || message.BCC[0] != "archive@example.com" {
+		t.Errorf("Expected archive BCC, got %v", message.BCC)
+	}
+}
+
+func TestRenderPreviewsUsesShortBodyForSMS(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+
+	p := &participant.Participant{Name: "Alice", NotificationType: "sms", ContactInfo: []string{"+15555550123"}}
+	want := (&PapaElfTemplate{}).ShortBody("Alice", PlaceholderRecipient)
+
+	legacy := RenderPreviews([]*participant.Participant{p}, &config.Config{})
+	if len(legacy[0].Messages) != 1 || legacy[0].Messages[0].Body != want {
+		t.Errorf("Expected the short body locally, got %+v", legacy[0].Messages)
+	}
+
+	cfg := &config.Config{}
+	cfg.Notifier.ServiceAddr = "localhost:50051"
+	service := RenderPreviews([]*participant.Participant{p}, cfg)
+	if len(service[0].Messages) != 1 || service[0].Messages[0].Body != want {
+		t.Errorf("Expected the short body via the notifier service, got %+v", service[0].Messages)
+	}
+	if len(want) > 160 {
+		t.Errorf("Expected the short body to fit one SMS segment, got %d characters", len(want))
+	}
+}
+
+func TestWritePreviews(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "previews")
+	previews := []*Preview{
+		{
+			Participant: "Alice Smith",
+			Messages:    []*notifier.Message{{Channel: "stdout", Recipients: []string{"stdout"}, Body: "Alice Smith has [Secret Recipient]"}},
+		},
+		{Participant: "Bob"},
+	}
+
+	paths, err := WritePreviews(dir, previews)
+	if err != nil {
+		t.Fatalf("WritePreviews failed: %v", err)
+	}
+	if len(paths) != 1 || filepath.Base(paths[0]) != "001-alice-smith-stdout.eml" {
+		t.Fatalf("Unexpected paths %v", paths)
+	}
+
+	info, err := os.Stat(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	// Unknown notification types reach previews via the notifier service
+	escape := []*Preview{{Participant: "Eve", Messages: []*notifier.Message{{Channel: "../../x", Body: "hi"}}}}
+	paths, err = WritePreviews(dir, escape)
+	if err != nil {
+		t.Fatalf("WritePreviews failed: %v", err)
+	}
+	if len(paths) != 1 || filepath.Dir(paths[0]) != dir || filepath.Base(paths[0]) != "001-eve-x.eml" {
+		t.Errorf("Expected the channel to be sanitized inside %s, got %v", dir, paths)
+	}
+}
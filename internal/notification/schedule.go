@@ -0,0 +1,128 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/igodwin/secretsanta/internal/scheduler"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/event"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+// NewScheduler returns a scheduler that delivers over the in-process
+// notifiers, persisting pending jobs to scheduler.store_path
+func NewScheduler(appConfig *config.Config) (*scheduler.Scheduler, error) {
+	store := &scheduler.FileStore{Path: appConfig.Scheduler.StorePath}
+	s, err := scheduler.New(store, func(a scheduler.Assignment) error {
+		instances := make(map[string]notifier.Notifier)
+		if a.Body == "" {
+			return deliverLegacy(context.Background(), a.Participant(), appConfig, instances)
+		}
+		return deliverMessageLegacy(context.Background(), a.Participant(), a.Subject, a.Body, appConfig, instances)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if appConfig.Scheduler.RetryDelay > 0 {
+		s.RetryDelay = appConfig.Scheduler.RetryDelay
+	}
+	if appConfig.Scheduler.MaxAttempts > 0 {
+		s.MaxAttempts = appConfig.Scheduler.MaxAttempts
+	}
+	return s, nil
+}
+
+// Schedule delivers the drawn participants' assignments at sendAt. With a
+// notifier service the notifications are sent now with scheduled_for set;
+// otherwise they are queued on sched for in-process delivery.
+func Schedule(ctx context.Context, participants []*participant.Participant, appConfig *config.Config, sendAt time.Time, sched *scheduler.Scheduler) error {
+	if serviceAddr(appConfig) != "" {
+		return scheduleViaGRPC(ctx, participants, appConfig, sendAt)
+	}
+
+	if sched == nil {
+		return fmt.Errorf("scheduled delivery is not available")
+	}
+	job, err := sched.Schedule(sendAt, participants)
+	if err != nil {
+		return err
+	}
+	for _, p := range participants {
+		countNotification(p.NotificationType, "scheduled")
+	}
+	slog.InfoContext(ctx, "Scheduled notifications", "count", len(job.Assignments), "send_at", job.SendAt, "job", job.ID)
+	return nil
+}
+
+// ScheduleReminders queues each of the event's reminder rules for every drawn
+// participant who has not opted out. Rules whose send time has already passed
+// are skipped. It returns the number of reminders scheduled.
+func ScheduleReminders(ctx context.Context, participants []*participant.Participant, ev *event.Event, appConfig *config.Config, sched *scheduler.Scheduler) (int, error) {
+	var recipients []*participant.Participant
+	for _, p := range participants {
+		if !p.NoReminders && p.Recipient != nil {
+			recipients = append(recipients, p)
+		}
+	}
+
+	notifierServiceAddr := serviceAddr(appConfig)
+	if notifierServiceAddr == "" && sched == nil {
+		return 0, fmt.Errorf("scheduled delivery is not available")
+	}
+
+	scheduled := 0
+	now := time.Now()
+	for _, rule := range ev.Reminders {
+		sendAt := ev.SendAt(rule)
+		if !sendAt.After(now) || len(recipients) == 0 {
+			continue
+		}
+
+		reminders := make(map[*participant.Participant]event.Reminder, len(recipients))
+		for _, p := range recipients {
+			reminder, err := ev.RenderReminder(rule, p.Name, p.Recipient.Name)
+			if err != nil {
+				return scheduled, err
+			}
+			reminders[p] = reminder
+		}
+
+		if notifierServiceAddr != "" {
+			render := func(p *participant.Participant) (string, string) {
+				return reminders[p].Subject, reminders[p].Body
+			}
+			if err := scheduleMessagesViaGRPC(ctx, recipients, render, appConfig, sendAt); err != nil {
+				return scheduled, err
+			}
+		} else {
+			job := &scheduler.Job{
+				Kind:        scheduler.KindReminder,
+				Description: fmt.Sprintf("%s reminder %s", ev.DisplayName(), rule.When()),
+				SendAt:      sendAt,
+			}
+			for _, p := range recipients {
+				assignment, err := scheduler.NewAssignment(p)
+				if err != nil {
+					return scheduled, err
+				}
+				assignment.Subject = reminders[p].Subject
+				assignment.Body = reminders[p].Body
+				job.Assignments = append(job.Assignments, assignment)
+			}
+			if _, err := sched.Add(job); err != nil {
+				return scheduled, err
+			}
+			for _, p := range recipients {
+				countNotification(p.NotificationType, "scheduled")
+			}
+		}
+
+		slog.InfoContext(ctx, "Scheduled reminders", "count", len(recipients), "send_at", sendAt)
+		scheduled += len(recipients)
+	}
+	return scheduled, nil
+}
@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/event"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+func TestScheduleReminders(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+
+	cfg := &config.Config{}
+	cfg.Scheduler.StorePath = filepath.Join(t.TempDir(), "scheduled.json")
+	sched, err := NewScheduler(cfg)
+	if err != nil {
+		t.Fatalf("NewScheduler() error = %v", err)
+	}
+
+	alice := &participant.Participant{Name: "Alice", NotificationType: "stdout"}
+	bob := &participant.Participant{Name: "Bob", NotificationType: "stdout", NoReminders: true}
+	alice.Recipient, bob.Recipient = bob, alice
+
+	ev := &event.Event{
+		Name:         "Office Party",
+		ExchangeDate: time.Now().AddDate(0, 0, 3),
+		Budget:       "$25",
+		// The one-week reminder is already in the past
+		Reminders: event.DefaultReminders(),
+	}
+
+	scheduled, err := ScheduleReminders(context.Background(), []*participant.Participant{alice, bob}, ev, cfg, sched)
+	if err != nil {
+		t.Fatalf("ScheduleReminders() error = %v", err)
+	}
+	if scheduled != 1 {
+		t.Fatalf("Expected one reminder, got %d", scheduled)
+	}
+
+	jobs := sched.Jobs()
+	if len(jobs) != 1 || len(jobs[0].Assignments) != 1 {
+		t.Fatalf("Expected one job for one participant, got %+v", jobs)
+	}
+	assignment := jobs[0].Assignments[0]
+	if assignment.Name != "Alice" {
+		t.Errorf("Expected a reminder for Alice, got %s", assignment.Name)
+	}
+	if !strings.Contains(assignment.Body, "tomorrow") || !strings.Contains(assignment.Body, "Bob") {
+		t.Errorf("Unexpected reminder body %q", assignment.Body)
+	}
+	if !jobs[0].SendAt.Equal(ev.SendAt(event.ReminderRule{DaysBefore: 1}).UTC()) {
+		t.Errorf("Expected the reminder a day before the exchange, got %s", jobs[0].SendAt)
+	}
+}
+
+func TestDeliverMessageLegacy(t *testing.T) {
+	alice := &participant.Participant{Name: "Alice", NotificationType: "stdout"}
+
+	err := deliverMessageLegacy(context.Background(), alice, "Reminder", "The exchange is tomorrow", &config.Config{}, make(map[string]notifier.Notifier))
+	if err != nil {
+		t.Fatalf("deliverMessageLegacy() error = %v", err)
+	}
+	if alice.DeliveredVia != "stdout" {
+		t.Errorf("Expected delivery via stdout, got %q", alice.DeliveredVia)
+	}
+}
+
+func TestDeliverLegacySkipsServiceOnlyChannels(t *testing.T) {
+	alice := &participant.Participant{
+		Name:             "Alice",
+		NotificationType: "slack",
+		ContactInfo:      []string{"@alice", "stdout:"},
+		Recipient:        &participant.Participant{Name: "Bob"},
+	}
+
+	if err := deliverLegacy(context.Background(), alice, &config.Config{}, make(map[string]notifier.Notifier)); err != nil {
+		t.Fatalf("deliverLegacy() error = %v", err)
+	}
+	if alice.DeliveredVia != "stdout" {
+		t.Errorf("Expected delivery on the stdout fallback, got %q", alice.DeliveredVia)
+	}
+
+	bob := &participant.Participant{Name: "Bob", NotificationType: "no-such-channel", Recipient: alice}
+	err := deliverLegacy(context.Background(), bob, &config.Config{}, make(map[string]notifier.Notifier))
+	if err == nil || !strings.Contains(err.Error(), "unknown notification channel") {
+		t.Errorf("Expected an unknown channel error, got %v", err)
+	}
+	if bob.DeliveredVia != "" {
+		t.Errorf("Expected no delivery for an unknown channel, got %q", bob.DeliveredVia)
+	}
+}
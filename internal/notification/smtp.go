@@ -0,0 +1,76 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+)
+
+// ErrSMTPViaService is returned by TestSMTP when email goes through the
+// notifier service, whose SMTP settings this server can't see
+var ErrSMTPViaService = errors.New("email is sent by the notifier service; test its SMTP settings there")
+
+// ErrSMTPRecipient is returned by TestSMTP for a test recipient that is
+// neither one of the configured addresses nor one the caller allowed, so the
+// test can't be used to send mail to anyone
+var ErrSMTPRecipient = errors.New("a test message can only be sent to the from address, the archive address or your own address")
+
+// TestSMTP connects to the configured SMTP server, negotiates TLS and
+// authenticates, reporting each step. When sendTo is set a test message is
+// sent there as well; sendTo must be the from address, the archive address
+// or one of allowed. An error means the test couldn't run at all.
+func TestSMTP(ctx context.Context, appConfig *config.Config, sendTo string, allowed ...string) ([]notifier.ConnectionStep, error) {
+	if serviceAddr(appConfig) != "" {
+		return nil, ErrSMTPViaService
+	}
+
+	channel, ok := notifier.Lookup("email")
+	if !ok {
+		return nil, fmt.Errorf("email channel is not registered")
+	}
+	instance, err := channel.New(appConfig)
+	if err != nil {
+		return nil, err
+	}
+	email, ok := instance.(*notifier.EmailNotifier)
+	if !ok {
+		return nil, fmt.Errorf("email channel does not use SMTP")
+	}
+	if err := email.IsConfigured(); err != nil {
+		return nil, err
+	}
+	if sendTo != "" {
+		if channel.ValidateContact != nil {
+			if err := channel.ValidateContact(sendTo); err != nil {
+				return nil, err
+			}
+		}
+		address, ok := matchAddress(sendTo, append([]string{appConfig.SMTP.FromAddress, appConfig.Notifier.ArchiveEmail}, allowed...))
+		if !ok {
+			return nil, ErrSMTPRecipient
+		}
+		sendTo = address
+	}
+
+	return email.TestConnection(ctx, sendTo), nil
+}
+
+// matchAddress returns the bare address of sendTo when it is the same
+// mailbox as one of candidates
+func matchAddress(sendTo string, candidates []string) (string, bool) {
+	parsed, err := mail.ParseAddress(sendTo)
+	if err != nil {
+		return "", false
+	}
+	for _, candidate := range candidates {
+		if c, err := mail.ParseAddress(candidate); err == nil && strings.EqualFold(c.Address, parsed.Address) {
+			return parsed.Address, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,60 @@
+package notification
+
+import (
+	"strings"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+)
+
+// ServiceStats are the notifier service's delivery counters
+type ServiceStats struct {
+	TotalSent    int64 `json:"total_sent"`
+	TotalFailed  int64 `json:"total_failed"`
+	TotalPending int64 `json:"total_pending"`
+	TotalQueued  int64 `json:"total_queued"`
+	// ByType is keyed by channel name, e.g. "email"
+	ByType map[string]int64 `json:"by_type,omitempty"`
+	// ByStatus is keyed by status name, e.g. "sent"
+	ByStatus         map[string]int64 `json:"by_status,omitempty"`
+	AverageLatencyMs float64          `json:"average_latency_ms"`
+}
+
+// Stats fetches delivery statistics from the notifier service
+func Stats(appConfig *config.Config) (*ServiceStats, error) {
+	g, err := SharedNotifier(appConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := g.GetStats()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ServiceStats{
+		TotalSent:        resp.TotalSent,
+		TotalFailed:      resp.TotalFailed,
+		TotalPending:     resp.TotalPending,
+		TotalQueued:      resp.TotalQueued,
+		ByType:           make(map[string]int64, len(resp.ByType)),
+		ByStatus:         make(map[string]int64, len(resp.ByStatus)),
+		AverageLatencyMs: resp.AverageLatencyMs,
+	}
+	for key, count := range resp.ByType {
+		stats.ByType[typeName(key)] += count
+	}
+	for key, count := range resp.ByStatus {
+		stats.ByStatus[strings.ToLower(strings.TrimPrefix(key, "NOTIFICATION_STATUS_"))] += count
+	}
+	return stats, nil
+}
+
+// typeName maps a service type key such as NOTIFICATION_TYPE_EMAIL to its
+// channel name, leaving keys that are already names as they are
+func typeName(key string) string {
+	if channel, ok := notifier.LookupServiceType(key); ok {
+		return channel.Name
+	}
+	return strings.ToLower(strings.TrimPrefix(key, "NOTIFICATION_TYPE_"))
+}
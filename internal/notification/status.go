@@ -0,0 +1,125 @@
+package notification
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+	"github.com/igodwin/secretsanta/pkg/config"
+)
+
+// ErrNoNotifierService is returned by status operations when no notifier
+// service is configured. In-process deliveries have no notification IDs.
+var ErrNoNotifierService = errors.New("notification status requires a notifier service")
+
+// DeliveryStatus is the live state of a tracked notification
+type DeliveryStatus struct {
+	ID          string `json:"id"`
+	Participant string `json:"participant"`
+	Channel     string `json:"channel,omitempty"`
+	// Status is pending, queued, processing, sent, failed, retrying, or
+	// unknown when the service no longer has the notification
+	Status       string     `json:"status"`
+	Recipients   []string   `json:"recipients,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
+	SentAt       *time.Time `json:"sent_at,omitempty"`
+	RetryCount   int32      `json:"retry_count"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// DeliveryStatuses looks up the live status of each tracked notification
+func DeliveryStatuses(appConfig *config.Config, tracked []TrackedNotification) ([]*DeliveryStatus, error) {
+	if len(tracked) == 0 {
+		return []*DeliveryStatus{}, nil
+	}
+	g, err := SharedNotifier(appConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(tracked))
+	for i, t := range tracked {
+		ids[i] = t.ID
+	}
+	notifications, err := g.ListNotifications(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*pb.Notification, len(notifications))
+	for _, n := range notifications {
+		byID[n.Id] = n
+	}
+
+	statuses := make([]*DeliveryStatus, len(tracked))
+	for i, t := range tracked {
+		statuses[i] = newDeliveryStatus(t, byID[t.ID])
+	}
+	return statuses, nil
+}
+
+// RetryNotification asks the notifier service to resend a notification and
+// returns its ID, which may differ from id
+func RetryNotification(appConfig *config.Config, id string) (string, error) {
+	g, err := SharedNotifier(appConfig)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := g.RetryNotification(id)
+	if err != nil {
+		return "", err
+	}
+	if result == nil || result.NotificationId == "" {
+		return id, nil
+	}
+	return result.NotificationId, nil
+}
+
+// CancelNotification cancels a notification the service has not sent yet
+func CancelNotification(appConfig *config.Config, id string) error {
+	g, err := SharedNotifier(appConfig)
+	if err != nil {
+		return err
+	}
+	return g.CancelNotification(id)
+}
+
+// newDeliveryStatus combines a tracked notification with the service's
+// record of it, which is nil when the service does not know the ID
+func newDeliveryStatus(t TrackedNotification, n *pb.Notification) *DeliveryStatus {
+	status := &DeliveryStatus{
+		ID:          t.ID,
+		Participant: t.Participant,
+		Channel:     t.Channel,
+		Status:      "unknown",
+	}
+	if n == nil {
+		return status
+	}
+
+	status.Status = statusName(n.Status)
+	status.Recipients = n.Recipients
+	status.CreatedAt = timestampOrNil(n.CreatedAt.AsTime(), n.CreatedAt != nil)
+	status.ScheduledFor = timestampOrNil(n.ScheduledFor.AsTime(), n.ScheduledFor != nil)
+	status.SentAt = timestampOrNil(n.SentAt.AsTime(), n.SentAt != nil)
+	status.RetryCount = n.RetryCount
+	status.LastError = n.LastError
+	return status
+}
+
+// statusName maps NOTIFICATION_STATUS_SENT to "sent"
+func statusName(s pb.NotificationStatus) string {
+	if s == pb.NotificationStatus_NOTIFICATION_STATUS_UNSPECIFIED {
+		return "unknown"
+	}
+	return strings.ToLower(strings.TrimPrefix(s.String(), "NOTIFICATION_STATUS_"))
+}
+
+func timestampOrNil(t time.Time, ok bool) *time.Time {
+	if !ok {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,185 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+// fakeNotifierService records notifications in memory
+type fakeNotifierService struct {
+	pb.UnimplementedNotifierServiceServer
+
+	notifications map[string]*pb.Notification
+	cancelled     []string
+	// rejected lists the notification types the service refuses
+	rejected map[pb.NotificationType]bool
+}
+
+func (f *fakeNotifierService) SendBatchNotifications(ctx context.Context, req *pb.SendBatchNotificationsRequest) (*pb.SendBatchNotificationsResponse, error) {
+	resp := &pb.SendBatchNotificationsResponse{}
+	for _, n := range req.Notifications {
+		if f.rejected[n.Type] {
+			resp.Results = append(resp.Results, &pb.NotificationResult{Error: "channel not configured"})
+			continue
+		}
+		id := fmt.Sprintf("n-%d", len(f.notifications)+1)
+		f.notifications[id] = &pb.Notification{
+			Id:         id,
+			Status:     pb.NotificationStatus_NOTIFICATION_STATUS_FAILED,
+			Recipients: n.Recipients,
+			CreatedAt:  timestamppb.Now(),
+			LastError:  "mailbox full",
+		}
+		resp.Results = append(resp.Results, &pb.NotificationResult{NotificationId: id, Success: true})
+	}
+	return resp, nil
+}
+
+func (f *fakeNotifierService) ListNotifications(ctx context.Context, req *pb.ListNotificationsRequest) (*pb.ListNotificationsResponse, error) {
+	resp := &pb.ListNotificationsResponse{}
+	for _, id := range req.Filter.Ids {
+		if n, ok := f.notifications[id]; ok {
+			resp.Notifications = append(resp.Notifications, n)
+		}
+	}
+	return resp, nil
+}
+
+func (f *fakeNotifierService) RetryNotification(ctx context.Context, req *pb.RetryNotificationRequest) (*pb.RetryNotificationResponse, error) {
+	n, ok := f.notifications[req.Id]
+	if !ok {
+		return &pb.RetryNotificationResponse{Result: &pb.NotificationResult{Error: "not found"}}, nil
+	}
+	n.Status = pb.NotificationStatus_NOTIFICATION_STATUS_SENT
+	n.RetryCount++
+	return &pb.RetryNotificationResponse{Result: &pb.NotificationResult{NotificationId: req.Id, Success: true}}, nil
+}
+
+func (f *fakeNotifierService) CancelNotification(ctx context.Context, req *pb.CancelNotificationRequest) (*pb.CancelNotificationResponse, error) {
+	f.cancelled = append(f.cancelled, req.Id)
+	return &pb.CancelNotificationResponse{Success: true}, nil
+}
+
+func (f *fakeNotifierService) GetStats(ctx context.Context, req *pb.GetStatsRequest) (*pb.GetStatsResponse, error) {
+	return &pb.GetStatsResponse{
+		TotalSent:        3,
+		TotalFailed:      1,
+		ByType:           map[string]int64{"NOTIFICATION_TYPE_EMAIL": 3, "slack": 1},
+		ByStatus:         map[string]int64{"NOTIFICATION_STATUS_SENT": 3, "NOTIFICATION_STATUS_FAILED": 1},
+		AverageLatencyMs: 42.5,
+	}, nil
+}
+
+func (f *fakeNotifierService) HealthCheck(ctx context.Context, req *pb.HealthCheckRequest) (*pb.HealthCheckResponse, error) {
+	return &pb.HealthCheckResponse{Healthy: true, Status: "ok"}, nil
+}
+
+// startFakeNotifierService serves fake on a local port and returns its address
+func startFakeNotifierService(t *testing.T, fake *fakeNotifierService, opts ...grpc.ServerOption) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(opts...)
+	pb.RegisterNotifierServiceServer(server, fake)
+	go server.Serve(lis)
+	t.Cleanup(func() {
+		CloseSharedNotifier()
+		server.Stop()
+	})
+	return lis.Addr().String()
+}
+
+func TestDeliveryStatusRetryAndCancel(t *testing.T) {
+	fake := &fakeNotifierService{notifications: make(map[string]*pb.Notification)}
+	cfg := &config.Config{}
+	cfg.Notifier.ServiceAddr = startFakeNotifierService(t, fake)
+
+	bob := &participant.Participant{Name: "Bob", NotificationType: "email", ContactInfo: []string{"bob@example.com"}}
+	alice := &participant.Participant{Name: "Alice", NotificationType: "email", ContactInfo: []string{"alice@example.com"}, Recipient: bob}
+	bob.Recipient = alice
+
+	participants := []*participant.Participant{alice, bob}
+	if err := Send(context.Background(), participants, cfg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if alice.NotificationID == "" || bob.NotificationID == "" {
+		t.Fatalf("Expected notification IDs, got %q and %q", alice.NotificationID, bob.NotificationID)
+	}
+
+	tracker := NewTracker(filepath.Join(t.TempDir(), "notifications.json"))
+	if err := tracker.Record("", participants); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	tracked, err := tracker.List("")
+	if err != nil || len(tracked) != 2 {
+		t.Fatalf("Expected 2 tracked notifications, got %v (%v)", tracked, err)
+	}
+	tracked = append(tracked, TrackedNotification{ID: "gone", Participant: "Carol"})
+
+	statuses, err := DeliveryStatuses(cfg, tracked)
+	if err != nil {
+		t.Fatalf("DeliveryStatuses() error = %v", err)
+	}
+	if statuses[0].Participant != "Alice" || statuses[0].Status != "failed" || statuses[0].LastError != "mailbox full" {
+		t.Errorf("Unexpected status for Alice: %+v", statuses[0])
+	}
+	if statuses[2].Status != "unknown" {
+		t.Errorf("Expected unknown status for a missing notification, got %q", statuses[2].Status)
+	}
+
+	id, err := RetryNotification(cfg, alice.NotificationID)
+	if err != nil || id != alice.NotificationID {
+		t.Fatalf("RetryNotification() = %q, %v", id, err)
+	}
+	statuses, _ = DeliveryStatuses(cfg, tracked[:1])
+	if statuses[0].Status != "sent" || statuses[0].RetryCount != 1 {
+		t.Errorf("Expected the retried notification to be sent, got %+v", statuses[0])
+	}
+
+	if err := CancelNotification(cfg, bob.NotificationID); err != nil {
+		t.Fatalf("CancelNotification() error = %v", err)
+	}
+	if len(fake.cancelled) != 1 || fake.cancelled[0] != bob.NotificationID {
+		t.Errorf("Expected Bob's notification to be cancelled, got %v", fake.cancelled)
+	}
+}
+
+func TestDeliveryStatusRequiresNotifierService(t *testing.T) {
+	t.Setenv("NOTIFIER_SERVICE_ADDR", "")
+
+	_, err := DeliveryStatuses(&config.Config{}, []TrackedNotification{{ID: "n-1"}})
+	if err != ErrNoNotifierService {
+		t.Errorf("Expected ErrNoNotifierService, got %v", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Notifier.ServiceAddr = startFakeNotifierService(t, &fakeNotifierService{})
+
+	stats, err := Stats(cfg)
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if stats.TotalSent != 3 || stats.TotalFailed != 1 || stats.AverageLatencyMs != 42.5 {
+		t.Errorf("Unexpected totals: %+v", stats)
+	}
+	if stats.ByType["email"] != 3 || stats.ByType["slack"] != 1 {
+		t.Errorf("Expected counts keyed by channel name, got %v", stats.ByType)
+	}
+	if stats.ByStatus["sent"] != 3 || stats.ByStatus["failed"] != 1 {
+		t.Errorf("Expected counts keyed by status name, got %v", stats.ByStatus)
+	}
+}
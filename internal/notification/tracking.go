@@ -0,0 +1,155 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/igodwin/secretsanta/internal/atomicfile"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+// Tracked notifications older than trackingRetention are dropped, as are the
+// oldest beyond maxTracked, so the file doesn't grow with every draw
+const (
+	trackingRetention = 90 * 24 * time.Hour
+	maxTracked        = 10000
+)
+
+// TrackedNotification links a notifier service notification to the
+// participant it was sent to and the organizer whose draw sent it. Owner is
+// empty for draws made without a session.
+type TrackedNotification struct {
+	ID          string    `json:"id"`
+	Owner       string    `json:"owner,omitempty"`
+	Participant string    `json:"participant"`
+	Channel     string    `json:"channel,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Tracker keeps the notification IDs returned by the notifier service in a
+// JSON file, so delivery status can be checked after a restart. Recipients
+// are not stored. Old entries are pruned as new ones are recorded.
+type Tracker struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// NewTracker returns a tracker backed by the file at path
+func NewTracker(path string) *Tracker {
+	return &Tracker{Path: path}
+}
+
+// Record stores the notification ID of every participant that has one,
+// owned by owner
+func (t *Tracker) Record(owner string, participants []*participant.Participant) error {
+	now := time.Now().UTC()
+	var tracked []TrackedNotification
+	for _, p := range participants {
+		if p.NotificationID == "" {
+			continue
+		}
+		tracked = append(tracked, TrackedNotification{
+			ID:          p.NotificationID,
+			Owner:       owner,
+			Participant: p.Name,
+			Channel:     p.DeliveredVia,
+			CreatedAt:   now,
+		})
+	}
+	if len(tracked) == 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	existing, err := t.load()
+	if err != nil {
+		return err
+	}
+	return t.save(pruneTracked(append(existing, tracked...), now))
+}
+
+// List returns owner's tracked notifications, oldest first
+func (t *Tracker) List(owner string) ([]TrackedNotification, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tracked, err := t.load()
+	if err != nil {
+		return nil, err
+	}
+	var owned []TrackedNotification
+	for _, n := range tracked {
+		if n.Owner == owner {
+			owned = append(owned, n)
+		}
+	}
+	return owned, nil
+}
+
+// Replace points a tracked notification at a new ID, for services that
+// assign one when a notification is retried
+func (t *Tracker) Replace(oldID, newID string) error {
+	if oldID == newID || newID == "" {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tracked, err := t.load()
+	if err != nil {
+		return err
+	}
+	for i := range tracked {
+		if tracked[i].ID == oldID {
+			tracked[i].ID = newID
+		}
+	}
+	return t.save(tracked)
+}
+
+func (t *Tracker) load() ([]TrackedNotification, error) {
+	data, err := os.ReadFile(t.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tracked notifications: %w", err)
+	}
+
+	var tracked []TrackedNotification
+	if err := json.Unmarshal(data, &tracked); err != nil {
+		return nil, fmt.Errorf("failed to parse tracked notifications in %s: %w", t.Path, err)
+	}
+	return tracked, nil
+}
+
+// pruneTracked drops notifications older than trackingRetention, then the
+// oldest beyond maxTracked
+func pruneTracked(tracked []TrackedNotification, now time.Time) []TrackedNotification {
+	var kept []TrackedNotification
+	for _, t := range tracked {
+		if now.Sub(t.CreatedAt) < trackingRetention {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) > maxTracked {
+		kept = kept[len(kept)-maxTracked:]
+	}
+	return kept
+}
+
+// save replaces the file atomically
+func (t *Tracker) save(tracked []TrackedNotification) error {
+	data, err := json.MarshalIndent(tracked, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.Write(t.Path, data); err != nil {
+		return fmt.Errorf("failed to write tracked notifications: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package notification
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPruneTracked(t *testing.T) {
+	now := time.Date(2026, 12, 1, 9, 0, 0, 0, time.UTC)
+	old := TrackedNotification{ID: "old", CreatedAt: now.Add(-trackingRetention - time.Hour)}
+	recent := TrackedNotification{ID: "recent", CreatedAt: now.Add(-time.Hour)}
+
+	kept := pruneTracked([]TrackedNotification{old, recent}, now)
+	if len(kept) != 1 || kept[0].ID != "recent" {
+		t.Errorf("Expected only the recent notification to be kept, got %+v", kept)
+	}
+
+	var many []TrackedNotification
+	for i := 0; i < maxTracked+5; i++ {
+		many = append(many, TrackedNotification{ID: fmt.Sprintf("n-%d", i), CreatedAt: now})
+	}
+	kept = pruneTracked(many, now)
+	if len(kept) != maxTracked || kept[0].ID != "n-5" {
+		t.Errorf("Expected the newest %d notifications, got %d starting at %s", maxTracked, len(kept), kept[0].ID)
+	}
+}
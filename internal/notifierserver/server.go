@@ -0,0 +1,586 @@
+// Package notifierserver implements the NotifierService gRPC API on top of
+// the in-process notifier channels, so the stack can run without the
+// external notifier service.
+package notifierserver
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+	"github.com/igodwin/secretsanta/internal/logging"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultRetryDelay = 30 * time.Second
+	// maxIdle bounds how long the worker sleeps, so wall clock changes are noticed
+	maxIdle = time.Hour
+)
+
+// Server implements NotifierService. Notifications sent without a schedule
+// are delivered once before the call returns, so clients can fall back to
+// another channel when delivery fails. Scheduled notifications are delivered
+// by a background worker and retried with exponential backoff.
+type Server struct {
+	pb.UnimplementedNotifierServiceServer
+
+	// MaxRetries applies to notifications that don't set their own
+	MaxRetries int32
+	// RetryDelay is the wait before the first retry; it doubles after each attempt
+	RetryDelay time.Duration
+	// Retention is how long sent and failed notifications are kept; zero keeps them forever
+	Retention time.Duration
+
+	config *config.Config
+	store  Store
+
+	mu           sync.Mutex
+	entries      map[string]*Entry
+	latencyTotal time.Duration
+	latencyCount int64
+
+	instancesMu sync.Mutex
+	instances   map[string]notifier.Notifier
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New returns a server delivering through the channels configured in
+// appConfig. store may be nil to keep notifications in memory only.
+func New(appConfig *config.Config, store Store) (*Server, error) {
+	s := &Server{
+		MaxRetries: defaultMaxRetries,
+		RetryDelay: defaultRetryDelay,
+		config:     appConfig,
+		store:      store,
+		entries:    make(map[string]*Entry),
+		instances:  make(map[string]notifier.Notifier),
+		wake:       make(chan struct{}, 1),
+	}
+	if store == nil {
+		return s, nil
+	}
+
+	entries, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		// A delivery interrupted by a restart is attempted again
+		switch e.Notification.Status {
+		case pb.NotificationStatus_NOTIFICATION_STATUS_QUEUED, pb.NotificationStatus_NOTIFICATION_STATUS_PROCESSING:
+			e.Notification.Status = pb.NotificationStatus_NOTIFICATION_STATUS_RETRYING
+			e.NextAttempt = time.Now()
+		}
+		s.entries[e.Notification.Id] = e
+	}
+	return s, nil
+}
+
+// Start runs the background worker until Stop is called
+func (s *Server) Start() {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run()
+}
+
+// Stop waits for any delivery in progress and stops the worker
+func (s *Server) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+// SendNotification delivers or schedules a single notification
+func (s *Server) SendNotification(ctx context.Context, req *pb.SendNotificationRequest) (*pb.SendNotificationResponse, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &pb.SendNotificationResponse{Result: s.submit(req)}, nil
+}
+
+// SendBatchNotifications handles each notification independently; an
+// invalid one is reported in its result without failing the batch
+func (s *Server) SendBatchNotifications(ctx context.Context, req *pb.SendBatchNotificationsRequest) (*pb.SendBatchNotificationsResponse, error) {
+	resp := &pb.SendBatchNotificationsResponse{}
+	for _, n := range req.Notifications {
+		if err := validateRequest(n); err != nil {
+			resp.Results = append(resp.Results, &pb.NotificationResult{Error: err.Error()})
+			continue
+		}
+		resp.Results = append(resp.Results, s.submit(n))
+	}
+	return resp, nil
+}
+
+// GetNotification returns a notification by ID
+func (s *Server) GetNotification(ctx context.Context, req *pb.GetNotificationRequest) (*pb.GetNotificationResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[req.Id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "notification %s not found", req.Id)
+	}
+	return &pb.GetNotificationResponse{Notification: proto.Clone(e.Notification).(*pb.Notification)}, nil
+}
+
+// ListNotifications returns the notifications matching the filter, newest
+// first. Total counts every match before limit and offset are applied.
+func (s *Server) ListNotifications(ctx context.Context, req *pb.ListNotificationsRequest) (*pb.ListNotificationsResponse, error) {
+	filter := req.Filter
+	if filter == nil {
+		filter = &pb.NotificationFilter{}
+	}
+
+	s.mu.Lock()
+	var matched []*pb.Notification
+	for _, e := range s.entries {
+		if matches(filter, e.Notification) {
+			matched = append(matched, proto.Clone(e.Notification).(*pb.Notification))
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.AsTime().After(matched[j].CreatedAt.AsTime())
+	})
+	resp := &pb.ListNotificationsResponse{Total: int64(len(matched))}
+
+	start := min(int(max(filter.Offset, 0)), len(matched))
+	end := len(matched)
+	if filter.Limit > 0 {
+		end = min(start+int(filter.Limit), end)
+	}
+	resp.Notifications = matched[start:end]
+	return resp, nil
+}
+
+// CancelNotification stops a notification that has not been sent yet. There
+// is no cancelled status, so it is marked failed with "cancelled".
+func (s *Server) CancelNotification(ctx context.Context, req *pb.CancelNotificationRequest) (*pb.CancelNotificationResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[req.Id]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "notification %s not found", req.Id)
+	}
+
+	n := e.Notification
+	if !waiting(n.Status) && n.Status != pb.NotificationStatus_NOTIFICATION_STATUS_QUEUED {
+		return &pb.CancelNotificationResponse{
+			Message: fmt.Sprintf("notification is %s and can no longer be cancelled", statusName(n.Status)),
+		}, nil
+	}
+	n.Status = pb.NotificationStatus_NOTIFICATION_STATUS_FAILED
+	n.LastError = "cancelled"
+	e.NextAttempt = time.Time{}
+	s.saveLocked()
+	return &pb.CancelNotificationResponse{Success: true, Message: "cancelled"}, nil
+}
+
+// RetryNotification sends a failed notification again before returning
+func (s *Server) RetryNotification(ctx context.Context, req *pb.RetryNotificationRequest) (*pb.RetryNotificationResponse, error) {
+	s.mu.Lock()
+	e, ok := s.entries[req.Id]
+	if !ok {
+		s.mu.Unlock()
+		return nil, status.Errorf(codes.NotFound, "notification %s not found", req.Id)
+	}
+	n := e.Notification
+	if n.Status != pb.NotificationStatus_NOTIFICATION_STATUS_FAILED {
+		s.mu.Unlock()
+		return &pb.RetryNotificationResponse{Result: &pb.NotificationResult{
+			NotificationId: n.Id,
+			Error:          fmt.Sprintf("only failed notifications can be retried; this one is %s", statusName(n.Status)),
+		}}, nil
+	}
+	n.Status = pb.NotificationStatus_NOTIFICATION_STATUS_QUEUED
+	n.RetryCount++
+	s.mu.Unlock()
+
+	return &pb.RetryNotificationResponse{Result: s.attempt(req.Id, false)}, nil
+}
+
+// GetStats summarizes every notification the server holds
+func (s *Server) GetStats(ctx context.Context, req *pb.GetStatsRequest) (*pb.GetStatsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := &pb.GetStatsResponse{
+		ByType:   make(map[string]int64),
+		ByStatus: make(map[string]int64),
+	}
+	for _, e := range s.entries {
+		n := e.Notification
+		resp.ByType[n.Type.String()]++
+		resp.ByStatus[n.Status.String()]++
+		switch n.Status {
+		case pb.NotificationStatus_NOTIFICATION_STATUS_SENT:
+			resp.TotalSent++
+		case pb.NotificationStatus_NOTIFICATION_STATUS_FAILED:
+			resp.TotalFailed++
+		case pb.NotificationStatus_NOTIFICATION_STATUS_PENDING, pb.NotificationStatus_NOTIFICATION_STATUS_RETRYING:
+			resp.TotalPending++
+		case pb.NotificationStatus_NOTIFICATION_STATUS_QUEUED, pb.NotificationStatus_NOTIFICATION_STATUS_PROCESSING:
+			resp.TotalQueued++
+		}
+	}
+	if s.latencyCount > 0 {
+		resp.AverageLatencyMs = float64(s.latencyTotal.Microseconds()) / 1000 / float64(s.latencyCount)
+	}
+	return resp, nil
+}
+
+// HealthCheck reports whether each local channel is configured. The server
+// is healthy as long as at least one channel can deliver.
+func (s *Server) HealthCheck(ctx context.Context, req *pb.HealthCheckRequest) (*pb.HealthCheckResponse, error) {
+	resp := &pb.HealthCheckResponse{Status: "no channels configured", Components: make(map[string]string)}
+	for _, channel := range serviceChannels() {
+		if err := channel.Configured(s.config); err != nil {
+			resp.Components[channel.Name] = "not configured: " + err.Error()
+			continue
+		}
+		resp.Components[channel.Name] = "ok"
+		resp.Healthy, resp.Status = true, "ok"
+	}
+	return resp, nil
+}
+
+// GetNotifiers lists the channels this server can deliver through
+func (s *Server) GetNotifiers(ctx context.Context, req *pb.GetNotifiersRequest) (*pb.GetNotifiersResponse, error) {
+	resp := &pb.GetNotifiersResponse{}
+	for _, channel := range serviceChannels() {
+		if channel.Configured(s.config) != nil {
+			continue
+		}
+		resp.Notifiers = append(resp.Notifiers, &pb.NotifierInfo{
+			Type: pb.NotificationType(pb.NotificationType_value[channel.ServiceType]),
+		})
+	}
+	return resp, nil
+}
+
+// APIKeyInterceptor rejects calls that don't carry apiKey as a bearer token
+func APIKeyInterceptor(apiKey string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, value := range md.Get("authorization") {
+			token, ok := strings.CutPrefix(value, "Bearer ")
+			if ok && subtle.ConstantTimeCompare([]byte(token), []byte(apiKey)) == 1 {
+				return handler(ctx, req)
+			}
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid or missing API key")
+	}
+}
+
+// submit stores req and either schedules it or delivers it now
+func (s *Server) submit(req *pb.SendNotificationRequest) *pb.NotificationResult {
+	now := time.Now()
+	maxRetries := req.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = s.MaxRetries
+	}
+	n := &pb.Notification{
+		Id:           newID(),
+		Type:         req.Type,
+		Account:      req.Account,
+		Priority:     req.Priority,
+		Status:       pb.NotificationStatus_NOTIFICATION_STATUS_QUEUED,
+		Subject:      req.Subject,
+		Body:         req.Body,
+		Recipients:   req.Recipients,
+		Metadata:     req.Metadata,
+		CreatedAt:    timestamppb.New(now),
+		ScheduledFor: req.ScheduledFor,
+		MaxRetries:   maxRetries,
+	}
+	e := &Entry{Notification: n, CC: req.Cc, BCC: req.Bcc, ContentType: req.ContentType}
+
+	if req.ScheduledFor != nil && req.ScheduledFor.AsTime().After(now) {
+		n.Status = pb.NotificationStatus_NOTIFICATION_STATUS_PENDING
+		e.NextAttempt = req.ScheduledFor.AsTime()
+		s.mu.Lock()
+		s.entries[n.Id] = e
+		s.saveLocked()
+		s.mu.Unlock()
+		s.notify()
+		return &pb.NotificationResult{NotificationId: n.Id, Success: true, Message: "scheduled"}
+	}
+
+	s.mu.Lock()
+	s.entries[n.Id] = e
+	s.mu.Unlock()
+	return s.attempt(n.Id, false)
+}
+
+// attempt delivers the notification with the given ID. Background attempts
+// skip notifications cancelled since they became due, and reschedule
+// failures until MaxRetries is reached.
+func (s *Server) attempt(id string, background bool) *pb.NotificationResult {
+	s.mu.Lock()
+	e := s.entries[id]
+	if e == nil || (background && !waiting(e.Notification.Status)) {
+		s.mu.Unlock()
+		return nil
+	}
+	e.Notification.Status = pb.NotificationStatus_NOTIFICATION_STATUS_PROCESSING
+	n := e.Notification
+	notifType, name := n.Type, n.Metadata["participant_name"]
+	message := &notifier.Message{
+		Recipients:  slices.Clone(n.Recipients),
+		BCC:         slices.Clone(e.BCC),
+		Subject:     n.Subject,
+		Body:        n.Body,
+		ContentType: e.ContentType,
+	}
+	s.mu.Unlock()
+
+	start := time.Now()
+	err := s.deliver(notifType, message, name)
+	elapsed := time.Since(start)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.saveLocked()
+
+	result := &pb.NotificationResult{NotificationId: id}
+	if err == nil {
+		n.Status = pb.NotificationStatus_NOTIFICATION_STATUS_SENT
+		n.SentAt = timestamppb.Now()
+		n.LastError = ""
+		e.NextAttempt = time.Time{}
+		s.latencyTotal += elapsed
+		s.latencyCount++
+		result.Success, result.Message, result.SentAt = true, "sent", n.SentAt
+		return result
+	}
+
+	n.LastError = err.Error()
+	result.Error = err.Error()
+	logErr := logging.RedactError(err, append([]string{name}, message.Recipients...)...)
+	if background && n.RetryCount < n.MaxRetries {
+		n.RetryCount++
+		n.Status = pb.NotificationStatus_NOTIFICATION_STATUS_RETRYING
+		e.NextAttempt = time.Now().Add(s.RetryDelay << (n.RetryCount - 1))
+		slog.Warn("Notification failed, retrying", "notification_id", id, "retry_at", e.NextAttempt, "error", logErr)
+		return result
+	}
+	n.Status = pb.NotificationStatus_NOTIFICATION_STATUS_FAILED
+	e.NextAttempt = time.Time{}
+	slog.Error("Notification failed", "notification_id", id, "error", logErr)
+	return result
+}
+
+// deliver sends message through the local channel for notifType. Channels
+// that can't send a composed message get a participant named name instead.
+func (s *Server) deliver(notifType pb.NotificationType, message *notifier.Message, name string) error {
+	channel, ok := notifier.LookupServiceType(notifType.String())
+	if !ok || !channel.Local() {
+		return fmt.Errorf("%s is not supported by this server", notifType)
+	}
+	instance, err := s.instance(channel)
+	if err != nil {
+		return err
+	}
+	if err := instance.IsConfigured(); err != nil {
+		return fmt.Errorf("%s is not configured: %w", channel.Name, err)
+	}
+
+	message.Channel = channel.Name
+	if deliverer, ok := instance.(notifier.MessageDeliverer); ok {
+		return deliverer.Deliver(message)
+	}
+	sender, ok := instance.(notifier.MessageSender)
+	if !ok {
+		return fmt.Errorf("%s cannot send messages", channel.Name)
+	}
+	p := &participant.Participant{Name: name, NotificationType: channel.Name, ContactInfo: message.Recipients}
+	return sender.SendMessage(p, message.Subject, message.Body)
+}
+
+// instance returns the notifier for channel, creating it on first use
+func (s *Server) instance(channel notifier.Channel) (notifier.Notifier, error) {
+	s.instancesMu.Lock()
+	defer s.instancesMu.Unlock()
+	if instance, ok := s.instances[channel.Name]; ok {
+		return instance, nil
+	}
+	instance, err := channel.New(s.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s notifier: %w", channel.Name, err)
+	}
+	s.instances[channel.Name] = instance
+	return instance, nil
+}
+
+func (s *Server) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Server) run() {
+	defer close(s.done)
+	for {
+		s.runDue(time.Now())
+
+		timer := time.NewTimer(s.untilNext(time.Now()))
+		select {
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		case <-s.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// untilNext returns how long to sleep before the next notification is due
+func (s *Server) untilNext(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wait := maxIdle
+	for _, e := range s.entries {
+		if !waiting(e.Notification.Status) {
+			continue
+		}
+		if d := e.NextAttempt.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	return max(wait, 0)
+}
+
+// runDue delivers every notification due at now and drops finished ones
+// older than Retention
+func (s *Server) runDue(now time.Time) {
+	s.mu.Lock()
+	var due []string
+	expired := false
+	for id, e := range s.entries {
+		n := e.Notification
+		switch {
+		case waiting(n.Status) && !e.NextAttempt.After(now):
+			due = append(due, id)
+		case s.Retention > 0 && finished(n.Status) && now.Sub(n.CreatedAt.AsTime()) > s.Retention:
+			delete(s.entries, id)
+			expired = true
+		}
+	}
+	if expired {
+		s.saveLocked()
+	}
+	s.mu.Unlock()
+
+	for _, id := range due {
+		s.attempt(id, true)
+	}
+}
+
+// saveLocked persists every notification; the caller must hold the lock
+func (s *Server) saveLocked() {
+	if s.store == nil {
+		return
+	}
+	entries := make([]*Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Notification.CreatedAt.AsTime().Before(entries[j].Notification.CreatedAt.AsTime())
+	})
+	if err := s.store.Save(entries); err != nil {
+		slog.Error("Failed to persist notification queue", "error", err)
+	}
+}
+
+// matches reports whether n passes every criterion set in filter
+func matches(filter *pb.NotificationFilter, n *pb.Notification) bool {
+	if len(filter.Ids) > 0 && !slices.Contains(filter.Ids, n.Id) {
+		return false
+	}
+	if len(filter.Types) > 0 && !slices.Contains(filter.Types, n.Type) {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, n.Status) {
+		return false
+	}
+	if len(filter.Recipients) > 0 && !slices.ContainsFunc(n.Recipients, func(r string) bool {
+		return slices.Contains(filter.Recipients, r)
+	}) {
+		return false
+	}
+	created := n.CreatedAt.AsTime()
+	if filter.CreatedAfter != nil && !created.After(filter.CreatedAfter.AsTime()) {
+		return false
+	}
+	if filter.CreatedBefore != nil && !created.Before(filter.CreatedBefore.AsTime()) {
+		return false
+	}
+	return true
+}
+
+func validateRequest(req *pb.SendNotificationRequest) error {
+	if req.Type == pb.NotificationType_NOTIFICATION_TYPE_UNSPECIFIED {
+		return fmt.Errorf("notification type is required")
+	}
+	if len(req.Recipients) == 0 {
+		return fmt.Errorf("at least one recipient is required")
+	}
+	return nil
+}
+
+// serviceChannels returns the local channels that have a NotificationType
+func serviceChannels() []notifier.Channel {
+	var channels []notifier.Channel
+	for _, channel := range notifier.Channels() {
+		if channel.Local() && channel.ServiceType != "" {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// waiting reports whether the worker still has to deliver a notification
+func waiting(s pb.NotificationStatus) bool {
+	return s == pb.NotificationStatus_NOTIFICATION_STATUS_PENDING || s == pb.NotificationStatus_NOTIFICATION_STATUS_RETRYING
+}
+
+func finished(s pb.NotificationStatus) bool {
+	return s == pb.NotificationStatus_NOTIFICATION_STATUS_SENT || s == pb.NotificationStatus_NOTIFICATION_STATUS_FAILED
+}
+
+// statusName maps NOTIFICATION_STATUS_SENT to "sent"
+func statusName(s pb.NotificationStatus) string {
+	return strings.ToLower(strings.TrimPrefix(s.String(), "NOTIFICATION_STATUS_"))
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,250 @@
+package notifierserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+// fakeEmail records delivered messages and fails while failures remain
+type fakeEmail struct {
+	mu        sync.Mutex
+	delivered []*notifier.Message
+	failures  int
+}
+
+func (f *fakeEmail) SendNotification(p *participant.Participant) error {
+	return nil
+}
+
+func (f *fakeEmail) IsConfigured() error {
+	return nil
+}
+
+func (f *fakeEmail) Deliver(message *notifier.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return fmt.Errorf("smtp unavailable")
+	}
+	f.delivered = append(f.delivered, message)
+	return nil
+}
+
+func (f *fakeEmail) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.delivered)
+}
+
+// newTestServer returns a server whose email channel is fake
+func newTestServer(t *testing.T, store Store, fake *fakeEmail) *Server {
+	t.Helper()
+	s, err := New(&config.Config{}, store)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	s.instances["email"] = fake
+	return s
+}
+
+func emailRequest(to string) *pb.SendNotificationRequest {
+	return &pb.SendNotificationRequest{
+		Type:        pb.NotificationType_NOTIFICATION_TYPE_EMAIL,
+		Subject:     "Secret Santa",
+		Body:        "You have Bob",
+		Recipients:  []string{to},
+		Bcc:         []string{"archive@example.com"},
+		ContentType: "text/html",
+	}
+}
+
+func TestSendNotification(t *testing.T) {
+	fake := &fakeEmail{}
+	s := newTestServer(t, nil, fake)
+	ctx := context.Background()
+
+	resp, err := s.SendNotification(ctx, emailRequest("alice@example.com"))
+	if err != nil || !resp.Result.Success {
+		t.Fatalf("SendNotification() = %v, %v", resp, err)
+	}
+	if fake.count() != 1 || fake.delivered[0].ContentType != "text/html" || fake.delivered[0].BCC[0] != "archive@example.com" {
+		t.Errorf("Expected the message to be delivered as composed, got %+v", fake.delivered)
+	}
+
+	got, err := s.GetNotification(ctx, &pb.GetNotificationRequest{Id: resp.Result.NotificationId})
+	if err != nil || got.Notification.Status != pb.NotificationStatus_NOTIFICATION_STATUS_SENT || got.Notification.SentAt == nil {
+		t.Errorf("Expected a sent notification, got %v (%v)", got, err)
+	}
+
+	fake.failures = 1
+	resp, _ = s.SendNotification(ctx, emailRequest("bob@example.com"))
+	if resp.Result.Success || resp.Result.Error != "smtp unavailable" {
+		t.Errorf("Expected an immediate send to report the failure, got %v", resp.Result)
+	}
+
+	retried, err := s.RetryNotification(ctx, &pb.RetryNotificationRequest{Id: resp.Result.NotificationId})
+	if err != nil || !retried.Result.Success {
+		t.Errorf("RetryNotification() = %v, %v", retried, err)
+	}
+
+	if _, err := s.SendNotification(ctx, &pb.SendNotificationRequest{Type: pb.NotificationType_NOTIFICATION_TYPE_EMAIL}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without recipients, got %v", err)
+	}
+	if _, err := s.GetNotification(ctx, &pb.GetNotificationRequest{Id: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+
+	batch, _ := s.SendBatchNotifications(ctx, &pb.SendBatchNotificationsRequest{Notifications: []*pb.SendNotificationRequest{
+		emailRequest("carol@example.com"),
+		{Type: pb.NotificationType_NOTIFICATION_TYPE_SLACK, Recipients: []string{"#general"}},
+	}})
+	if !batch.Results[0].Success || batch.Results[1].Success {
+		t.Errorf("Expected only the email to succeed, got %v", batch.Results)
+	}
+}
+
+func TestScheduledNotificationRetries(t *testing.T) {
+	fake := &fakeEmail{failures: 1}
+	s := newTestServer(t, nil, fake)
+	s.RetryDelay = 10 * time.Millisecond
+	s.Start()
+	defer s.Stop()
+
+	req := emailRequest("alice@example.com")
+	req.ScheduledFor = timestamppb.New(time.Now().Add(20 * time.Millisecond))
+	resp, _ := s.SendNotification(context.Background(), req)
+	if !resp.Result.Success || fake.count() != 0 {
+		t.Fatalf("Expected the notification to be accepted without sending, got %v", resp.Result)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for fake.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	got, _ := s.GetNotification(context.Background(), &pb.GetNotificationRequest{Id: resp.Result.NotificationId})
+	if got.Notification.Status != pb.NotificationStatus_NOTIFICATION_STATUS_SENT || got.Notification.RetryCount != 1 {
+		t.Errorf("Expected the notification to be sent on the first retry, got %v", got.Notification)
+	}
+}
+
+func TestCancelNotification(t *testing.T) {
+	s := newTestServer(t, nil, &fakeEmail{})
+	ctx := context.Background()
+
+	req := emailRequest("alice@example.com")
+	req.ScheduledFor = timestamppb.New(time.Now().Add(time.Hour))
+	resp, _ := s.SendNotification(ctx, req)
+
+	cancelled, err := s.CancelNotification(ctx, &pb.CancelNotificationRequest{Id: resp.Result.NotificationId})
+	if err != nil || !cancelled.Success {
+		t.Fatalf("CancelNotification() = %v, %v", cancelled, err)
+	}
+	got, _ := s.GetNotification(ctx, &pb.GetNotificationRequest{Id: resp.Result.NotificationId})
+	if got.Notification.Status != pb.NotificationStatus_NOTIFICATION_STATUS_FAILED || got.Notification.LastError != "cancelled" {
+		t.Errorf("Expected a cancelled notification, got %v", got.Notification)
+	}
+
+	again, _ := s.CancelNotification(ctx, &pb.CancelNotificationRequest{Id: resp.Result.NotificationId})
+	if again.Success {
+		t.Error("Expected a second cancel to be refused")
+	}
+}
+
+func TestListNotificationsAndStats(t *testing.T) {
+	fake := &fakeEmail{}
+	s := newTestServer(t, nil, fake)
+	ctx := context.Background()
+
+	for _, to := range []string{"alice@example.com", "bob@example.com", "carol@example.com"} {
+		s.SendNotification(ctx, emailRequest(to))
+		time.Sleep(time.Millisecond)
+	}
+	fake.failures = 1
+	s.SendNotification(ctx, emailRequest("dave@example.com"))
+
+	list, _ := s.ListNotifications(ctx, &pb.ListNotificationsRequest{Filter: &pb.NotificationFilter{
+		Statuses: []pb.NotificationStatus{pb.NotificationStatus_NOTIFICATION_STATUS_SENT},
+		Limit:    2,
+	}})
+	if list.Total != 3 || len(list.Notifications) != 2 || list.Notifications[0].Recipients[0] != "carol@example.com" {
+		t.Errorf("Expected the 2 newest of 3 sent notifications, got %d: %v", list.Total, list.Notifications)
+	}
+
+	list, _ = s.ListNotifications(ctx, &pb.ListNotificationsRequest{Filter: &pb.NotificationFilter{
+		Recipients: []string{"bob@example.com"},
+	}})
+	if list.Total != 1 || list.Notifications[0].Recipients[0] != "bob@example.com" {
+		t.Errorf("Expected Bob's notification, got %v", list.Notifications)
+	}
+
+	stats, _ := s.GetStats(ctx, &pb.GetStatsRequest{})
+	if stats.TotalSent != 3 || stats.TotalFailed != 1 || stats.ByType["NOTIFICATION_TYPE_EMAIL"] != 4 || stats.ByStatus["NOTIFICATION_STATUS_FAILED"] != 1 {
+		t.Errorf("Unexpected stats: %v", stats)
+	}
+}
+
+func TestFileStoreRestoresQueue(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "queue.json")}
+	s := newTestServer(t, store, &fakeEmail{})
+
+	req := emailRequest("alice@example.com")
+	req.ScheduledFor = timestamppb.New(time.Now().Add(time.Hour))
+	resp, _ := s.SendNotification(context.Background(), req)
+
+	restored := newTestServer(t, store, &fakeEmail{})
+	got, err := restored.GetNotification(context.Background(), &pb.GetNotificationRequest{Id: resp.Result.NotificationId})
+	if err != nil {
+		t.Fatalf("GetNotification() error = %v", err)
+	}
+	n := got.Notification
+	if n.Status != pb.NotificationStatus_NOTIFICATION_STATUS_PENDING || n.Recipients[0] != "alice@example.com" {
+		t.Errorf("Expected the pending notification to be restored, got %v", n)
+	}
+	if e := restored.entries[n.Id]; e.ContentType != "text/html" || len(e.BCC) != 1 || !e.NextAttempt.Equal(req.ScheduledFor.AsTime()) {
+		t.Errorf("Expected delivery details to be restored, got %+v", e)
+	}
+}
+
+func TestAPIKeyInterceptor(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(grpc.UnaryInterceptor(APIKeyInterceptor("secret")))
+	pb.RegisterNotifierServiceServer(server, newTestServer(t, nil, &fakeEmail{}))
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := pb.NewNotifierServiceClient(conn)
+
+	if _, err := client.GetStats(context.Background(), &pb.GetStatsRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without a key, got %v", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	if _, err := client.GetStats(ctx, &pb.GetStatsRequest{}); err != nil {
+		t.Errorf("Expected the key to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package notifierserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	pb "github.com/igodwin/secretsanta/api/grpc/pb"
+	"github.com/igodwin/secretsanta/internal/atomicfile"
+)
+
+// Entry is a notification together with the delivery details the
+// Notification message does not carry
+type Entry struct {
+	Notification *pb.Notification
+	CC           []string
+	BCC          []string
+	ContentType  string
+	// NextAttempt is when a pending or retrying notification is due
+	NextAttempt time.Time
+}
+
+// Store persists notifications so queued deliveries survive restarts
+type Store interface {
+	Load() ([]*Entry, error)
+	Save(entries []*Entry) error
+}
+
+// storedEntry is the JSON form of an Entry. The notification is encoded with
+// protojson so timestamps and enums stay readable.
+type storedEntry struct {
+	Notification json.RawMessage `json:"notification"`
+	CC           []string        `json:"cc,omitempty"`
+	BCC          []string        `json:"bcc,omitempty"`
+	ContentType  string          `json:"content_type,omitempty"`
+	NextAttempt  time.Time       `json:"next_attempt,omitempty"`
+}
+
+// FileStore keeps all notifications in a single JSON file. Notifications
+// contain assignments, so the file is only readable by the current user.
+type FileStore struct {
+	Path string
+}
+
+// Load returns the stored notifications, or none if the file does not exist yet
+func (f *FileStore) Load() ([]*Entry, error) {
+	data, err := os.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification queue: %w", err)
+	}
+
+	var stored []storedEntry
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to parse notification queue in %s: %w", f.Path, err)
+	}
+
+	entries := make([]*Entry, len(stored))
+	for i, s := range stored {
+		n := &pb.Notification{}
+		if err := protojson.Unmarshal(s.Notification, n); err != nil {
+			return nil, fmt.Errorf("failed to parse notification in %s: %w", f.Path, err)
+		}
+		entries[i] = &Entry{Notification: n, CC: s.CC, BCC: s.BCC, ContentType: s.ContentType, NextAttempt: s.NextAttempt}
+	}
+	return entries, nil
+}
+
+// Save replaces the stored notifications, atomically so a crash never leaves
+// a partial file behind
+func (f *FileStore) Save(entries []*Entry) error {
+	stored := make([]storedEntry, len(entries))
+	for i, e := range entries {
+		n, err := protojson.Marshal(e.Notification)
+		if err != nil {
+			return err
+		}
+		stored[i] = storedEntry{Notification: n, CC: e.CC, BCC: e.BCC, ContentType: e.ContentType, NextAttempt: e.NextAttempt}
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.Write(f.Path, data); err != nil {
+		return fmt.Errorf("failed to write notification queue: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package scheduler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/igodwin/secretsanta/internal/logging"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+const (
+	defaultRetryDelay  = time.Minute
+	defaultMaxAttempts = 5
+	// maxIdle bounds how long the scheduler sleeps, so wall clock changes are noticed
+	maxIdle = time.Hour
+)
+
+// Job kinds
+const (
+	KindAssignment = "assignment"
+	KindReminder   = "reminder"
+)
+
+// DeliverFunc sends one scheduled message
+type DeliverFunc func(a Assignment) error
+
+// Assignment is the part of a drawn participant needed to deliver later
+type Assignment struct {
+	Name             string   `json:"name"`
+	NotificationType string   `json:"notification_type"`
+	ContactInfo      []string `json:"contact_info"`
+	Recipient        string   `json:"recipient"`
+	// Subject and Body hold a pre-rendered message such as a reminder. When
+	// Body is empty the assignment notification itself is sent.
+	Subject string `json:"subject,omitempty"`
+	Body    string `json:"body,omitempty"`
+}
+
+// NewAssignment captures a drawn participant for later delivery
+func NewAssignment(p *participant.Participant) (Assignment, error) {
+	if p.Recipient == nil {
+		return Assignment{}, fmt.Errorf("participant %s has no recipient", p.Name)
+	}
+	return Assignment{
+		Name:             p.Name,
+		NotificationType: p.NotificationType,
+		ContactInfo:      p.ContactInfo,
+		Recipient:        p.Recipient.Name,
+	}, nil
+}
+
+// Participant rebuilds the participant to pass to the notifiers
+func (a Assignment) Participant() *participant.Participant {
+	return &participant.Participant{
+		Name:             a.Name,
+		NotificationType: a.NotificationType,
+		ContactInfo:      a.ContactInfo,
+		Recipient:        &participant.Participant{Name: a.Recipient},
+	}
+}
+
+// Job is a set of assignments to deliver at SendAt. Delivered assignments are
+// removed as they succeed, so a retry only reaches the remaining participants.
+type Job struct {
+	ID string `json:"id"`
+	// Kind is KindAssignment or KindReminder; empty means KindAssignment
+	Kind        string       `json:"kind,omitempty"`
+	Description string       `json:"description,omitempty"`
+	SendAt      time.Time    `json:"send_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+	Assignments []Assignment `json:"assignments"`
+	Attempts    int          `json:"attempts,omitempty"`
+	LastError   string       `json:"last_error,omitempty"`
+}
+
+// Scheduler delivers jobs at their send time from a background goroutine.
+// Jobs are persisted to the store whenever they change.
+type Scheduler struct {
+	RetryDelay  time.Duration
+	MaxAttempts int
+
+	store   Store
+	deliver DeliverFunc
+
+	mu   sync.Mutex
+	jobs []*Job
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New returns a scheduler holding the jobs already in store
+func New(store Store, deliver DeliverFunc) (*Scheduler, error) {
+	jobs, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Scheduler{
+		RetryDelay:  defaultRetryDelay,
+		MaxAttempts: defaultMaxAttempts,
+		store:       store,
+		deliver:     deliver,
+		jobs:        jobs,
+		wake:        make(chan struct{}, 1),
+	}, nil
+}
+
+// Schedule persists the drawn participants' assignments for delivery at sendAt
+func (s *Scheduler) Schedule(sendAt time.Time, participants []*participant.Participant) (*Job, error) {
+	job := &Job{Kind: KindAssignment, SendAt: sendAt}
+	for _, p := range participants {
+		assignment, err := NewAssignment(p)
+		if err != nil {
+			return nil, err
+		}
+		job.Assignments = append(job.Assignments, assignment)
+	}
+	return s.Add(job)
+}
+
+// Add persists job for delivery at its SendAt, assigning its ID
+func (s *Scheduler) Add(job *Job) (*Job, error) {
+	job.ID = newJobID()
+	job.SendAt = job.SendAt.UTC()
+	job.CreatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	s.jobs = append(s.jobs, job)
+	if err := s.store.Save(s.jobs); err != nil {
+		s.jobs = s.jobs[:len(s.jobs)-1]
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.mu.Unlock()
+
+	s.notify()
+	return job, nil
+}
+
+// Jobs returns a snapshot of the pending jobs
+func (s *Scheduler) Jobs() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]Job, len(s.jobs))
+	for i, job := range s.jobs {
+		jobs[i] = *job
+		jobs[i].Assignments = append([]Assignment(nil), job.Assignments...)
+	}
+	return jobs
+}
+
+// Start begins delivering jobs in the background. Jobs whose send time passed
+// while the process was down are delivered immediately.
+func (s *Scheduler) Start() {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run()
+}
+
+// Stop waits for any delivery in progress and stops the scheduler
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) run() {
+	defer close(s.done)
+	for {
+		s.runDue(time.Now())
+
+		timer := time.NewTimer(s.untilNext(time.Now()))
+		select {
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		case <-s.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// untilNext returns how long to sleep before the next job is due
+func (s *Scheduler) untilNext(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wait := maxIdle
+	for _, job := range s.jobs {
+		if d := job.SendAt.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// runDue delivers every job due at now. Delivery happens without holding the
+// lock so new jobs can be scheduled meanwhile.
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	var due []*Job
+	for _, job := range s.jobs {
+		if !job.SendAt.After(now) {
+			due = append(due, job)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, job := range due {
+		s.runJob(job)
+	}
+}
+
+func (s *Scheduler) runJob(job *Job) {
+	s.mu.Lock()
+	assignments := append([]Assignment(nil), job.Assignments...)
+	s.mu.Unlock()
+
+	var remaining []Assignment
+	var lastErr error
+	var personal []string
+	for _, assignment := range assignments {
+		if err := s.deliver(assignment); err != nil {
+			remaining = append(remaining, assignment)
+			lastErr = err
+			personal = append(personal, notifier.PersonalData(assignment.Participant())...)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job.Assignments = remaining
+	switch {
+	case len(remaining) == 0:
+		slog.Info("Scheduled job delivered", "job", job.ID, "count", len(assignments))
+		s.remove(job.ID)
+	case job.Attempts+1 >= s.MaxAttempts:
+		slog.Error("Scheduled job gave up", "job", job.ID, "undelivered", len(remaining), "attempts", job.Attempts+1, "error", logging.RedactError(lastErr, personal...))
+		s.remove(job.ID)
+	default:
+		job.Attempts++
+		job.LastError = lastErr.Error()
+		job.SendAt = time.Now().UTC().Add(s.RetryDelay)
+		slog.Warn("Scheduled job failed, retrying", "job", job.ID, "undelivered", len(remaining), "retry_at", job.SendAt, "error", logging.RedactError(lastErr, personal...))
+	}
+
+	if err := s.store.Save(s.jobs); err != nil {
+		slog.Error("Failed to persist scheduled jobs", "error", err)
+	}
+}
+
+// remove drops a job; the caller must hold the lock
+func (s *Scheduler) remove(id string) {
+	for i, job := range s.jobs {
+		if job.ID == id {
+			s.jobs = append(s.jobs[:i], s.jobs[i+1:]...)
+			return
+		}
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,234 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+// recorder is a DeliverFunc that records deliveries and fails for names in fail
+type recorder struct {
+	mu        sync.Mutex
+	delivered []string
+	fail      map[string]bool
+}
+
+func (r *recorder) deliver(a Assignment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail[a.Name] {
+		return fmt.Errorf("delivery to %s failed", a.Name)
+	}
+	r.delivered = append(r.delivered, a.Name+"->"+a.Recipient+a.Body)
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.delivered)
+}
+
+func drawn() []*participant.Participant {
+	alice := &participant.Participant{Name: "Alice", NotificationType: "email", ContactInfo: []string{"alice@example.com"}}
+	bob := &participant.Participant{Name: "Bob", NotificationType: "sms", ContactInfo: []string{"+15555550123"}}
+	alice.Recipient = bob
+	bob.Recipient = alice
+	return []*participant.Participant{alice, bob}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for scheduler")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "data", "scheduled.json")}
+
+	jobs, err := store.Load()
+	if err != nil || len(jobs) != 0 {
+		t.Fatalf("Expected no jobs from a missing file, got %v, %v", jobs, err)
+	}
+
+	sendAt := time.Date(2026, 12, 1, 9, 0, 0, 0, time.UTC)
+	job := &Job{ID: "abc", SendAt: sendAt, Assignments: []Assignment{{Name: "Alice", Recipient: "Bob"}}}
+	if err := store.Save([]*Job{job}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	info, err := os.Stat(store.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	jobs, err = store.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != "abc" || !jobs[0].SendAt.Equal(sendAt) || jobs[0].Assignments[0].Recipient != "Bob" {
+		t.Errorf("Unexpected jobs after round trip: %+v", jobs)
+	}
+}
+
+func TestSchedulerDeliversAtSendTime(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "scheduled.json")}
+	rec := &recorder{}
+	s, err := New(store, rec.deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	defer s.Stop()
+
+	if _, err := s.Schedule(time.Now().Add(50*time.Millisecond), drawn()); err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	if rec.count() != 0 {
+		t.Error("Delivered before the send time")
+	}
+
+	waitFor(t, func() bool { return rec.count() == 2 })
+	waitFor(t, func() bool { return len(s.Jobs()) == 0 })
+
+	jobs, _ := store.Load()
+	if len(jobs) != 0 {
+		t.Errorf("Expected delivered job to be removed from the store, got %d", len(jobs))
+	}
+}
+
+func TestSchedulerResumesAfterRestart(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "scheduled.json")}
+
+	// Schedule without starting, as if the process stopped before the send time
+	first, err := New(store, (&recorder{}).deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.Schedule(time.Now().Add(20*time.Millisecond), drawn()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	rec := &recorder{}
+	second, err := New(store, rec.deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.Jobs()) != 1 {
+		t.Fatalf("Expected the job to be loaded from the store, got %d", len(second.Jobs()))
+	}
+	second.Start()
+	defer second.Stop()
+
+	waitFor(t, func() bool { return rec.count() == 2 })
+	if rec.delivered[0] != "Alice->Bob" {
+		t.Errorf("Expected the recipient to survive the restart, got %v", rec.delivered)
+	}
+}
+
+func TestSchedulerRetriesOnlyFailedAssignments(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "scheduled.json")}
+	rec := &recorder{fail: map[string]bool{"Bob": true}}
+	s, err := New(store, rec.deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.RetryDelay = time.Hour
+
+	if _, err := s.Schedule(time.Now(), drawn()); err != nil {
+		t.Fatal(err)
+	}
+	s.runDue(time.Now())
+
+	jobs := s.Jobs()
+	if len(jobs) != 1 || len(jobs[0].Assignments) != 1 || jobs[0].Assignments[0].Name != "Bob" {
+		t.Fatalf("Expected only Bob to remain, got %+v", jobs)
+	}
+	if jobs[0].Attempts != 1 || jobs[0].LastError == "" || !jobs[0].SendAt.After(time.Now().Add(time.Minute)) {
+		t.Errorf("Expected a recorded retry an hour out, got %+v", jobs[0])
+	}
+
+	stored, _ := store.Load()
+	if len(stored) != 1 || len(stored[0].Assignments) != 1 {
+		t.Errorf("Expected the retry to be persisted, got %+v", stored)
+	}
+}
+
+func TestSchedulerGivesUpAfterMaxAttempts(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "scheduled.json")}
+	rec := &recorder{fail: map[string]bool{"Alice": true, "Bob": true}}
+	s, err := New(store, rec.deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.MaxAttempts = 2
+	s.RetryDelay = 0
+
+	if _, err := s.Schedule(time.Now(), drawn()); err != nil {
+		t.Fatal(err)
+	}
+	s.runDue(time.Now())
+	if len(s.Jobs()) != 1 {
+		t.Fatal("Expected the job to be kept after the first failure")
+	}
+	s.runDue(time.Now())
+	if len(s.Jobs()) != 0 {
+		t.Error("Expected the job to be dropped after MaxAttempts")
+	}
+}
+
+func TestScheduleRequiresRecipients(t *testing.T) {
+	s, err := New(&FileStore{Path: filepath.Join(t.TempDir(), "scheduled.json")}, (&recorder{}).deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Schedule(time.Now(), []*participant.Participant{{Name: "Alice"}})
+	if err == nil {
+		t.Error("Expected an error for a participant without a recipient")
+	}
+}
+
+func TestAddReminderJob(t *testing.T) {
+	store := &FileStore{Path: filepath.Join(t.TempDir(), "scheduled.json")}
+	rec := &recorder{}
+	s, err := New(store, rec.deliver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := s.Add(&Job{
+		Kind:        KindReminder,
+		SendAt:      time.Now(),
+		Assignments: []Assignment{{Name: "Alice", Recipient: "Bob", Subject: "One week left", Body: ": one week left"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ID == "" || job.CreatedAt.IsZero() {
+		t.Errorf("Expected Add to assign an ID and creation time, got %+v", job)
+	}
+
+	stored, _ := store.Load()
+	if len(stored) != 1 || stored[0].Kind != KindReminder || stored[0].Assignments[0].Subject != "One week left" {
+		t.Fatalf("Expected the reminder to be persisted, got %+v", stored)
+	}
+
+	s.runDue(time.Now())
+	if len(rec.delivered) != 1 || rec.delivered[0] != "Alice->Bob: one week left" {
+		t.Errorf("Expected the reminder body to be delivered, got %v", rec.delivered)
+	}
+}
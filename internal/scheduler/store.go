@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/igodwin/secretsanta/internal/atomicfile"
+)
+
+// Store persists scheduled jobs so they survive restarts
+type Store interface {
+	Load() ([]*Job, error)
+	Save(jobs []*Job) error
+}
+
+// FileStore keeps all jobs in a single JSON file. The file holds
+// assignments, so it is only readable by the current user.
+type FileStore struct {
+	Path string
+}
+
+// Load returns the stored jobs, or none if the file does not exist yet
+func (f *FileStore) Load() ([]*Job, error) {
+	data, err := os.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scheduled jobs: %w", err)
+	}
+
+	var jobs []*Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to parse scheduled jobs in %s: %w", f.Path, err)
+	}
+	return jobs, nil
+}
+
+// Save replaces the stored jobs, atomically so a crash never leaves a
+// partial file behind
+func (f *FileStore) Save(jobs []*Job) error {
+	if jobs == nil {
+		jobs = []*Job{}
+	}
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.Write(f.Path, data); err != nil {
+		return fmt.Errorf("failed to write scheduled jobs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/igodwin/secretsanta/pkg/config"
+)
+
+// recordSpans sends spans to an in-memory exporter for the rest of the test
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	if _, err := Setup(context.Background(), config.TracingConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+	return exporter
+}
+
+func TestUnaryClientInterceptorPropagatesTraceContext(t *testing.T) {
+	exporter := recordSpans(t)
+	ctx, parent := Start(context.Background(), "parent")
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer key")
+	var sent metadata.MD
+	err := UnaryClientInterceptor(ctx, "/notifier.v1.NotifierService/SendBatchNotifications", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			sent, _ = metadata.FromOutgoingContext(ctx)
+			return errors.New("unavailable")
+		})
+	parent.End()
+	if err == nil {
+		t.Fatal("Expected the call's error to be returned")
+	}
+
+	traceID := parent.SpanContext().TraceID().String()
+	if tp := sent.Get("traceparent"); len(tp) != 1 || !strings.Contains(tp[0], traceID) {
+		t.Errorf("Expected traceparent for trace %s, got %v", traceID, tp)
+	}
+	if len(sent.Get("authorization")) != 1 {
+		t.Errorf("Expected existing metadata to be kept, got %v", sent)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Expected client and parent spans, got %d", len(spans))
+	}
+	call := spans[0]
+	if call.SpanKind != trace.SpanKindClient || call.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("Expected a client span under the parent, got %+v", call)
+	}
+	if call.Status.Code != codes.Error {
+		t.Errorf("Expected the failed call's span to be an error, got %v", call.Status)
+	}
+}
+
+func TestEndOmitsErrorMessage(t *testing.T) {
+	exporter := recordSpans(t)
+	_, span := Start(context.Background(), "SendNotifications")
+	End(span, errors.New("failed to notify Alice: alice@example.com bounced"))
+
+	got := exporter.GetSpans()[0]
+	if got.Status.Code != codes.Error {
+		t.Errorf("Expected the span to be marked failed, got %v", got.Status)
+	}
+	recorded := fmt.Sprint(got.Status, got.Attributes, got.Events)
+	if strings.Contains(recorded, "Alice") || strings.Contains(recorded, "alice@example.com") {
+		t.Errorf("Expected no error message on the span, got %s", recorded)
+	}
+}
+
+func TestStartHTTPContinuesTrace(t *testing.T) {
+	exporter := recordSpans(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/draw", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, span := StartHTTP(req, "/api/draw")
+	EndHTTP(span, http.StatusInternalServerError)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected one span, got %d", len(spans))
+	}
+	got := spans[0]
+	if got.Name != "POST /api/draw" || got.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the caller's trace to continue, got %s in %s", got.Name, got.SpanContext.TraceID())
+	}
+	if got.Status.Code != codes.Error {
+		t.Errorf("Expected a 500 to mark the span failed, got %v", got.Status)
+	}
+}
+
+func TestSetupDisabled(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.TracingConfig{})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+	if _, span := Start(context.Background(), "noop"); span.IsRecording() {
+		t.Error("Expected spans not to be recorded when tracing is disabled")
+	}
+}
@@ -2,7 +2,10 @@
 const state = {
     participants: [],
     drawResults: null,
-    availableNotifiers: []
+    availableNotifiers: [],
+    usingNotifier: false,
+    // Login from /api/auth/session; csrf_token is sent with every POST
+    session: null
 };
 
 // API Base URL
@@ -16,9 +19,123 @@ document.addEventListener('DOMContentLoaded', () => {
     initializeUploadTab();
     initializeDrawTab();
     updateParticipantCount();
-    fetchNotificationStatus();
+    initializeLogin();
+    loadSession().then(fetchNotificationStatus);
 });
 
+// Authentication
+function initializeLogin() {
+    document.getElementById('login-form').addEventListener('submit', login);
+    document.getElementById('logout-btn').addEventListener('click', logout);
+}
+
+async function loadSession() {
+    try {
+        const response = await fetch(`${API_BASE}/api/auth/session`);
+        state.session = await response.json();
+    } catch (error) {
+        state.session = { auth_enabled: false };
+    }
+    renderSession();
+}
+
+function renderSession() {
+    const session = state.session;
+    const info = document.getElementById('session-info');
+    if (!session.auth_enabled || !session.authenticated) {
+        info.style.display = 'none';
+        if (session.auth_enabled) {
+            showLoginModal();
+        }
+        return;
+    }
+    document.getElementById('session-user').textContent = `${session.username} (${session.role})`;
+    info.style.display = 'flex';
+    document.getElementById('login-modal').style.display = 'none';
+}
+
+function showLoginModal() {
+    document.getElementById('oidc-login').style.display = state.session.oidc_enabled ? 'block' : 'none';
+    document.getElementById('login-modal').style.display = 'block';
+}
+
+async function login(e) {
+    e.preventDefault();
+    const error = document.getElementById('login-error');
+    error.textContent = '';
+
+    const response = await fetch(`${API_BASE}/api/auth/login`, {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({
+            username: document.getElementById('login-username').value,
+            password: document.getElementById('login-password').value
+        })
+    });
+    if (!response.ok) {
+        error.textContent = await errorText(response);
+        return;
+    }
+    state.session = await response.json();
+    document.getElementById('login-password').value = '';
+    renderSession();
+    fetchNotificationStatus();
+}
+
+async function logout() {
+    try {
+        await apiFetch(`${API_BASE}/api/auth/logout`, { method: 'POST' });
+    } catch (error) {
+        // The session had already expired
+    }
+    state.session = { auth_enabled: true, oidc_enabled: state.session.oidc_enabled };
+    renderSession();
+}
+
+// formatWait describes a Retry-After delay in seconds
+function formatWait(seconds) {
+    if (seconds < 120) {
+        return `${seconds} seconds`;
+    }
+    if (seconds < 2 * 3600) {
+        return `${Math.ceil(seconds / 60)} minutes`;
+    }
+    return `${Math.ceil(seconds / 3600)} hours`;
+}
+
+// errorText returns the error of a JSON rejection or a plain text error
+async function errorText(response) {
+    const text = (await response.text()).trim();
+    try {
+        return JSON.parse(text).error || text;
+    } catch (error) {
+        return text;
+    }
+}
+
+// apiFetch is fetch with the CSRF token added to POSTs. Requests rejected
+// before reaching the API (login, CSRF, origin or size) are thrown as errors.
+async function apiFetch(url, options = {}) {
+    const method = (options.method || 'GET').toUpperCase();
+    if (method !== 'GET' && state.session && state.session.csrf_token) {
+        options.headers = { ...options.headers, 'X-CSRF-Token': state.session.csrf_token };
+    }
+    const response = await fetch(url, options);
+    if (response.status === 401 && state.session && state.session.auth_enabled) {
+        state.session = { auth_enabled: true, oidc_enabled: state.session.oidc_enabled };
+        renderSession();
+    }
+    if (response.status === 401 || response.status === 403 || response.status === 413) {
+        throw new Error(await errorText(response));
+    }
+    if (response.status === 429) {
+        const retryAfter = response.headers.get('Retry-After');
+        const message = await errorText(response);
+        throw new Error(retryAfter ? `${message}. Try again in ${formatWait(Number(retryAfter))}.` : message);
+    }
+    return response;
+}
+
 // Tab Management
 function initializeTabs() {
     const tabButtons = document.querySelectorAll('.tab-button');
@@ -93,6 +210,12 @@ function initializeCreateTab() {
         // Enable/disable download button based on format selection
         downloadBtn.disabled = state.participants.length === 0 || !e.target.value;
     });
+    document.getElementById('participants-container').addEventListener('click', (e) => {
+        const button = e.target.closest('button[data-index]');
+        if (button) {
+            removeParticipant(Number(button.dataset.index));
+        }
+    });
 }
 
 function addParticipant() {
@@ -115,6 +238,9 @@ function addParticipant() {
         contact_info: contactInfo,
         exclusions: exclusions
     };
+    if (formData.get('no_reminders')) {
+        participant.no_reminders = true;
+    }
 
     // Check for duplicate names
     if (state.participants.some(p => p.name === participant.name)) {
@@ -144,9 +270,10 @@ function renderParticipants() {
                 <small>
                     ${escapeHtml(p.notification_type)} • ${escapeHtml(p.contact_info.join(', '))}
                     ${p.exclusions.length > 0 ? ` • Excludes: ${escapeHtml(p.exclusions.join(', '))}` : ''}
+                    ${p.no_reminders ? ' • No reminders' : ''}
                 </small>
             </div>
-            <button onclick="removeParticipant(${index})">Remove</button>
+            <button data-index="${index}">Remove</button>
         </div>
     `).join('');
 }
@@ -176,6 +303,7 @@ function updateParticipantCount() {
     // Enable/disable draw button
     const drawBtn = document.getElementById('run-draw-btn');
     drawBtn.disabled = state.participants.length < 2;
+    document.getElementById('preview-btn').disabled = state.participants.length < 2;
 
     // Enable/disable download button
     const downloadBtn = document.getElementById('download-btn');
@@ -189,7 +317,7 @@ async function validateParticipants() {
     }
 
     try {
-        const response = await fetch(`${API_BASE}/api/validate`, {
+        const response = await apiFetch(`${API_BASE}/api/validate`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify(state.participants)
@@ -252,7 +380,7 @@ async function handleFile(file) {
     formData.append('file', file);
 
     try {
-        const response = await fetch(`${API_BASE}/api/upload`, {
+        const response = await apiFetch(`${API_BASE}/api/upload`, {
             method: 'POST',
             body: formData
         });
@@ -283,7 +411,7 @@ async function handleFile(file) {
 
 async function downloadTemplate(format) {
     try {
-        const response = await fetch(`${API_BASE}/api/template?format=${format}`);
+        const response = await apiFetch(`${API_BASE}/api/template?format=${format}`);
 
         if (!response.ok) {
             throw new Error('Failed to download template');
@@ -320,8 +448,23 @@ function initializeDrawTab() {
     const newDrawBtn = document.getElementById('new-draw-btn');
 
     runDrawBtn.addEventListener('click', runDraw);
+    document.getElementById('preview-btn').addEventListener('click', previewMessages);
+    document.getElementById('event-date').addEventListener('change', (e) => {
+        document.getElementById('event-details').style.display = e.target.value ? 'block' : 'none';
+    });
     exportBtn.addEventListener('click', exportResults);
     newDrawBtn.addEventListener('click', resetDraw);
+    document.getElementById('refresh-delivery-btn').addEventListener('click', () => {
+        loadDeliveryStatus();
+        loadNotifierStats();
+    });
+    document.getElementById('delivery-container').addEventListener('click', (e) => {
+        const button = e.target.closest('button[data-action]');
+        if (button) {
+            notificationAction(button.dataset.action, button.dataset.id);
+        }
+    });
+    document.getElementById('smtp-test-btn').addEventListener('click', testSMTP);
 }
 
 function updateDrawTab() {
@@ -359,7 +502,18 @@ async function runDraw() {
             requestBody.archive_email = archiveEmail;
         }
 
-        const response = await fetch(`${API_BASE}/api/draw`, {
+        // Delay delivery if a send time was picked (local time in the browser)
+        const sendAt = document.getElementById('send-at').value;
+        if (sendAt) {
+            requestBody.send_at = new Date(sendAt).toISOString();
+        }
+
+        const event = buildEvent();
+        if (event) {
+            requestBody.event = event;
+        }
+
+        const response = await apiFetch(`${API_BASE}/api/draw`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify(requestBody)
@@ -373,8 +527,17 @@ async function runDraw() {
 
         state.drawResults = result.participants;
         displayResults();
+        if (state.usingNotifier) {
+            loadDeliveryStatus();
+        }
 
-        if (archiveEmail) {
+        if (result.reminders_scheduled) {
+            showToast(`${result.reminders_scheduled} reminder(s) scheduled`, 'success');
+        }
+
+        if (result.scheduled_for) {
+            showToast(`Draw completed! Notifications scheduled for ${new Date(result.scheduled_for).toLocaleString()}`, 'success');
+        } else if (archiveEmail) {
             showToast(`Draw completed! Archive sent to ${archiveEmail}`, 'success');
         } else {
             showToast('Draw completed successfully!', 'success');
@@ -386,6 +549,99 @@ async function runDraw() {
     }
 }
 
+// Build the event from the Run Draw tab, or null when no exchange date is set
+function buildEvent() {
+    const date = document.getElementById('event-date').value;
+    if (!date) {
+        return null;
+    }
+
+    const reminders = [];
+    if (document.getElementById('remind-week').checked) {
+        reminders.push({ days_before: 7 });
+    }
+    if (document.getElementById('remind-day').checked) {
+        reminders.push({ days_before: 1 });
+    }
+    if (reminders.length === 0) {
+        return null;
+    }
+
+    // Reminders go out in the morning of the organizer's time zone
+    return {
+        name: document.getElementById('event-name').value.trim(),
+        exchange_date: new Date(`${date}T09:00`).toISOString(),
+        budget: document.getElementById('event-budget').value.trim(),
+        reminders: reminders
+    };
+}
+
+// Render every message without drawing or sending, and show one sample
+async function previewMessages() {
+    const previewBtn = document.getElementById('preview-btn');
+    previewBtn.disabled = true;
+
+    try {
+        const requestBody = {
+            participants: state.participants,
+            preview: true
+        };
+        const archiveEmail = document.getElementById('archive-email').value.trim();
+        if (archiveEmail) {
+            requestBody.archive_email = archiveEmail;
+        }
+
+        const response = await apiFetch(`${API_BASE}/api/draw`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(requestBody)
+        });
+
+        const result = await response.json();
+
+        if (!result.success) {
+            throw new Error(result.error);
+        }
+
+        showPreviewModal(result.previews || []);
+    } catch (error) {
+        showToast('Preview failed: ' + error.message, 'error');
+    } finally {
+        previewBtn.disabled = state.participants.length < 2;
+    }
+}
+
+function showPreviewModal(previews) {
+    const messageCount = previews.reduce((count, p) => count + (p.messages || []).length, 0);
+    const errors = previews.flatMap(p => (p.errors || []).map(e => `${p.participant}: ${e}`));
+    const sample = previews.find(p => p.messages && p.messages.length > 0);
+
+    let html = `<p>${messageCount} message(s) will be sent to ${previews.length} participant(s).
+        Recipients are shown as a placeholder; no assignments were drawn.</p>`;
+
+    if (sample) {
+        const message = sample.messages[0];
+        html += `<div class="message-preview">
+            <p><strong>Channel:</strong> ${getNotificationIcon(message.channel)} ${escapeHtml(message.channel)}</p>
+            ${message.from ? `<p><strong>From:</strong> ${escapeHtml(message.from)}</p>` : ''}
+            <p><strong>To:</strong> ${escapeHtml((message.recipients || []).join(', '))}</p>
+            ${message.bcc ? `<p><strong>BCC:</strong> ${escapeHtml(message.bcc.join(', '))}</p>` : ''}
+            ${message.subject ? `<p><strong>Subject:</strong> ${escapeHtml(message.subject)}</p>` : ''}
+            <pre>${escapeHtml(message.body)}</pre>
+        </div>`;
+    }
+
+    if (errors.length > 0) {
+        html += `<div class="validation-warning">
+            <h4>⚠️ Problems:</h4>
+            <ul>${errors.map(e => `<li>${escapeHtml(e)}</li>`).join('')}</ul>
+        </div>`;
+    }
+
+    document.getElementById('preview-results').innerHTML = html;
+    document.getElementById('preview-modal').style.display = 'block';
+}
+
 function displayResults() {
     const resultsSection = document.getElementById('results-section');
     const resultsContainer = document.getElementById('results-container');
@@ -460,6 +716,7 @@ function revealActualResults() {
                 <div class="giver">${escapeHtml(p.name)}</div>
                 <div class="arrow">→</div>
                 <div class="recipient">${escapeHtml(p.recipient)}</div>
+                ${p.delivered_via ? `<small class="delivered-via">via ${escapeHtml(p.delivered_via)}</small>` : ''}
             </div>
         `).join('')}
     `;
@@ -524,10 +781,13 @@ document.querySelector('.close').addEventListener('click', () => {
     document.getElementById('validation-modal').style.display = 'none';
 });
 
+document.getElementById('preview-close').addEventListener('click', () => {
+    document.getElementById('preview-modal').style.display = 'none';
+});
+
 window.addEventListener('click', (e) => {
-    const modal = document.getElementById('validation-modal');
-    if (e.target === modal) {
-        modal.style.display = 'none';
+    if (e.target.classList.contains('modal') && e.target.id !== 'login-modal') {
+        e.target.style.display = 'none';
     }
 });
 
@@ -550,12 +810,167 @@ function escapeHtml(text) {
     return div.innerHTML;
 }
 
+// Delivery Status
+async function loadDeliveryStatus() {
+    const container = document.getElementById('delivery-container');
+
+    try {
+        const response = await apiFetch(`${API_BASE}/api/notifications`);
+        const result = await response.json();
+        if (!result.success) {
+            throw new Error(result.error);
+        }
+        renderDeliveryStatus(result.notifications || []);
+    } catch (error) {
+        container.innerHTML = `<p class="status-error">Could not load delivery status: ${escapeHtml(error.message)}</p>`;
+    }
+}
+
+function renderDeliveryStatus(notifications) {
+    const container = document.getElementById('delivery-container');
+
+    if (notifications.length === 0) {
+        container.innerHTML = '<p style="color: #6c757d; text-align: center;">No notifications sent yet</p>';
+        return;
+    }
+
+    container.innerHTML = notifications.map(n => {
+        const when = n.sent_at ? `Sent ${new Date(n.sent_at).toLocaleString()}`
+            : n.scheduled_for ? `Scheduled for ${new Date(n.scheduled_for).toLocaleString()}`
+            : '';
+        const canRetry = n.status === 'failed';
+        const canCancel = ['pending', 'queued', 'retrying'].includes(n.status);
+        return `
+            <div class="delivery-card">
+                <div class="participant-info">
+                    <strong>${escapeHtml(n.participant)}</strong>
+                    <span class="delivery-status status-${escapeHtml(n.status)}">${escapeHtml(n.status)}</span>
+                    <small>
+                        ${escapeHtml(n.channel || '')}${when ? ` • ${escapeHtml(when)}` : ''}
+                        ${n.retry_count > 0 ? ` • ${n.retry_count} retr${n.retry_count > 1 ? 'ies' : 'y'}` : ''}
+                        ${n.last_error ? `<br>${escapeHtml(n.last_error)}` : ''}
+                    </small>
+                </div>
+                <div>
+                    ${canRetry ? `<button class="btn btn-secondary" data-action="retry" data-id="${escapeHtml(n.id)}">Retry</button>` : ''}
+                    ${canCancel ? `<button class="btn btn-secondary" data-action="cancel" data-id="${escapeHtml(n.id)}">Cancel</button>` : ''}
+                </div>
+            </div>
+        `;
+    }).join('');
+}
+
+async function notificationAction(action, id) {
+    if (action === 'cancel' && !confirm('Cancel this notification? The participant will not receive it.')) {
+        return;
+    }
+
+    try {
+        const response = await apiFetch(`${API_BASE}/api/notifications/${action}`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ id: id })
+        });
+        const result = await response.json();
+        if (!result.success) {
+            throw new Error(result.error);
+        }
+        showToast(action === 'retry' ? 'Notification resent' : 'Notification cancelled', 'success');
+    } catch (error) {
+        showToast(`Failed to ${action} notification: ${error.message}`, 'error');
+    }
+    loadDeliveryStatus();
+}
+
+// Check the built-in SMTP settings step by step, so bad credentials show up
+// before a draw instead of as failed assignments
+async function testSMTP() {
+    const button = document.getElementById('smtp-test-btn');
+    const container = document.getElementById('smtp-test-container');
+    button.disabled = true;
+    button.textContent = 'Testing...';
+    container.innerHTML = '';
+
+    try {
+        const response = await apiFetch(`${API_BASE}/api/smtp/test`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ send_to: document.getElementById('smtp-test-to').value.trim() })
+        });
+        const result = await response.json();
+        if (result.error) {
+            throw new Error(result.error);
+        }
+        renderSMTPTest(result.steps);
+        showToast(result.success ? 'Email settings work' : 'Email settings test failed', result.success ? 'success' : 'error');
+    } catch (error) {
+        container.innerHTML = `<p class="status-error">Could not test email settings: ${escapeHtml(error.message)}</p>`;
+    } finally {
+        button.disabled = false;
+        button.textContent = 'Test Email Settings';
+    }
+}
+
+function renderSMTPTest(steps) {
+    const labels = { dial: 'Connect', tls: 'TLS', auth: 'Log in', send: 'Test message' };
+    document.getElementById('smtp-test-container').innerHTML = steps.map(step => {
+        const result = step.skipped ? 'skipped' : (step.ok ? 'ok' : 'failed');
+        const statusClass = step.skipped ? '' : (step.ok ? 'status-sent' : 'status-failed');
+        return `
+            <div class="delivery-card">
+                <div class="participant-info">
+                    <strong>${escapeHtml(labels[step.name] || step.name)}</strong>
+                    <span class="delivery-status ${statusClass}">${result}</span>
+                    <small>${escapeHtml(step.detail || '')}</small>
+                </div>
+            </div>
+        `;
+    }).join('');
+}
+
+// Notifier service statistics, cached briefly by the server
+async function loadNotifierStats() {
+    const container = document.getElementById('stats-container');
+
+    try {
+        const response = await apiFetch(`${API_BASE}/api/notifier/stats`);
+        const result = await response.json();
+        if (!result.success) {
+            throw new Error(result.error);
+        }
+        renderNotifierStats(result.stats, result.fetched_at);
+    } catch (error) {
+        container.innerHTML = `<p class="status-error">Could not load statistics: ${escapeHtml(error.message)}</p>`;
+    }
+}
+
+function renderNotifierStats(stats, fetchedAt) {
+    const container = document.getElementById('stats-container');
+    const breakdown = (counts) => Object.entries(counts || {})
+        .sort(([a], [b]) => a.localeCompare(b))
+        .map(([key, count]) => `<span class="status-type">${escapeHtml(key)}: ${count}</span>`)
+        .join(' ') || '<span class="status-type">none</span>';
+
+    container.innerHTML = `
+        <div class="stats-grid">
+            <div class="stat"><strong>${stats.total_sent}</strong><small>Sent</small></div>
+            <div class="stat"><strong>${stats.total_failed}</strong><small>Failed</small></div>
+            <div class="stat"><strong>${stats.total_pending}</strong><small>Pending</small></div>
+            <div class="stat"><strong>${stats.total_queued}</strong><small>Queued</small></div>
+            <div class="stat"><strong>${stats.average_latency_ms.toFixed(0)} ms</strong><small>Avg Latency</small></div>
+        </div>
+        <p><small>By type:</small> ${breakdown(stats.by_type)}</p>
+        <p><small>By status:</small> ${breakdown(stats.by_status)}</p>
+        <p><small>As of ${new Date(fetchedAt).toLocaleTimeString()}</small></p>
+    `;
+}
+
 // Notification Status
 async function fetchNotificationStatus() {
     const statusContainer = document.getElementById('notification-types');
 
     try {
-        const response = await fetch(`${API_BASE}/api/status`);
+        const response = await apiFetch(`${API_BASE}/api/status`);
         if (!response.ok) {
             throw new Error('Failed to fetch status');
         }
@@ -571,6 +986,17 @@ async function fetchNotificationStatus() {
 function displayNotificationStatus(status) {
     const container = document.getElementById('notification-types');
 
+    // Delivery status is only tracked by the notifier service
+    state.usingNotifier = !!status.using_notifier;
+    if (state.usingNotifier) {
+        document.getElementById('stats-section').style.display = 'block';
+        document.getElementById('delivery-section').style.display = 'block';
+        loadDeliveryStatus();
+        loadNotifierStats();
+    } else if (status.smtp_configured) {
+        document.getElementById('smtp-section').style.display = 'block';
+    }
+
     if (!status.available || status.available.length === 0) {
         container.innerHTML = '<span class="status-type">None configured</span>';
         return;
@@ -664,7 +1090,7 @@ async function downloadParticipants() {
     }
 
     try {
-        const response = await fetch(`${API_BASE}/api/download`, {
+        const response = await apiFetch(`${API_BASE}/api/download`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({
@@ -700,7 +1126,12 @@ function getNotificationIcon(type) {
         'email': '📧',
         'slack': '💬',
         'ntfy': '🔔',
-        'stdout': '💻'
+        'stdout': '💻',
+        'webhook': '🔗',
+        'sms': '📱',
+        'discord': '🎮',
+        'telegram': '✈️',
+        'matrix': '🟩'
     };
     return icons[type.toLowerCase()] || '📬';
 }
@@ -0,0 +1,9 @@
+// Package web holds the browser UI, embedded into the server binary
+package web
+
+import "embed"
+
+// Static is the static directory: index.html and the files under /static/
+//
+//go:embed static
+var Static embed.FS
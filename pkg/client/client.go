@@ -0,0 +1,291 @@
+// Package client is a Go SDK for the Secret Santa REST API
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+const (
+	sessionCookie = "secretsanta_session"
+	csrfHeader    = "X-CSRF-Token"
+)
+
+// APIError is returned when the server answers with an error status.
+// Message is the error from a JSON body, or the plain text body. Code is
+// set when the server rejected the request before handling it, e.g.
+// "authentication_required" or "body_too_large". RetryAfter is how long to
+// wait before retrying a rate limited request.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Code       string
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("secretsanta: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Client calls a Secret Santa web server. When the server requires a
+// login, call Login first; the client then sends the session cookie and
+// CSRF token with every request.
+type Client struct {
+	// BaseURL is the server root, e.g. http://localhost:8080
+	BaseURL    string
+	HTTPClient *http.Client
+
+	mu        sync.Mutex
+	session   *http.Cookie
+	csrfToken string
+}
+
+// New returns a client for the server at baseURL
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// Login logs in with a local account
+func (c *Client) Login(ctx context.Context, username, password string) (*SessionResponse, error) {
+	body, err := json.Marshal(LoginRequest{Username: username, Password: password})
+	if err != nil {
+		return nil, err
+	}
+	resp, data, err := c.send(ctx, http.MethodPost, "/api/auth/login", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	var session SessionResponse
+	if err := decode(data, &session); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == sessionCookie {
+			c.session = cookie
+		}
+	}
+	c.csrfToken = session.CSRFToken
+	return &session, nil
+}
+
+// Logout ends the session started by Login
+func (c *Client) Logout(ctx context.Context) error {
+	var session SessionResponse
+	if err := c.postJSON(ctx, "/api/auth/logout", struct{}{}, &session); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.session, c.csrfToken = nil, ""
+	return nil
+}
+
+// Session reports whether the server requires a login and who the client
+// is logged in as
+func (c *Client) Session(ctx context.Context) (*SessionResponse, error) {
+	var resp SessionResponse
+	return &resp, c.getJSON(ctx, "/api/auth/session", &resp)
+}
+
+// Validate checks whether a draw is possible without drawing
+func (c *Client) Validate(ctx context.Context, participants []*participant.Participant) (*ValidationResponse, error) {
+	var resp ValidationResponse
+	return &resp, c.postJSON(ctx, "/api/validate", participants, &resp)
+}
+
+// Draw draws names and sends, schedules or previews the notifications.
+// A rejected draw is returned as an *APIError.
+func (c *Client) Draw(ctx context.Context, req *DrawRequest) (*DrawResponse, error) {
+	var resp DrawResponse
+	return &resp, c.postJSON(ctx, "/api/draw", req, &resp)
+}
+
+// Upload parses and validates a participants file. The format is taken
+// from the filename's extension.
+func (c *Client) Upload(ctx context.Context, filename string, content io.Reader) (*UploadResponse, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		return nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	data, err := c.do(ctx, http.MethodPost, "/api/upload", form.FormDataContentType(), &body)
+	if err != nil {
+		return nil, err
+	}
+	var resp UploadResponse
+	return &resp, decode(data, &resp)
+}
+
+// Download exports participants as a file in req.Format
+func (c *Client) Download(ctx context.Context, req *DownloadRequest) ([]byte, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, "/api/download", "application/json", bytes.NewReader(body))
+}
+
+// Export returns draw results as the server's JSON attachment
+func (c *Client) Export(ctx context.Context, participants []*ParticipantResponse) ([]*ParticipantResponse, error) {
+	var resp []*ParticipantResponse
+	return resp, c.postJSON(ctx, "/api/export", participants, &resp)
+}
+
+// Template returns a sample participants file in format
+func (c *Client) Template(ctx context.Context, format string) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, "/api/template?format="+url.QueryEscape(format), "", nil)
+}
+
+// Status lists the available notification channels and notifier health
+func (c *Client) Status(ctx context.Context) (*NotificationStatusResponse, error) {
+	var resp NotificationStatusResponse
+	return &resp, c.getJSON(ctx, "/api/status", &resp)
+}
+
+// Notifications returns the delivery status of tracked notifications
+func (c *Client) Notifications(ctx context.Context) (*NotificationsResponse, error) {
+	var resp NotificationsResponse
+	return &resp, c.getJSON(ctx, "/api/notifications", &resp)
+}
+
+// RetryNotification resends a failed notification. The response ID may
+// differ from id.
+func (c *Client) RetryNotification(ctx context.Context, id string) (*NotificationActionResponse, error) {
+	var resp NotificationActionResponse
+	return &resp, c.postJSON(ctx, "/api/notifications/retry", NotificationActionRequest{ID: id}, &resp)
+}
+
+// CancelNotification cancels a notification that has not been sent yet
+func (c *Client) CancelNotification(ctx context.Context, id string) (*NotificationActionResponse, error) {
+	var resp NotificationActionResponse
+	return &resp, c.postJSON(ctx, "/api/notifications/cancel", NotificationActionRequest{ID: id}, &resp)
+}
+
+// TestSMTP checks the server's SMTP settings step by step, sending a test
+// message to sendTo when it is set
+func (c *Client) TestSMTP(ctx context.Context, sendTo string) (*SMTPTestResponse, error) {
+	var resp SMTPTestResponse
+	return &resp, c.postJSON(ctx, "/api/smtp/test", SMTPTestRequest{SendTo: sendTo}, &resp)
+}
+
+// NotifierStats returns the notifier service delivery statistics
+func (c *Client) NotifierStats(ctx context.Context) (*NotifierStatsResponse, error) {
+	var resp NotifierStatsResponse
+	return &resp, c.getJSON(ctx, "/api/notifier/stats", &resp)
+}
+
+// OpenAPI returns the server's OpenAPI document
+func (c *Client) OpenAPI(ctx context.Context) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, "/api/openapi.json", "", nil)
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, out any) error {
+	data, err := c.do(ctx, http.MethodGet, path, "", nil)
+	if err != nil {
+		return err
+	}
+	return decode(data, out)
+}
+
+func (c *Client) postJSON(ctx context.Context, path string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	data, err := c.do(ctx, http.MethodPost, path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	return decode(data, out)
+}
+
+// do sends a request and returns the response body, or an *APIError for
+// error statuses
+func (c *Client) do(ctx context.Context, method, path, contentType string, body io.Reader) ([]byte, error) {
+	_, data, err := c.send(ctx, method, path, contentType, body)
+	return data, err
+}
+
+// send is do that also returns the response, whose body has been read
+func (c *Client) send(ctx context.Context, method, path, contentType string, body io.Reader) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	c.mu.Lock()
+	if c.session != nil {
+		req.AddCookie(&http.Cookie{Name: c.session.Name, Value: c.session.Value})
+		req.Header.Set(csrfHeader, c.csrfToken)
+	}
+	c.mu.Unlock()
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode >= 300 {
+		message, code := errorMessage(data)
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: message, Code: code}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, nil, apiErr
+	}
+	return resp, data, nil
+}
+
+// errorMessage extracts the error and code fields of a JSON error body,
+// falling back to the body as text
+func errorMessage(data []byte) (string, string) {
+	var body struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		return body.Error, body.Code
+	}
+	return strings.TrimSpace(string(data)), ""
+}
+
+func decode(data []byte, out any) error {
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("secretsanta: invalid response: %w", err)
+	}
+	return nil
+}
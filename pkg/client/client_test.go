@@ -0,0 +1,162 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/igodwin/secretsanta/pkg/client"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		server  *httptest.Server
+		handler http.HandlerFunc
+		c       *client.Client
+		ctx     context.Context
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler(w, r)
+		}))
+		c = client.New(server.URL + "/")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("Draw", func() {
+		It("should post the request and decode the response", func() {
+			var received client.DrawRequest
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal(http.MethodPost))
+				Expect(r.URL.Path).To(Equal("/api/draw"))
+				json.NewDecoder(r.Body).Decode(&received)
+				recipient := "Bob"
+				json.NewEncoder(w).Encode(client.DrawResponse{
+					Success:      true,
+					Participants: []*client.ParticipantResponse{{Name: "Alice", Recipient: &recipient}},
+				})
+			}
+
+			resp, err := c.Draw(ctx, &client.DrawRequest{
+				Participants: []participant.Participant{{Name: "Alice"}, {Name: "Bob"}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(received.Participants).To(HaveLen(2))
+			Expect(*resp.Participants[0].Recipient).To(Equal("Bob"))
+		})
+
+		It("should return the JSON error as an APIError", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(client.DrawResponse{Error: "send_at must be in the future"})
+			}
+
+			_, err := c.Draw(ctx, &client.DrawRequest{})
+			var apiErr *client.APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusBadRequest))
+			Expect(apiErr.Message).To(Equal("send_at must be in the future"))
+		})
+
+		It("should return how long to wait when rate limited", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Retry-After", "30")
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"error":"Too many requests from this address","code":"rate_limited"}`))
+			}
+
+			_, err := c.Draw(ctx, &client.DrawRequest{})
+			var apiErr *client.APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.Code).To(Equal("rate_limited"))
+			Expect(apiErr.RetryAfter).To(Equal(30 * time.Second))
+		})
+	})
+
+	Context("Upload", func() {
+		It("should send the file as multipart form data", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				file, header, err := r.FormFile("file")
+				Expect(err).NotTo(HaveOccurred())
+				content, _ := io.ReadAll(file)
+				Expect(header.Filename).To(Equal("people.csv"))
+				Expect(string(content)).To(Equal("name\nAlice\n"))
+				json.NewEncoder(w).Encode(client.UploadResponse{Success: true, Format: "csv"})
+			}
+
+			resp, err := c.Upload(ctx, "people.csv", strings.NewReader("name\nAlice\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Format).To(Equal("csv"))
+		})
+	})
+
+	Context("Template", func() {
+		It("should return plain text errors as the message", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("format")).To(Equal("xls"))
+				http.Error(w, "Failed to generate template", http.StatusInternalServerError)
+			}
+
+			_, err := c.Template(ctx, "xls")
+			var apiErr *client.APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.Message).To(Equal("Failed to generate template"))
+		})
+	})
+
+	Context("Login", func() {
+		It("should send the session cookie and CSRF token with later requests", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/api/auth/login":
+					var req client.LoginRequest
+					json.NewDecoder(r.Body).Decode(&req)
+					Expect(req).To(Equal(client.LoginRequest{Username: "alice", Password: "hunter2"}))
+					http.SetCookie(w, &http.Cookie{Name: "secretsanta_session", Value: "token"})
+					json.NewEncoder(w).Encode(client.SessionResponse{AuthEnabled: true, Authenticated: true, Role: "organizer", CSRFToken: "csrf"})
+				default:
+					cookie, err := r.Cookie("secretsanta_session")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(cookie.Value).To(Equal("token"))
+					Expect(r.Header.Get("X-CSRF-Token")).To(Equal("csrf"))
+					json.NewEncoder(w).Encode(client.ValidationResponse{Valid: true})
+				}
+			}
+
+			session, err := c.Login(ctx, "alice", "hunter2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(session.Role).To(Equal("organizer"))
+
+			resp, err := c.Validate(ctx, []*participant.Participant{{Name: "Alice"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Valid).To(BeTrue())
+		})
+
+		It("should return a rejected login as an APIError with its code", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error":"Invalid username or password","code":"invalid_credentials"}`))
+			}
+
+			_, err := c.Login(ctx, "alice", "wrong")
+			var apiErr *client.APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusUnauthorized))
+			Expect(apiErr.Code).To(Equal("invalid_credentials"))
+			Expect(apiErr.Message).To(Equal("Invalid username or password"))
+		})
+	})
+})
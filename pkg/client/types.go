@@ -0,0 +1,178 @@
+package client
+
+import (
+	"time"
+
+	"github.com/igodwin/secretsanta/pkg/event"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+)
+
+// The types below mirror the JSON bodies of the REST API, described at
+// /api/openapi.json. A test in internal/api checks they match the server.
+
+// SessionResponse describes the client's login
+type SessionResponse struct {
+	AuthEnabled   bool   `json:"auth_enabled"`
+	OIDCEnabled   bool   `json:"oidc_enabled,omitempty"`
+	Authenticated bool   `json:"authenticated"`
+	Username      string `json:"username,omitempty"`
+	Role          string `json:"role,omitempty"`
+	CSRFToken     string `json:"csrf_token,omitempty"`
+}
+
+// LoginRequest holds local account credentials
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ParticipantResponse is a participant in draw results
+type ParticipantResponse struct {
+	Name             string   `json:"name"`
+	NotificationType string   `json:"notification_type"`
+	ContactInfo      []string `json:"contact_info"`
+	Exclusions       []string `json:"exclusions"`
+	Recipient        *string  `json:"recipient,omitempty"`
+	NoReminders      bool     `json:"no_reminders,omitempty"`
+	DeliveredVia     string   `json:"delivered_via,omitempty"`
+	NotificationID   string   `json:"notification_id,omitempty"`
+}
+
+// ValidationResponse reports whether a draw is possible
+type ValidationResponse struct {
+	Valid                     bool     `json:"valid"`
+	Errors                    []string `json:"errors,omitempty"`
+	Warnings                  []string `json:"warnings,omitempty"`
+	ParticipantsWithNoOptions []string `json:"participants_with_no_options,omitempty"`
+	MinCompatibility          int      `json:"min_compatibility"`
+	AvgCompatibility          float64  `json:"avg_compatibility"`
+	TotalParticipants         int      `json:"total_participants"`
+}
+
+// DrawRequest asks the server to draw names
+type DrawRequest struct {
+	Participants []participant.Participant `json:"participants"`
+	ArchiveEmail string                    `json:"archive_email,omitempty"`
+	// Preview renders the notifications without drawing or sending anything
+	Preview bool `json:"preview,omitempty"`
+	// SendAt delays delivery of the assignments until the given time
+	SendAt *time.Time `json:"send_at,omitempty"`
+	// Event schedules reminders before the exchange date
+	Event *event.Event `json:"event,omitempty"`
+}
+
+// DrawResponse is the result of a draw or preview
+type DrawResponse struct {
+	Success            bool                   `json:"success"`
+	Participants       []*ParticipantResponse `json:"participants,omitempty"`
+	Previews           []*Preview             `json:"previews,omitempty"`
+	ScheduledFor       *time.Time             `json:"scheduled_for,omitempty"`
+	RemindersScheduled int                    `json:"reminders_scheduled,omitempty"`
+	Error              string                 `json:"error,omitempty"`
+}
+
+// Preview is every message one participant would receive
+type Preview struct {
+	Participant string              `json:"participant"`
+	Messages    []*notifier.Message `json:"messages"`
+	Errors      []string            `json:"errors,omitempty"`
+}
+
+// UploadResponse is the parsed and validated content of an uploaded file
+type UploadResponse struct {
+	Success      bool                       `json:"success"`
+	Participants []*participant.Participant `json:"participants"`
+	Validation   ValidationResponse         `json:"validation"`
+	Format       string                     `json:"format"`
+}
+
+// DownloadRequest asks for participants as a file
+type DownloadRequest struct {
+	Participants []participant.Participant `json:"participants"`
+	Format       string                    `json:"format"`
+}
+
+// NotifierTypeInfo describes an available notification channel
+type NotifierTypeInfo struct {
+	Type           string                 `json:"type"`
+	Description    string                 `json:"description,omitempty"`
+	Capabilities   *notifier.Capabilities `json:"capabilities,omitempty"`
+	Accounts       []string               `json:"accounts,omitempty"`
+	DefaultAccount string                 `json:"default_account,omitempty"`
+}
+
+// NotificationStatusResponse lists the available channels and notifier health
+type NotificationStatusResponse struct {
+	Available       []NotifierTypeInfo `json:"available"`
+	UsingNotifier   bool               `json:"using_notifier"`
+	NotifierHealthy bool               `json:"notifier_healthy,omitempty"`
+	NotifierStatus  string             `json:"notifier_status,omitempty"`
+	NotifierDetails map[string]string  `json:"notifier_details,omitempty"`
+	SMTPConfigured  bool               `json:"smtp_configured"`
+}
+
+// DeliveryStatus is the live state of a notification sent through the
+// notifier service
+type DeliveryStatus struct {
+	ID           string     `json:"id"`
+	Participant  string     `json:"participant"`
+	Channel      string     `json:"channel,omitempty"`
+	Status       string     `json:"status"`
+	Recipients   []string   `json:"recipients,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
+	SentAt       *time.Time `json:"sent_at,omitempty"`
+	RetryCount   int32      `json:"retry_count"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// NotificationsResponse lists the tracked notifications, newest first
+type NotificationsResponse struct {
+	Success       bool              `json:"success"`
+	Notifications []*DeliveryStatus `json:"notifications,omitempty"`
+	Error         string            `json:"error,omitempty"`
+}
+
+// NotificationActionRequest names the notification to retry or cancel
+type NotificationActionRequest struct {
+	ID string `json:"id"`
+}
+
+// NotificationActionResponse is the result of a retry or cancel
+type NotificationActionResponse struct {
+	Success bool   `json:"success"`
+	ID      string `json:"id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ServiceStats summarizes the notifier service's deliveries
+type ServiceStats struct {
+	TotalSent        int64            `json:"total_sent"`
+	TotalFailed      int64            `json:"total_failed"`
+	TotalPending     int64            `json:"total_pending"`
+	TotalQueued      int64            `json:"total_queued"`
+	ByType           map[string]int64 `json:"by_type,omitempty"`
+	ByStatus         map[string]int64 `json:"by_status,omitempty"`
+	AverageLatencyMs float64          `json:"average_latency_ms"`
+}
+
+// SMTPTestRequest optionally names an address to send a test message to
+type SMTPTestRequest struct {
+	SendTo string `json:"send_to,omitempty"`
+}
+
+// SMTPTestResponse reports each step of an SMTP connection test
+type SMTPTestResponse struct {
+	Success bool                      `json:"success"`
+	Steps   []notifier.ConnectionStep `json:"steps,omitempty"`
+	Error   string                    `json:"error,omitempty"`
+}
+
+// NotifierStatsResponse wraps the notifier service statistics
+type NotifierStatsResponse struct {
+	Success   bool          `json:"success"`
+	Stats     *ServiceStats `json:"stats,omitempty"`
+	FetchedAt *time.Time    `json:"fetched_at,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
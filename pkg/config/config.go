@@ -1,11 +1,11 @@
 package config
 
 import (
-	"encoding/json"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -13,13 +13,19 @@ import (
 var (
 	configInstance *Config
 	once           sync.Once
-	Paths          = []string{getBinaryDir(), ".", "$HOME/.secretsanta", "/etc/secretsanta/"}
+	// loadErr is set when a config file was found but none could be read
+	loadErr error
+	// loadedFrom is the path of the config file read, if any
+	loadedFrom string
+	Paths      = []string{getBinaryDir(), ".", "$HOME/.secretsanta", "/etc/secretsanta/"}
 )
 
 // ResetConfig resets the singleton config instance (useful for testing)
 func ResetConfig() {
 	configInstance = nil
 	once = sync.Once{}
+	loadErr = nil
+	loadedFrom = ""
 	viper.Reset()
 }
 
@@ -33,8 +39,24 @@ func getBinaryDir() string {
 }
 
 type Config struct {
+	// DataDir holds the server's files. Relative store paths, including the
+	// defaults, are resolved against it. Empty means $XDG_DATA_HOME/secretsanta
+	// or ~/.local/share/secretsanta.
+	DataDir   string          `mapstructure:"data_dir"`
 	SMTP      SMTPConfig      `mapstructure:"smtp"`
 	Notifier  NotifierConfig  `mapstructure:"notifier"`
+	Webhook   WebhookConfig   `mapstructure:"webhook"`
+	SMS       SMSConfig       `mapstructure:"sms"`
+	Discord   DiscordConfig   `mapstructure:"discord"`
+	Telegram  TelegramConfig  `mapstructure:"telegram"`
+	Matrix    MatrixConfig    `mapstructure:"matrix"`
+	Scheduler SchedulerConfig `mapstructure:"scheduler"`
+	Auth      AuthConfig      `mapstructure:"auth"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Limits    LimitsConfig    `mapstructure:"limits"`
+	Logging   LoggingConfig   `mapstructure:"logging"`
+	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	Tracing   TracingConfig   `mapstructure:"tracing"`
 }
 
 type SMTPConfig struct {
@@ -48,10 +70,189 @@ type SMTPConfig struct {
 	ContentType string `mapstructure:"content_type"`
 }
 
+type WebhookConfig struct {
+	URL          string        `mapstructure:"url"`
+	Secret       string        `mapstructure:"secret"`
+	BodyTemplate string        `mapstructure:"body_template"`
+	ContentType  string        `mapstructure:"content_type"`
+	MaxRetries   int           `mapstructure:"max_retries"`
+	RetryDelay   time.Duration `mapstructure:"retry_delay"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+}
+
+type SMSConfig struct {
+	Provider   string `mapstructure:"provider"`
+	BaseURL    string `mapstructure:"base_url"`
+	AccountSID string `mapstructure:"account_sid"`
+	AuthToken  string `mapstructure:"auth_token"`
+	FromNumber string `mapstructure:"from_number"`
+}
+
+type DiscordConfig struct {
+	BotToken string `mapstructure:"bot_token"`
+	BaseURL  string `mapstructure:"base_url"`
+}
+
+type TelegramConfig struct {
+	BotToken string `mapstructure:"bot_token"`
+	BaseURL  string `mapstructure:"base_url"`
+}
+
+type MatrixConfig struct {
+	HomeserverURL string `mapstructure:"homeserver_url"`
+	AccessToken   string `mapstructure:"access_token"`
+}
+
+// SchedulerConfig controls delayed delivery when no notifier service is configured
+type SchedulerConfig struct {
+	StorePath   string        `mapstructure:"store_path"`
+	RetryDelay  time.Duration `mapstructure:"retry_delay"`
+	MaxAttempts int           `mapstructure:"max_attempts"`
+}
+
+// ServerConfig hardens the web server's HTTP handling
+type ServerConfig struct {
+	// AllowedOrigins may call the API from other sites' pages, with
+	// cookies. "*" allows any origin without cookies. The server's own
+	// origin is always allowed.
+	AllowedOrigins []string `mapstructure:"allowed_origins"`
+	// MaxBodyBytes limits JSON request bodies, MaxUploadBytes file uploads
+	MaxBodyBytes   int64 `mapstructure:"max_body_bytes"`
+	MaxUploadBytes int64 `mapstructure:"max_upload_bytes"`
+	// HSTSMaxAge is sent in Strict-Transport-Security over HTTPS; zero
+	// disables the header
+	HSTSMaxAge time.Duration `mapstructure:"hsts_max_age"`
+	// Timeouts of the HTTP server. WriteTimeout has to cover a draw, which
+	// sends its notifications before answering.
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout"`
+	ReadTimeout       time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout"`
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout"`
+	// ShutdownTimeout is how long a SIGINT or SIGTERM waits for requests
+	// and notification batches in progress
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	TLS             TLSConfig     `mapstructure:"tls"`
+}
+
+// TLSConfig serves HTTPS from a certificate file or with certificates from
+// an ACME CA such as Let's Encrypt. Both are off when empty.
+type TLSConfig struct {
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// ACMEDomains are the host names to request certificates for. The
+	// server must be reachable on port 443 at each of them.
+	ACMEDomains  []string `mapstructure:"acme_domains"`
+	ACMEEmail    string   `mapstructure:"acme_email"`
+	ACMECacheDir string   `mapstructure:"acme_cache_dir"`
+}
+
+// LoggingConfig controls the web server's structured logs
+type LoggingConfig struct {
+	// Level is debug, info, warn or error
+	Level string `mapstructure:"level"`
+	// Format is text or json
+	Format string `mapstructure:"format"`
+	// LogPII writes participant names and contact addresses in full at the
+	// debug level. Otherwise they are replaced by a short keyed hash.
+	LogPII bool `mapstructure:"log_pii"`
+}
+
+// MetricsConfig controls the web server's Prometheus metrics
+type MetricsConfig struct {
+	// Enabled serves the metrics at /metrics, without authentication. It is
+	// off by default; only turn it on where just the scraper can reach it.
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// TracingConfig controls OpenTelemetry tracing of the web server
+type TracingConfig struct {
+	// Enabled sends spans to an OTLP/gRPC collector
+	Enabled bool `mapstructure:"enabled"`
+	// Endpoint is the collector's host:port. Empty uses
+	// OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4317.
+	Endpoint string `mapstructure:"endpoint"`
+	// Insecure connects to the collector without TLS
+	Insecure bool `mapstructure:"insecure"`
+	// ServiceName is reported as service.name
+	ServiceName string `mapstructure:"service_name"`
+	// SampleRatio is the fraction of new traces recorded. Traces started
+	// by a caller follow the caller's sampling decision.
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// LimitsConfig keeps a public server from being used to send spam. A zero
+// value disables a limit.
+type LimitsConfig struct {
+	// IPRequestsPerMinute and IPBurst throttle draws, uploads and logins
+	// per client IP; the account limits do the same per logged-in user
+	IPRequestsPerMinute      float64 `mapstructure:"ip_requests_per_minute"`
+	IPBurst                  int     `mapstructure:"ip_burst"`
+	AccountRequestsPerMinute float64 `mapstructure:"account_requests_per_minute"`
+	AccountBurst             int     `mapstructure:"account_burst"`
+	// DailyNotifications caps the recipients of assignments and reminders
+	// each organizer can message per UTC day
+	DailyNotifications int `mapstructure:"daily_notifications"`
+	MaxParticipants    int `mapstructure:"max_participants"`
+	// TrustForwardedFor takes the client IP from X-Forwarded-For. Enable it
+	// only behind a proxy that sets the header.
+	TrustForwardedFor bool `mapstructure:"trust_forwarded_for"`
+}
+
+// AuthConfig protects the web server. When it is disabled anyone who can
+// reach the server can draw and send notifications.
+type AuthConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Users are the local accounts
+	Users      []UserConfig  `mapstructure:"users"`
+	SessionTTL time.Duration `mapstructure:"session_ttl"`
+	// SecureCookie restricts the session cookie to HTTPS
+	SecureCookie bool       `mapstructure:"secure_cookie"`
+	OIDC         OIDCConfig `mapstructure:"oidc"`
+}
+
+// UserConfig is a local account. PasswordHash is a bcrypt hash and Role is
+// "organizer" or "viewer" (the default).
+type UserConfig struct {
+	Username     string `mapstructure:"username"`
+	PasswordHash string `mapstructure:"password_hash"`
+	Role         string `mapstructure:"role"`
+}
+
+// OIDCConfig enables login with an OpenID Connect provider when Issuer is
+// set. Users matching OrganizerEmails or OrganizerGroups are organizers;
+// everyone else the provider lets in is a viewer.
+type OIDCConfig struct {
+	Issuer       string   `mapstructure:"issuer"`
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`
+	// GroupsClaim names the ID token claim listing the user's groups
+	GroupsClaim     string   `mapstructure:"groups_claim"`
+	OrganizerEmails []string `mapstructure:"organizer_emails"`
+	OrganizerGroups []string `mapstructure:"organizer_groups"`
+}
+
 type NotifierConfig struct {
-	ServiceAddr string `mapstructure:"service_addr"`
+	ServiceAddr  string `mapstructure:"service_addr"`
 	ArchiveEmail string `mapstructure:"archive_email"`
-	APIKey      string `mapstructure:"api_key"`
+	APIKey       string `mapstructure:"api_key"`
+	// TrackingPath records the notification IDs returned by the service
+	TrackingPath string            `mapstructure:"tracking_path"`
+	TLS          NotifierTLSConfig `mapstructure:"tls"`
+	// KeepaliveTime is how often an idle connection is pinged
+	KeepaliveTime    time.Duration `mapstructure:"keepalive_time"`
+	KeepaliveTimeout time.Duration `mapstructure:"keepalive_timeout"`
+}
+
+// NotifierTLSConfig secures the connection to the notifier service. CertFile
+// and KeyFile enable mutual TLS; CAFile replaces the system roots.
+type NotifierTLSConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	CAFile     string `mapstructure:"ca_file"`
+	CertFile   string `mapstructure:"cert_file"`
+	KeyFile    string `mapstructure:"key_file"`
+	ServerName string `mapstructure:"server_name"`
 }
 
 func GetConfig() *Config {
@@ -61,8 +262,16 @@ func GetConfig() *Config {
 	return configInstance
 }
 
+// LoadError returns the error reading the config file when one was found
+// but couldn't be read, in which case the defaults are in use
+func LoadError() error {
+	GetConfig()
+	return loadErr
+}
+
 func loadConfig() *Config {
 	// Set defaults for all fields (allows running without config file)
+	viper.SetDefault("data_dir", "")
 	viper.SetDefault("smtp.host", "")
 	viper.SetDefault("smtp.port", "")
 	viper.SetDefault("smtp.identity", "")
@@ -74,6 +283,73 @@ func loadConfig() *Config {
 	viper.SetDefault("notifier.service_addr", "")
 	viper.SetDefault("notifier.archive_email", "")
 	viper.SetDefault("notifier.api_key", "")
+	viper.SetDefault("notifier.tracking_path", "secretsanta-notifications.json")
+	viper.SetDefault("notifier.tls.enabled", false)
+	viper.SetDefault("notifier.tls.ca_file", "")
+	viper.SetDefault("notifier.tls.cert_file", "")
+	viper.SetDefault("notifier.tls.key_file", "")
+	viper.SetDefault("notifier.tls.server_name", "")
+	viper.SetDefault("notifier.keepalive_time", "5m")
+	viper.SetDefault("notifier.keepalive_timeout", "20s")
+	viper.SetDefault("webhook.url", "")
+	viper.SetDefault("webhook.secret", "")
+	viper.SetDefault("webhook.body_template", "")
+	viper.SetDefault("webhook.content_type", "application/json")
+	viper.SetDefault("webhook.max_retries", 3)
+	viper.SetDefault("webhook.retry_delay", "2s")
+	viper.SetDefault("webhook.timeout", "10s")
+	viper.SetDefault("sms.provider", "twilio")
+	viper.SetDefault("sms.base_url", "")
+	viper.SetDefault("sms.account_sid", "")
+	viper.SetDefault("sms.auth_token", "")
+	viper.SetDefault("sms.from_number", "")
+	viper.SetDefault("discord.bot_token", "")
+	viper.SetDefault("discord.base_url", "")
+	viper.SetDefault("telegram.bot_token", "")
+	viper.SetDefault("telegram.base_url", "")
+	viper.SetDefault("matrix.homeserver_url", "")
+	viper.SetDefault("matrix.access_token", "")
+	viper.SetDefault("scheduler.store_path", "secretsanta-scheduled.json")
+	viper.SetDefault("scheduler.retry_delay", "1m")
+	viper.SetDefault("scheduler.max_attempts", 5)
+	viper.SetDefault("server.allowed_origins", []string{})
+	viper.SetDefault("server.max_body_bytes", 1<<20)
+	viper.SetDefault("server.max_upload_bytes", 10<<20)
+	viper.SetDefault("server.hsts_max_age", "8760h")
+	viper.SetDefault("server.read_header_timeout", "10s")
+	viper.SetDefault("server.read_timeout", "1m")
+	viper.SetDefault("server.write_timeout", "5m")
+	viper.SetDefault("server.idle_timeout", "2m")
+	viper.SetDefault("server.shutdown_timeout", "30s")
+	viper.SetDefault("server.tls.cert_file", "")
+	viper.SetDefault("server.tls.key_file", "")
+	viper.SetDefault("server.tls.acme_domains", []string{})
+	viper.SetDefault("server.tls.acme_email", "")
+	viper.SetDefault("server.tls.acme_cache_dir", "secretsanta-acme")
+	viper.SetDefault("limits.ip_requests_per_minute", 30)
+	viper.SetDefault("limits.ip_burst", 10)
+	viper.SetDefault("limits.account_requests_per_minute", 20)
+	viper.SetDefault("limits.account_burst", 10)
+	viper.SetDefault("limits.daily_notifications", 1000)
+	viper.SetDefault("limits.max_participants", 1000)
+	viper.SetDefault("limits.trust_forwarded_for", false)
+	viper.SetDefault("logging.level", "info")
+	viper.SetDefault("logging.format", "text")
+	viper.SetDefault("logging.log_pii", false)
+	viper.SetDefault("metrics.enabled", false)
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.insecure", false)
+	viper.SetDefault("tracing.service_name", "secretsanta-web")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+	viper.SetDefault("auth.enabled", false)
+	viper.SetDefault("auth.session_ttl", "12h")
+	viper.SetDefault("auth.secure_cookie", false)
+	viper.SetDefault("auth.oidc.issuer", "")
+	viper.SetDefault("auth.oidc.client_id", "")
+	viper.SetDefault("auth.oidc.client_secret", "")
+	viper.SetDefault("auth.oidc.redirect_url", "")
+	viper.SetDefault("auth.oidc.scopes", []string{"openid", "email", "profile"})
+	viper.SetDefault("auth.oidc.groups_claim", "groups")
 
 	viper.AutomaticEnv()
 
@@ -117,26 +393,56 @@ func loadConfig() *Config {
 	}
 
 	if configFound {
-		log.Printf("Loaded config from: %s", configPath)
+		slog.Info("Loaded config", "path", configPath)
+		loadedFrom = configPath
 	} else {
 		if configErr != nil {
-			log.Printf("Error reading config file: %v", configErr)
+			slog.Error("Error reading config file", "error", configErr)
+			loadErr = configErr
 		}
-		log.Println("No config file found, using defaults (stdout notifications only)")
+		slog.Info("No config file found, using defaults (stdout notifications only)")
 	}
 
 	config := &Config{}
 	err := viper.Unmarshal(config)
 	if err != nil {
-		log.Fatalf("unable to decode into struct: %v", err)
+		slog.Error("Unable to decode config", "error", err)
+		os.Exit(1)
 	}
 
-	// Log the loaded configuration
-	logConfig(config, configPath)
+	if config.DataDir == "" {
+		config.DataDir = defaultDataDir()
+	}
+	config.Notifier.TrackingPath = config.DataPath(config.Notifier.TrackingPath)
+	config.Scheduler.StorePath = config.DataPath(config.Scheduler.StorePath)
+	config.Server.TLS.ACMECacheDir = config.DataPath(config.Server.TLS.ACMECacheDir)
 
 	return config
 }
 
+// defaultDataDir is $XDG_DATA_HOME/secretsanta, or ~/.local/share/secretsanta
+// when XDG_DATA_HOME isn't set, or the working directory without a home
+func defaultDataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "secretsanta")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(home, ".local", "share", "secretsanta")
+}
+
+// DataPath resolves a relative path against DataDir, so files don't land in
+// whatever directory the server was started from. Empty and absolute paths
+// are returned unchanged.
+func (c *Config) DataPath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.DataDir, path)
+}
+
 // redact returns a redacted version of the string for logging
 func redact(s string) string {
 	if s == "" {
@@ -145,33 +451,144 @@ func redact(s string) string {
 	return "***REDACTED***"
 }
 
-// logConfig logs the configuration in a structured format with sensitive fields redacted
-func logConfig(cfg *Config, configFile string) {
-	// Create a redacted version of the config for logging
-	redactedConfig := map[string]interface{}{
-		"config_file": configFile,
+// authUsernames lists the local accounts without their password hashes
+func authUsernames(users []UserConfig) []string {
+	names := make([]string, len(users))
+	for i, user := range users {
+		names[i] = user.Username
+	}
+	return names
+}
+
+// Redacted returns the configuration for logging, with secrets replaced and
+// personal data such as usernames and addresses passed through personal
+func Redacted(cfg *Config, personal func(string) string) map[string]interface{} {
+	personalList := func(values []string) []string {
+		redacted := make([]string, len(values))
+		for i, value := range values {
+			redacted[i] = personal(value)
+		}
+		return redacted
+	}
+
+	return map[string]interface{}{
+		"config_file": loadedFrom,
+		"data_dir":    cfg.DataDir,
 		"smtp": map[string]interface{}{
 			"host":         cfg.SMTP.Host,
 			"port":         cfg.SMTP.Port,
-			"username":     cfg.SMTP.Username,
+			"username":     personal(cfg.SMTP.Username),
 			"password":     redact(cfg.SMTP.Password),
-			"from_address": cfg.SMTP.FromAddress,
+			"from_address": personal(cfg.SMTP.FromAddress),
 			"from_name":    cfg.SMTP.FromName,
 			"identity":     cfg.SMTP.Identity,
 			"content_type": cfg.SMTP.ContentType,
 		},
 		"notifier": map[string]interface{}{
 			"service_addr":  cfg.Notifier.ServiceAddr,
-			"archive_email": cfg.Notifier.ArchiveEmail,
+			"archive_email": personal(cfg.Notifier.ArchiveEmail),
 			"api_key":       redact(cfg.Notifier.APIKey),
+			"tracking_path": cfg.Notifier.TrackingPath,
+			"tls": map[string]interface{}{
+				"enabled":     cfg.Notifier.TLS.Enabled,
+				"ca_file":     cfg.Notifier.TLS.CAFile,
+				"cert_file":   cfg.Notifier.TLS.CertFile,
+				"key_file":    cfg.Notifier.TLS.KeyFile,
+				"server_name": cfg.Notifier.TLS.ServerName,
+			},
+			"keepalive_time":    cfg.Notifier.KeepaliveTime.String(),
+			"keepalive_timeout": cfg.Notifier.KeepaliveTimeout.String(),
+		},
+		"webhook": map[string]interface{}{
+			"url":           cfg.Webhook.URL,
+			"secret":        redact(cfg.Webhook.Secret),
+			"body_template": cfg.Webhook.BodyTemplate != "",
+			"content_type":  cfg.Webhook.ContentType,
+			"max_retries":   cfg.Webhook.MaxRetries,
+			"retry_delay":   cfg.Webhook.RetryDelay.String(),
+			"timeout":       cfg.Webhook.Timeout.String(),
+		},
+		"sms": map[string]interface{}{
+			"provider":    cfg.SMS.Provider,
+			"base_url":    cfg.SMS.BaseURL,
+			"account_sid": cfg.SMS.AccountSID,
+			"auth_token":  redact(cfg.SMS.AuthToken),
+			"from_number": personal(cfg.SMS.FromNumber),
+		},
+		"discord": map[string]interface{}{
+			"bot_token": redact(cfg.Discord.BotToken),
+			"base_url":  cfg.Discord.BaseURL,
+		},
+		"telegram": map[string]interface{}{
+			"bot_token": redact(cfg.Telegram.BotToken),
+			"base_url":  cfg.Telegram.BaseURL,
+		},
+		"matrix": map[string]interface{}{
+			"homeserver_url": cfg.Matrix.HomeserverURL,
+			"access_token":   redact(cfg.Matrix.AccessToken),
+		},
+		"scheduler": map[string]interface{}{
+			"store_path":   cfg.Scheduler.StorePath,
+			"retry_delay":  cfg.Scheduler.RetryDelay.String(),
+			"max_attempts": cfg.Scheduler.MaxAttempts,
+		},
+		"server": map[string]interface{}{
+			"allowed_origins":     cfg.Server.AllowedOrigins,
+			"max_body_bytes":      cfg.Server.MaxBodyBytes,
+			"max_upload_bytes":    cfg.Server.MaxUploadBytes,
+			"hsts_max_age":        cfg.Server.HSTSMaxAge.String(),
+			"read_header_timeout": cfg.Server.ReadHeaderTimeout.String(),
+			"read_timeout":        cfg.Server.ReadTimeout.String(),
+			"write_timeout":       cfg.Server.WriteTimeout.String(),
+			"idle_timeout":        cfg.Server.IdleTimeout.String(),
+			"shutdown_timeout":    cfg.Server.ShutdownTimeout.String(),
+			"tls": map[string]interface{}{
+				"cert_file":      cfg.Server.TLS.CertFile,
+				"key_file":       cfg.Server.TLS.KeyFile,
+				"acme_domains":   cfg.Server.TLS.ACMEDomains,
+				"acme_email":     personal(cfg.Server.TLS.ACMEEmail),
+				"acme_cache_dir": cfg.Server.TLS.ACMECacheDir,
+			},
+		},
+		"limits": map[string]interface{}{
+			"ip_requests_per_minute":      cfg.Limits.IPRequestsPerMinute,
+			"ip_burst":                    cfg.Limits.IPBurst,
+			"account_requests_per_minute": cfg.Limits.AccountRequestsPerMinute,
+			"account_burst":               cfg.Limits.AccountBurst,
+			"daily_notifications":         cfg.Limits.DailyNotifications,
+			"max_participants":            cfg.Limits.MaxParticipants,
+			"trust_forwarded_for":         cfg.Limits.TrustForwardedFor,
+		},
+		"logging": map[string]interface{}{
+			"level":   cfg.Logging.Level,
+			"format":  cfg.Logging.Format,
+			"log_pii": cfg.Logging.LogPII,
+		},
+		"metrics": map[string]interface{}{
+			"enabled": cfg.Metrics.Enabled,
+		},
+		"tracing": map[string]interface{}{
+			"enabled":      cfg.Tracing.Enabled,
+			"endpoint":     cfg.Tracing.Endpoint,
+			"insecure":     cfg.Tracing.Insecure,
+			"service_name": cfg.Tracing.ServiceName,
+			"sample_ratio": cfg.Tracing.SampleRatio,
+		},
+		"auth": map[string]interface{}{
+			"enabled":       cfg.Auth.Enabled,
+			"users":         personalList(authUsernames(cfg.Auth.Users)),
+			"session_ttl":   cfg.Auth.SessionTTL.String(),
+			"secure_cookie": cfg.Auth.SecureCookie,
+			"oidc": map[string]interface{}{
+				"issuer":           cfg.Auth.OIDC.Issuer,
+				"client_id":        cfg.Auth.OIDC.ClientID,
+				"client_secret":    redact(cfg.Auth.OIDC.ClientSecret),
+				"redirect_url":     cfg.Auth.OIDC.RedirectURL,
+				"scopes":           cfg.Auth.OIDC.Scopes,
+				"groups_claim":     cfg.Auth.OIDC.GroupsClaim,
+				"organizer_emails": personalList(cfg.Auth.OIDC.OrganizerEmails),
+				"organizer_groups": cfg.Auth.OIDC.OrganizerGroups,
+			},
 		},
 	}
-
-	jsonBytes, err := json.MarshalIndent(redactedConfig, "", "  ")
-	if err != nil {
-		log.Printf("Failed to marshal config for logging: %v", err)
-		return
-	}
-
-	log.Printf("Configuration:\n%s", string(jsonBytes))
 }
@@ -89,5 +89,47 @@ var _ = Describe("Config", func() {
 				Expect(testConfig).To(BeIdenticalTo(testConfig3))
 			})
 		})
+
+		Describe("LoadError", func() {
+			It("should be nil when the config file was read", func() {
+				Expect(config.LoadError()).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Context("with data_dir set", func() {
+		BeforeEach(func() {
+			contents := "data_dir = \"/srv/santa\"\n[scheduler]\nstore_path = \"/var/lib/scheduled.json\"\n"
+			Expect(os.WriteFile(filepath.Join(tempDir, "secretsanta.config"), []byte(contents), 0o644)).To(Succeed())
+			config.ResetConfig()
+		})
+
+		It("should resolve relative store paths against it", func() {
+			cfg := config.GetConfig()
+			Expect(cfg.Notifier.TrackingPath).To(Equal("/srv/santa/secretsanta-notifications.json"))
+			Expect(cfg.Scheduler.StorePath).To(Equal("/var/lib/scheduled.json"))
+			Expect(cfg.DataPath("")).To(BeEmpty())
+		})
+	})
+
+	Context("without data_dir", func() {
+		It("should use the XDG data directory", func() {
+			GinkgoT().Setenv("XDG_DATA_HOME", tempDir)
+			Expect(config.GetConfig().Scheduler.StorePath).To(Equal(filepath.Join(tempDir, "secretsanta", "secretsanta-scheduled.json")))
+		})
+	})
+
+	Context("using an unreadable config", func() {
+		BeforeEach(func() {
+			Expect(os.WriteFile(filepath.Join(tempDir, "secretsanta.config"), []byte("[smtp\nhost ="), 0o644)).To(Succeed())
+			config.ResetConfig()
+		})
+
+		Describe("LoadError", func() {
+			It("should report the parse error and fall back to defaults", func() {
+				Expect(config.LoadError()).To(HaveOccurred())
+				Expect(config.GetConfig().SMTP.FromName).To(Equal("Secret Santa"))
+			})
+		})
 	})
 })
@@ -0,0 +1,64 @@
+package event
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event describes the gift exchange a draw is for
+type Event struct {
+	Name         string    `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty"`
+	ExchangeDate time.Time `json:"exchange_date" yaml:"exchange_date" toml:"exchange_date"`
+	// Budget is free text such as "$25" or "about 20 EUR"
+	Budget    string         `json:"budget,omitempty" yaml:"budget,omitempty" toml:"budget,omitempty"`
+	Reminders []ReminderRule `json:"reminders,omitempty" yaml:"reminders,omitempty" toml:"reminders,omitempty"`
+}
+
+// ReminderRule sends a reminder to every giver a number of days before the exchange
+type ReminderRule struct {
+	DaysBefore int `json:"days_before" yaml:"days_before" toml:"days_before"`
+	// Subject and Template are Go text/templates over ReminderData.
+	// The defaults are used when they are empty.
+	Subject  string `json:"subject,omitempty" yaml:"subject,omitempty" toml:"subject,omitempty"`
+	Template string `json:"template,omitempty" yaml:"template,omitempty" toml:"template,omitempty"`
+}
+
+// DefaultReminders are "one week left" and "exchange is tomorrow" nudges
+func DefaultReminders() []ReminderRule {
+	return []ReminderRule{{DaysBefore: 7}, {DaysBefore: 1}}
+}
+
+// DisplayName returns the event name, or a generic one when it is unset
+func (e *Event) DisplayName() string {
+	if e.Name == "" {
+		return "Secret Santa"
+	}
+	return e.Name
+}
+
+// SendAt returns when the reminder for rule is due
+func (e *Event) SendAt(rule ReminderRule) time.Time {
+	return e.ExchangeDate.AddDate(0, 0, -rule.DaysBefore)
+}
+
+// Validate checks the exchange date and reminder rules, including that
+// their templates parse and render within MaxReminderSize
+func (e *Event) Validate() error {
+	if e.ExchangeDate.IsZero() {
+		return fmt.Errorf("event exchange_date is required")
+	}
+	seen := make(map[int]bool)
+	for _, rule := range e.Reminders {
+		if rule.DaysBefore < 1 {
+			return fmt.Errorf("reminder days_before must be at least 1, got %d", rule.DaysBefore)
+		}
+		if seen[rule.DaysBefore] {
+			return fmt.Errorf("duplicate reminder %d days before the exchange", rule.DaysBefore)
+		}
+		seen[rule.DaysBefore] = true
+		if _, err := e.RenderReminder(rule, "Giver", "Recipient"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
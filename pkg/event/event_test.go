@@ -0,0 +1,90 @@
+package event_test
+
+import (
+	"github.com/igodwin/secretsanta/pkg/event"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"strings"
+	"time"
+)
+
+var _ = Describe("Event", func() {
+	var testEvent *event.Event
+
+	BeforeEach(func() {
+		testEvent = &event.Event{
+			Name:         "Office Party",
+			ExchangeDate: time.Date(2026, 12, 18, 17, 0, 0, 0, time.UTC),
+			Budget:       "$25",
+			Reminders:    event.DefaultReminders(),
+		}
+	})
+
+	Context("SendAt", func() {
+		It("counts back whole days from the exchange", func() {
+			Expect(testEvent.SendAt(event.ReminderRule{DaysBefore: 7})).To(Equal(time.Date(2026, 12, 11, 17, 0, 0, 0, time.UTC)))
+		})
+	})
+
+	Context("Validate", func() {
+		It("accepts the default reminders", func() {
+			Expect(testEvent.Validate()).To(Succeed())
+		})
+
+		It("requires an exchange date", func() {
+			testEvent.ExchangeDate = time.Time{}
+			Expect(testEvent.Validate()).To(MatchError(ContainSubstring("exchange_date")))
+		})
+
+		It("rejects reminders on or after the exchange", func() {
+			testEvent.Reminders = []event.ReminderRule{{DaysBefore: 0}}
+			Expect(testEvent.Validate()).NotTo(Succeed())
+		})
+
+		It("rejects duplicate reminders", func() {
+			testEvent.Reminders = []event.ReminderRule{{DaysBefore: 3}, {DaysBefore: 3}}
+			Expect(testEvent.Validate()).NotTo(Succeed())
+		})
+
+		It("rejects templates that do not parse", func() {
+			testEvent.Reminders = []event.ReminderRule{{DaysBefore: 3, Template: "{{.Giver"}}
+			Expect(testEvent.Validate()).To(MatchError(ContainSubstring("invalid reminder template")))
+		})
+
+		It("rejects templates whose output is too long", func() {
+			testEvent.Reminders = []event.ReminderRule{{DaysBefore: 3, Template: `{{printf "%99999d" 1}}{{printf "%99999d" 1}}`}}
+			Expect(testEvent.Validate()).To(MatchError(ContainSubstring("longer than")))
+		})
+	})
+
+	Context("RenderReminder", func() {
+		It("stops rendering at the size limit", func() {
+			rule := event.ReminderRule{DaysBefore: 7, Template: `{{.Giver}}`}
+			_, err := testEvent.RenderReminder(rule, strings.Repeat("x", event.MaxReminderSize+1), "Bob")
+			Expect(err).To(MatchError(ContainSubstring("longer than")))
+		})
+
+		It("includes the recipient, budget and date", func() {
+			reminder, err := testEvent.RenderReminder(event.ReminderRule{DaysBefore: 7}, "Alice", "Bob")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reminder.Subject).To(Equal("Office Party: in one week"))
+			Expect(reminder.Body).To(Equal("Hi Alice! Papa Elf here: the Office Party gift exchange is in one week (Fri, Dec 18). You're getting a gift for Bob, budget $25."))
+		})
+
+		It("says tomorrow the day before and omits an unset budget", func() {
+			testEvent.Budget = ""
+			reminder, err := testEvent.RenderReminder(event.ReminderRule{DaysBefore: 1}, "Alice", "Bob")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reminder.Body).To(ContainSubstring("is tomorrow"))
+			Expect(reminder.Body).To(HaveSuffix("a gift for Bob."))
+		})
+
+		It("uses custom templates", func() {
+			rule := event.ReminderRule{DaysBefore: 3, Subject: "{{.DaysLeft}} days", Template: "{{.Giver}} -> {{.Recipient}} {{.When}}"}
+			reminder, err := testEvent.RenderReminder(rule, "Alice", "Bob")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reminder.Subject).To(Equal("3 days"))
+			Expect(reminder.Body).To(Equal("Alice -> Bob in 3 days"))
+		})
+	})
+})
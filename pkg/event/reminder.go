@@ -0,0 +1,130 @@
+package event
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// MaxReminderSize caps a rendered reminder subject or body in bytes, so an
+// organizer's template can't make the server build huge messages
+const MaxReminderSize = 16 << 10
+
+var errReminderTooLarge = fmt.Errorf("reminder is longer than %d bytes", MaxReminderSize)
+
+const (
+	defaultReminderSubject  = `{{.EventName}}: {{.When}}`
+	defaultReminderTemplate = `Hi {{.Giver}}! Papa Elf here: the {{.EventName}} gift exchange is {{.When}} ({{.Date}}). You're getting a gift for {{.Recipient}}{{if .Budget}}, budget {{.Budget}}{{end}}.`
+)
+
+// ReminderData is passed to reminder subject and body templates
+type ReminderData struct {
+	Giver        string
+	Recipient    string
+	EventName    string
+	Budget       string
+	ExchangeDate time.Time
+	// Date is the exchange date formatted for people, e.g. "Fri, Dec 19"
+	Date string
+	// When is a phrase such as "tomorrow" or "in one week"
+	When     string
+	DaysLeft int
+}
+
+// Reminder is a rendered reminder for one giver
+type Reminder struct {
+	Subject string
+	Body    string
+}
+
+// RenderReminder renders rule's reminder for a giver and their recipient
+func (e *Event) RenderReminder(rule ReminderRule, giver, recipient string) (Reminder, error) {
+	subjectTmpl, bodyTmpl, err := rule.templates()
+	if err != nil {
+		return Reminder{}, err
+	}
+
+	data := ReminderData{
+		Giver:        giver,
+		Recipient:    recipient,
+		EventName:    e.DisplayName(),
+		Budget:       e.Budget,
+		ExchangeDate: e.ExchangeDate,
+		Date:         e.ExchangeDate.Format("Mon, Jan 2"),
+		When:         rule.When(),
+		DaysLeft:     rule.DaysBefore,
+	}
+
+	subject, body := &cappedBuffer{limit: MaxReminderSize}, &cappedBuffer{limit: MaxReminderSize}
+	if err := subjectTmpl.Execute(subject, data); err != nil {
+		return Reminder{}, fmt.Errorf("failed to render reminder subject: %w", renderError(err))
+	}
+	if err := bodyTmpl.Execute(body, data); err != nil {
+		return Reminder{}, fmt.Errorf("failed to render reminder: %w", renderError(err))
+	}
+	return Reminder{Subject: subject.String(), Body: body.String()}, nil
+}
+
+// renderError reports a template that went over MaxReminderSize as such,
+// rather than as the write error the template package wraps it in
+func renderError(err error) error {
+	if errors.Is(err, errReminderTooLarge) {
+		return errReminderTooLarge
+	}
+	return err
+}
+
+// cappedBuffer collects template output, refusing writes past limit bytes
+type cappedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.buf.Len()+len(p) > b.limit {
+		return 0, errReminderTooLarge
+	}
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
+
+func (r ReminderRule) templates() (*template.Template, *template.Template, error) {
+	subject, body := r.Subject, r.Template
+	if subject == "" {
+		subject = defaultReminderSubject
+	}
+	if body == "" {
+		body = defaultReminderTemplate
+	}
+
+	subjectTmpl, err := template.New("subject").Parse(subject)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid reminder subject template: %w", err)
+	}
+	bodyTmpl, err := template.New("reminder").Parse(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid reminder template: %w", err)
+	}
+	return subjectTmpl, bodyTmpl, nil
+}
+
+// When describes how far away the exchange is when the reminder is sent,
+// e.g. "tomorrow" or "in one week"
+func (r ReminderRule) When() string {
+	daysBefore := r.DaysBefore
+	switch {
+	case daysBefore == 1:
+		return "tomorrow"
+	case daysBefore == 7:
+		return "in one week"
+	case daysBefore%7 == 0:
+		return fmt.Sprintf("in %d weeks", daysBefore/7)
+	default:
+		return fmt.Sprintf("in %d days", daysBefore)
+	}
+}
@@ -0,0 +1,167 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+type recordedRequest struct {
+	Method string
+	Path   string
+	Auth   string
+	Body   map[string]interface{}
+}
+
+// newRecordingServer returns a server that records each request and replies
+// with the response registered for its path suffix, or an empty JSON object
+func newRecordingServer(requests *[]recordedRequest, responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := recordedRequest{Method: r.Method, Path: r.URL.EscapedPath(), Auth: r.Header.Get("Authorization")}
+		_ = json.NewDecoder(r.Body).Decode(&req.Body)
+		*requests = append(*requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		for suffix, body := range responses {
+			if strings.HasSuffix(r.URL.Path, suffix) {
+				_, _ = w.Write([]byte(body))
+				return
+			}
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+}
+
+var _ = Describe("Chat Notifiers", func() {
+	var (
+		server          *httptest.Server
+		requests        []recordedRequest
+		testParticipant *participant.Participant
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		testParticipant = &participant.Participant{
+			Name:      "Test",
+			Recipient: &participant.Participant{Name: "TestRecipient"},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Discord", func() {
+		BeforeEach(func() {
+			server = newRecordingServer(&requests, map[string]string{"/users/@me/channels": `{"id":"42"}`})
+		})
+
+		It("should post to the participant's webhook", func() {
+			discord := &notifier.DiscordNotifier{}
+			testParticipant.ContactInfo = []string{server.URL + "/webhooks/1/abc"}
+			Expect(discord.SendNotification(testParticipant)).To(Succeed())
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Path).To(Equal("/webhooks/1/abc"))
+			Expect(requests[0].Body["content"]).To(Equal(notifier.ChatBody("Test", "TestRecipient")))
+		})
+
+		It("should fail rather than post anywhere when the participant has no contact info", func() {
+			discord := &notifier.DiscordNotifier{BotToken: "bot-token", BaseURL: server.URL}
+			Expect(discord.SendNotification(testParticipant)).To(MatchError(ContainSubstring("no discord webhook or user id")))
+			Expect(requests).To(BeEmpty())
+		})
+
+		It("should open a DM channel and message a user as the bot", func() {
+			discord := &notifier.DiscordNotifier{BotToken: "bot-token", BaseURL: server.URL}
+			testParticipant.ContactInfo = []string{"1234567890"}
+			Expect(discord.SendNotification(testParticipant)).To(Succeed())
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[0].Path).To(Equal("/users/@me/channels"))
+			Expect(requests[0].Auth).To(Equal("Bot bot-token"))
+			Expect(requests[0].Body["recipient_id"]).To(Equal("1234567890"))
+			Expect(requests[1].Path).To(Equal("/channels/42/messages"))
+		})
+
+		It("should require a bot token to message a user", func() {
+			discord := &notifier.DiscordNotifier{BaseURL: server.URL}
+			testParticipant.ContactInfo = []string{"1234567890"}
+			Expect(discord.SendNotification(testParticipant)).To(MatchError(ContainSubstring("bot token")))
+		})
+
+	})
+
+	Describe("Telegram", func() {
+		BeforeEach(func() {
+			server = newRecordingServer(&requests, map[string]string{"/sendMessage": `{"ok":true}`})
+		})
+
+		It("should call sendMessage for each chat id", func() {
+			telegram := &notifier.TelegramNotifier{BotToken: "123:abc", BaseURL: server.URL}
+			testParticipant.ContactInfo = []string{"1001", "1002"}
+			Expect(telegram.SendNotification(testParticipant)).To(Succeed())
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[0].Path).To(Equal("/bot123:abc/sendMessage"))
+			Expect(requests[0].Body["chat_id"]).To(Equal("1001"))
+			Expect(requests[1].Body["chat_id"]).To(Equal("1002"))
+		})
+
+		It("should surface Bot API errors", func() {
+			server.Close()
+			server = newRecordingServer(&requests, map[string]string{"/sendMessage": `{"ok":false,"description":"chat not found"}`})
+			telegram := &notifier.TelegramNotifier{BotToken: "123:abc", BaseURL: server.URL}
+			testParticipant.ContactInfo = []string{"1001"}
+			Expect(telegram.SendNotification(testParticipant)).To(MatchError(ContainSubstring("chat not found")))
+		})
+
+		It("should keep the bot token out of transport errors", func() {
+			server.Close()
+			telegram := &notifier.TelegramNotifier{BotToken: "123:secret", BaseURL: server.URL}
+			testParticipant.ContactInfo = []string{"1001"}
+			err := telegram.SendNotification(testParticipant)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).NotTo(ContainSubstring("123:secret"))
+		})
+
+		It("should error when not configured", func() {
+			Expect((&notifier.TelegramNotifier{}).IsConfigured()).To(MatchError("telegram is not configured"))
+		})
+	})
+
+	Describe("Matrix", func() {
+		var matrix *notifier.MatrixNotifier
+
+		BeforeEach(func() {
+			server = newRecordingServer(&requests, map[string]string{"/createRoom": `{"room_id":"!dm:example.org"}`})
+			matrix = &notifier.MatrixNotifier{HomeserverURL: server.URL, AccessToken: "syt_token"}
+		})
+
+		It("should send to a room id directly", func() {
+			testParticipant.ContactInfo = []string{"!room:example.org"}
+			Expect(matrix.SendNotification(testParticipant)).To(Succeed())
+			Expect(requests).To(HaveLen(1))
+			Expect(requests[0].Method).To(Equal(http.MethodPut))
+			Expect(requests[0].Path).To(HavePrefix("/_matrix/client/v3/rooms/%21room:example.org/send/m.room.message/"))
+			Expect(requests[0].Auth).To(Equal("Bearer syt_token"))
+			Expect(requests[0].Body["msgtype"]).To(Equal("m.text"))
+		})
+
+		It("should create a direct chat for a user id", func() {
+			testParticipant.ContactInfo = []string{"@test:example.org"}
+			Expect(matrix.SendNotification(testParticipant)).To(Succeed())
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[0].Path).To(Equal("/_matrix/client/v3/createRoom"))
+			Expect(requests[0].Body["invite"]).To(ConsistOf("@test:example.org"))
+			Expect(requests[1].Path).To(HavePrefix("/_matrix/client/v3/rooms/%21dm:example.org/send/"))
+		})
+
+		It("should require both homeserver and token", func() {
+			Expect((&notifier.MatrixNotifier{}).IsConfigured()).To(MatchError("matrix is not configured"))
+			Expect((&notifier.MatrixNotifier{HomeserverURL: server.URL}).IsConfigured()).To(HaveOccurred())
+		})
+	})
+})
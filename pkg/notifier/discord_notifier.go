@@ -0,0 +1,115 @@
+package notifier
+
+import (
+	"fmt"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	"net/http"
+	"strings"
+)
+
+const defaultDiscordBaseURL = "https://discord.com/api/v10"
+
+// DiscordNotifier posts assignments to the participant's own Discord webhook,
+// or sends them as a direct message from a bot when the participant's contact
+// info is a user ID. There is no shared default destination: an assignment
+// posted to a channel everyone can read would no longer be secret.
+type DiscordNotifier struct {
+	BotToken   string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func init() {
+	Register(Channel{
+		Name:        "discord",
+		Description: "Discord webhook or bot direct message",
+		ConfigSchema: []ConfigField{
+			{Key: "discord.bot_token", Secret: true, Description: "Bot token for direct messages"},
+			{Key: "discord.base_url", Description: "Override for the Discord API"},
+		},
+		Capabilities: Capabilities{LongForm: true},
+		New: func(cfg *config.Config) (Notifier, error) {
+			return &DiscordNotifier{
+				BotToken: cfg.Discord.BotToken,
+				BaseURL:  cfg.Discord.BaseURL,
+			}, nil
+		},
+	})
+}
+
+func (d *DiscordNotifier) SendNotification(participant *participant.Participant) error {
+	return d.SendMessage(participant, "", ChatBody(participant.Name, participant.Recipient.Name))
+}
+
+func (d *DiscordNotifier) SendMessage(participant *participant.Participant, subject, content string) error {
+	d.HTTPClient = defaultHTTPClient(d.HTTPClient)
+
+	targets := nonEmpty(participant.ContactInfo)
+	if len(targets) == 0 {
+		return fmt.Errorf("no discord webhook or user id for %s", participant.Name)
+	}
+
+	for _, target := range targets {
+		var err error
+		if strings.HasPrefix(target, "https://") || strings.HasPrefix(target, "http://") {
+			err = d.sendWebhook(target, content)
+		} else {
+			err = d.sendDirectMessage(target, content)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to send discord message to %s: %w", participant.Name, err)
+		}
+	}
+	return nil
+}
+
+// Preview renders the Discord message without sending it
+func (d *DiscordNotifier) Preview(participant *participant.Participant) (*Message, error) {
+	targets := nonEmpty(participant.ContactInfo)
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no discord webhook or user id for %s", participant.Name)
+	}
+	return &Message{
+		Channel:     "discord",
+		Recipients:  targets,
+		Body:        ChatBody(participant.Name, participant.Recipient.Name),
+		ContentType: "text/plain",
+	}, nil
+}
+
+// IsConfigured always succeeds: webhook contacts need no settings, and a
+// user ID contact without a bot token fails when it is sent
+func (d *DiscordNotifier) IsConfigured() error {
+	return nil
+}
+
+func (d *DiscordNotifier) sendWebhook(webhookURL, content string) error {
+	return doJSON(d.HTTPClient, http.MethodPost, webhookURL, nil, map[string]string{"content": content}, nil)
+}
+
+// sendDirectMessage opens (or reuses) a DM channel with userID and posts content to it
+func (d *DiscordNotifier) sendDirectMessage(userID, content string) error {
+	if d.BotToken == "" {
+		return fmt.Errorf("discord bot token is required to message user %s", userID)
+	}
+
+	baseURL := d.BaseURL
+	if baseURL == "" {
+		baseURL = defaultDiscordBaseURL
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	headers := map[string]string{"Authorization": "Bot " + d.BotToken}
+
+	var channel struct {
+		ID string `json:"id"`
+	}
+	err := doJSON(d.HTTPClient, http.MethodPost, baseURL+"/users/@me/channels", headers,
+		map[string]string{"recipient_id": strings.TrimPrefix(userID, "@")}, &channel)
+	if err != nil {
+		return fmt.Errorf("failed to open DM channel: %w", err)
+	}
+
+	return doJSON(d.HTTPClient, http.MethodPost, fmt.Sprintf("%s/channels/%s/messages", baseURL, channel.ID), headers,
+		map[string]string{"content": content}, nil)
+}
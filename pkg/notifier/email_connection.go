@@ -0,0 +1,146 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// Steps of an SMTP connection test, in order
+const (
+	StepDial = "dial"
+	StepTLS  = "tls"
+	StepAuth = "auth"
+	StepSend = "send"
+)
+
+// smtpTestTimeout bounds a whole SMTP connection test
+const smtpTestTimeout = 30 * time.Second
+
+// ConnectionStep is the result of one step of an SMTP connection test.
+// Steps after a failed one are skipped.
+type ConnectionStep struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Skipped bool   `json:"skipped,omitempty"`
+	// Detail describes what happened, such as the TLS version or the error
+	Detail string `json:"detail,omitempty"`
+}
+
+// ConnectionSucceeded reports whether no step of a connection test failed
+func ConnectionSucceeded(steps []ConnectionStep) bool {
+	for _, step := range steps {
+		if !step.OK && !step.Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// TestConnection checks the SMTP settings: it connects, upgrades to TLS with
+// STARTTLS when the server offers it and authenticates when a username is
+// set. TLS is only required when credentials will be sent, except to
+// localhost. When sendTo is set it also sends a test message there. Unlike
+// IsConfigured it talks to the server, so bad credentials show up before a
+// draw.
+func (e *EmailNotifier) TestConnection(ctx context.Context, sendTo string) []ConnectionStep {
+	steps := []ConnectionStep{{Name: StepDial}, {Name: StepTLS}, {Name: StepAuth}, {Name: StepSend}}
+	// fail records err on the step and skips the ones after it
+	fail := func(i int, err error) []ConnectionStep {
+		steps[i].Detail = err.Error()
+		for j := i + 1; j < len(steps); j++ {
+			steps[j] = ConnectionStep{Name: steps[j].Name, Skipped: true, Detail: "skipped after " + steps[i].Name + " failed"}
+		}
+		return steps
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTestTimeout)
+	defer cancel()
+	addr := net.JoinHostPort(e.Host, e.Port)
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fail(0, err)
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		conn.Close()
+		if e.Port == "465" {
+			err = fmt.Errorf("%w (port 465 expects TLS from the start, which isn't supported; use port 587 with STARTTLS)", err)
+		}
+		return fail(0, err)
+	}
+	defer client.Close()
+	steps[0] = ConnectionStep{Name: StepDial, OK: true, Detail: "connected to " + addr}
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: e.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fail(1, err)
+		}
+		state, _ := client.TLSConnectionState()
+		steps[1] = ConnectionStep{Name: StepTLS, OK: true, Detail: "STARTTLS negotiated " + tls.VersionName(state.Version)}
+	} else if isLocalhost(e.Host) {
+		steps[1] = ConnectionStep{Name: StepTLS, Skipped: true, Detail: "server does not offer STARTTLS; allowed for localhost"}
+	} else if e.Username == "" {
+		steps[1] = ConnectionStep{Name: StepTLS, Skipped: true, Detail: "server does not offer STARTTLS; allowed because no credentials are sent"}
+	} else {
+		return fail(1, fmt.Errorf("server does not offer STARTTLS, so credentials can't be sent"))
+	}
+
+	if e.Username == "" {
+		steps[2] = ConnectionStep{Name: StepAuth, Skipped: true, Detail: "no username configured"}
+	} else {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fail(2, fmt.Errorf("server does not offer authentication"))
+		}
+		if err := client.Auth(smtp.PlainAuth(e.Identity, e.Username, e.Password, e.Host)); err != nil {
+			return fail(2, err)
+		}
+		steps[2] = ConnectionStep{Name: StepAuth, OK: true, Detail: "authenticated as " + e.Username}
+	}
+
+	if sendTo == "" {
+		steps[3] = ConnectionStep{Name: StepSend, Skipped: true, Detail: "no test recipient given"}
+	} else {
+		message := e.compose(&participant.Participant{ContactInfo: []string{sendTo}}, "Secret Santa test message",
+			"This is a test message from Secret Santa. Your email settings work.")
+		if err := sendWith(client, e.FromAddress, []string{sendTo}, formatMessage(message)); err != nil {
+			return fail(3, err)
+		}
+		steps[3] = ConnectionStep{Name: StepSend, OK: true, Detail: "test message sent to " + sendTo}
+	}
+
+	client.Quit()
+	return steps
+}
+
+// sendWith sends msg over an open SMTP session
+func sendWith(client *smtp.Client, from string, to []string, msg []byte) error {
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// isLocalhost matches the hosts net/smtp sends credentials to without TLS
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
@@ -0,0 +1,176 @@
+package notifier_test
+
+import (
+	"bufio"
+	"context"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"net"
+	"strings"
+	"sync"
+)
+
+// fakeSMTPServer speaks just enough SMTP for TestConnection. It offers
+// AUTH but not STARTTLS, and accepts only the password "secret".
+type fakeSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	data     []string
+}
+
+func startFakeSMTPServer() *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	server := &fakeSMTPServer{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakeSMTPServer) port() string {
+	return strings.TrimPrefix(s.listener.Addr().String(), "127.0.0.1:")
+}
+
+func (s *fakeSMTPServer) messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.data...)
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 fake ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.Fields(line + " x")[0])
+		switch command {
+		case "EHLO":
+			reply("250-fake")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			// AUTH PLAIN base64("\x00user\x00secret")
+			if strings.Contains(line, "AHVzZXIAc2VjcmV0") {
+				reply("235 Authentication succeeded")
+			} else {
+				reply("535 Authentication failed")
+			}
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			reply("250 OK")
+		case "DATA":
+			reply("354 Go ahead")
+			var body strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				body.WriteString(dataLine)
+			}
+			s.mu.Lock()
+			s.data = append(s.data, body.String())
+			s.mu.Unlock()
+			reply("250 Queued")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Not implemented")
+		}
+	}
+}
+
+var _ = Describe("Email connection test", func() {
+	var (
+		server        *fakeSMTPServer
+		emailNotifier *notifier.EmailNotifier
+	)
+
+	BeforeEach(func() {
+		server = startFakeSMTPServer()
+		DeferCleanup(func() { server.listener.Close() })
+		emailNotifier = &notifier.EmailNotifier{
+			Host:        "127.0.0.1",
+			Port:        server.port(),
+			Username:    "user",
+			Password:    "secret",
+			FromAddress: "santa@example.com",
+		}
+	})
+
+	It("should report each step and skip sending without a recipient", func() {
+		steps := emailNotifier.TestConnection(context.Background(), "")
+		Expect(steps).To(HaveLen(4))
+		Expect(steps[0].OK).To(BeTrue())
+		Expect(steps[1].Skipped).To(BeTrue())
+		Expect(steps[2].OK).To(BeTrue())
+		Expect(steps[3].Skipped).To(BeTrue())
+		Expect(notifier.ConnectionSucceeded(steps)).To(BeTrue())
+		Expect(server.messages()).To(BeEmpty())
+	})
+
+	It("should send a test message to the recipient", func() {
+		steps := emailNotifier.TestConnection(context.Background(), "organizer@example.com")
+		Expect(notifier.ConnectionSucceeded(steps)).To(BeTrue())
+		Expect(steps[3]).To(Equal(notifier.ConnectionStep{Name: notifier.StepSend, OK: true, Detail: "test message sent to organizer@example.com"}))
+		Expect(server.messages()).To(ConsistOf(ContainSubstring("To: organizer@example.com")))
+	})
+
+	It("should fail the auth step with bad credentials and skip sending", func() {
+		emailNotifier.Password = "wrong"
+		steps := emailNotifier.TestConnection(context.Background(), "organizer@example.com")
+		Expect(notifier.ConnectionSucceeded(steps)).To(BeFalse())
+		Expect(steps[2].OK).To(BeFalse())
+		Expect(steps[2].Detail).To(ContainSubstring("535"))
+		Expect(steps[3].Skipped).To(BeTrue())
+		Expect(server.messages()).To(BeEmpty())
+	})
+
+	Context("with a server that isn't localhost and offers no STARTTLS", func() {
+		BeforeEach(func() {
+			// An IPv4-mapped address reaches the fake server without
+			// counting as localhost
+			emailNotifier.Host = "::ffff:127.0.0.1"
+		})
+
+		It("should refuse to send credentials", func() {
+			steps := emailNotifier.TestConnection(context.Background(), "")
+			Expect(steps[1].OK).To(BeFalse())
+			Expect(steps[1].Skipped).To(BeFalse())
+			Expect(steps[1].Detail).To(ContainSubstring("credentials can't be sent"))
+			Expect(steps[2].Skipped).To(BeTrue())
+		})
+
+		It("should allow the connection when there are no credentials", func() {
+			emailNotifier.Username, emailNotifier.Password = "", ""
+			steps := emailNotifier.TestConnection(context.Background(), "")
+			Expect(steps[1].Skipped).To(BeTrue())
+			Expect(steps[2].Skipped).To(BeTrue())
+			Expect(notifier.ConnectionSucceeded(steps)).To(BeTrue())
+		})
+	})
+
+	It("should fail the dial step when nothing is listening", func() {
+		server.listener.Close()
+		steps := emailNotifier.TestConnection(context.Background(), "")
+		Expect(steps[0].OK).To(BeFalse())
+		Expect(steps[0].Skipped).To(BeFalse())
+		Expect(steps[1].Skipped).To(BeTrue())
+		Expect(notifier.ConnectionSucceeded(steps)).To(BeFalse())
+	})
+})
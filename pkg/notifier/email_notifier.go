@@ -2,6 +2,7 @@ package notifier
 
 import (
 	"fmt"
+	"github.com/igodwin/secretsanta/pkg/config"
 	"github.com/igodwin/secretsanta/pkg/participant"
 	"net/smtp"
 	"strings"
@@ -34,9 +35,78 @@ type EmailNotifier struct {
 	SendMailFunc SendMailFunc
 }
 
+func init() {
+	Register(Channel{
+		Name:        "email",
+		Description: "Email via SMTP",
+		ServiceType: "NOTIFICATION_TYPE_EMAIL",
+		ConfigSchema: []ConfigField{
+			{Key: "smtp.host", Required: true, Description: "SMTP server hostname"},
+			{Key: "smtp.port", Required: true, Description: "SMTP server port"},
+			{Key: "smtp.username", Description: "SMTP username"},
+			{Key: "smtp.password", Secret: true, Description: "SMTP password"},
+			{Key: "smtp.from_address", Required: true, Description: "From email address"},
+			{Key: "smtp.from_name", Description: "From display name"},
+			{Key: "smtp.content_type", Description: "text/plain or text/html"},
+			{Key: "smtp.identity", Description: "SMTP identity"},
+		},
+		Capabilities:    Capabilities{Subject: true, HTML: true, LongForm: true, BCC: true},
+		ValidateContact: contactValidator("email", participant.ContactEmail),
+		ContactKind:     participant.ContactEmail,
+		New: func(cfg *config.Config) (Notifier, error) {
+			return newEmailNotifier(cfg), nil
+		},
+		IsConfigured: func(cfg *config.Config) error {
+			return newEmailNotifier(cfg).IsConfigured()
+		},
+	})
+}
+
+// newEmailNotifier returns a notifier using the smtp config section
+func newEmailNotifier(cfg *config.Config) *EmailNotifier {
+	return &EmailNotifier{
+		Host:        cfg.SMTP.Host,
+		Port:        cfg.SMTP.Port,
+		Identity:    cfg.SMTP.Identity,
+		Username:    cfg.SMTP.Username,
+		Password:    cfg.SMTP.Password,
+		FromAddress: cfg.SMTP.FromAddress,
+		FromName:    cfg.SMTP.FromName,
+		ContentType: cfg.SMTP.ContentType,
+	}
+}
+
 func (e *EmailNotifier) SendNotification(participant *participant.Participant) error {
-	allAddresses := strings.Join(participant.ContactInfo, ",")
-	auth := smtp.PlainAuth(e.Identity, e.Username, e.Password, e.Host)
+	message, err := e.Preview(participant)
+	if err != nil {
+		return err
+	}
+	return e.send(message)
+}
+
+func (e *EmailNotifier) SendMessage(participant *participant.Participant, subject, body string) error {
+	return e.send(e.compose(participant, subject, body))
+}
+
+// Deliver sends message to its recipients from the configured sender. The
+// message's BCC recipients are added to the sender's copy.
+func (e *EmailNotifier) Deliver(message *Message) error {
+	composed := e.compose(&participant.Participant{ContactInfo: message.Recipients}, message.Subject, message.Body)
+	composed.BCC = append(composed.BCC, message.BCC...)
+	if message.ContentType != "" {
+		composed.ContentType = message.ContentType
+	}
+	return e.send(composed)
+}
+
+// Preview renders the email without sending it. The sender is copied on
+// every message so it appears as a BCC recipient.
+func (e *EmailNotifier) Preview(participant *participant.Participant) (*Message, error) {
+	body := fmt.Sprintf(emailBodyTemplate, participant.Name, participant.Recipient.Name)
+	return e.compose(participant, participant.Name+subjectSuffix, body), nil
+}
+
+func (e *EmailNotifier) compose(participant *participant.Participant, subject, body string) *Message {
 	from := fmt.Sprintf("<%s>", e.FromAddress)
 	if e.FromName != "" {
 		from = fmt.Sprintf(`"%s" <%s>`, e.FromName, e.FromAddress)
@@ -48,28 +118,63 @@ func (e *EmailNotifier) SendNotification(participant *participant.Participant) e
 		contentType = "text/plain"
 	}
 
-	formattedMessage := []byte(fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s%s\r\nContent-Type: %s; charset=UTF-8\r\n\r\n%s",
-		from,
-		allAddresses,
-		participant.Name,
-		subjectSuffix,
-		contentType,
-		fmt.Sprintf(emailBodyTemplate, participant.Name, participant.Recipient.Name)))
+	var bcc []string
+	if e.FromAddress != "" {
+		bcc = []string{e.FromAddress}
+	}
+
+	return &Message{
+		Channel:     "email",
+		From:        from,
+		Recipients:  append([]string(nil), participant.ContactInfo...),
+		BCC:         bcc,
+		Subject:     subject,
+		Body:        body,
+		ContentType: contentType,
+	}
+}
+
+func (e *EmailNotifier) send(message *Message) error {
+	auth := smtp.PlainAuth(e.Identity, e.Username, e.Password, e.Host)
+	formattedMessage := formatMessage(message)
 
 	if e.SendMailFunc == nil {
 		e.SendMailFunc = smtp.SendMail
 	}
 
-	err := e.SendMailFunc(fmt.Sprintf("%s:%s", e.Host, e.Port), auth, e.FromAddress, append(participant.ContactInfo, e.FromAddress), formattedMessage)
+	err := e.SendMailFunc(fmt.Sprintf("%s:%s", e.Host, e.Port), auth, e.FromAddress, append(message.Recipients, message.BCC...), formattedMessage)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// formatMessage renders message's headers and body as sent over SMTP
+func formatMessage(message *Message) []byte {
+	return []byte(fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: %s; charset=UTF-8\r\n\r\n%s",
+		message.From,
+		strings.Join(message.Recipients, ","),
+		message.Subject,
+		message.ContentType,
+		message.Body))
+}
+
+// IsConfigured reports whether the settings needed to send are present. It
+// doesn't contact the server; use TestConnection for that.
 func (e *EmailNotifier) IsConfigured() error {
 	if e.Host == "" && e.Port == "" && e.Username == "" && e.Password == "" && e.FromAddress == "" {
 		return fmt.Errorf("smtp is not configured")
 	}
+	var missing []string
+	for _, field := range []struct{ name, value string }{
+		{"host", e.Host}, {"port", e.Port}, {"from_address", e.FromAddress},
+	} {
+		if field.value == "" {
+			missing = append(missing, "smtp."+field.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("smtp is missing %s", strings.Join(missing, ", "))
+	}
 	return nil
 }
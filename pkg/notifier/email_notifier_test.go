@@ -98,6 +98,29 @@ var _ = Describe("Email Notifier", func() {
 		})
 	})
 
+	Context("Deliver", func() {
+		It("should send to the BCC recipients with the message content type", func() {
+			var recipients []string
+			messageCapture := ""
+			emailNotifier.SendMailFunc = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+				recipients = to
+				messageCapture = string(msg)
+				return nil
+			}
+			message := &notifier.Message{
+				Recipients:  []string{"alice@example.com"},
+				BCC:         []string{"archive@example.com"},
+				Subject:     "Hello",
+				Body:        "<p>Hi</p>",
+				ContentType: "text/html",
+			}
+			Expect(emailNotifier.Deliver(message)).To(Succeed())
+			Expect(recipients).To(ConsistOf("alice@example.com", "noreply@example.com", "archive@example.com"))
+			Expect(messageCapture).To(ContainSubstring("Content-Type: text/html; charset=UTF-8"))
+			Expect(messageCapture).NotTo(ContainSubstring("archive@example.com"))
+		})
+	})
+
 	Context("IsConfigured", func() {
 		It("should not error when smtp is configured", func() {
 			Expect(emailNotifier.IsConfigured()).NotTo(HaveOccurred())
@@ -107,5 +130,10 @@ var _ = Describe("Email Notifier", func() {
 			err := badEmailNotifier.IsConfigured()
 			Expect(err).To(MatchError("smtp is not configured"))
 		})
+
+		It("should error when only some settings are present", func() {
+			err := (&notifier.EmailNotifier{Host: "smtp.example.com"}).IsConfigured()
+			Expect(err).To(MatchError("smtp is missing smtp.port, smtp.from_address"))
+		})
 	})
 })
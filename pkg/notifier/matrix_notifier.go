@@ -0,0 +1,120 @@
+package notifier
+
+import (
+	"fmt"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MatrixNotifier sends assignments with the Matrix client-server API.
+// Contact info may be a room ID (!room:server) or a user ID (@user:server),
+// in which case a direct chat is created and the user is invited to it.
+type MatrixNotifier struct {
+	HomeserverURL string
+	AccessToken   string
+	HTTPClient    *http.Client
+	txnCounter    uint64
+}
+
+func init() {
+	Register(Channel{
+		Name:        "matrix",
+		Description: "Matrix room or direct chat message",
+		ConfigSchema: []ConfigField{
+			{Key: "matrix.homeserver_url", Required: true, Description: "Homeserver base URL"},
+			{Key: "matrix.access_token", Required: true, Secret: true, Description: "Access token of the sending account"},
+		},
+		Capabilities: Capabilities{LongForm: true},
+		New: func(cfg *config.Config) (Notifier, error) {
+			return &MatrixNotifier{
+				HomeserverURL: cfg.Matrix.HomeserverURL,
+				AccessToken:   cfg.Matrix.AccessToken,
+			}, nil
+		},
+	})
+}
+
+func (m *MatrixNotifier) SendNotification(participant *participant.Participant) error {
+	return m.SendMessage(participant, "", ChatBody(participant.Name, participant.Recipient.Name))
+}
+
+func (m *MatrixNotifier) SendMessage(participant *participant.Participant, subject, body string) error {
+	targets := nonEmpty(participant.ContactInfo)
+	if len(targets) == 0 {
+		return fmt.Errorf("no matrix room or user id for %s", participant.Name)
+	}
+
+	m.HTTPClient = defaultHTTPClient(m.HTTPClient)
+
+	for _, target := range targets {
+		roomID := target
+		if strings.HasPrefix(target, "@") {
+			var err error
+			if roomID, err = m.createDirectRoom(target); err != nil {
+				return fmt.Errorf("failed to create matrix direct chat with %s: %w", target, err)
+			}
+		}
+		if err := m.sendMessage(roomID, body); err != nil {
+			return fmt.Errorf("failed to send matrix message to %s: %w", participant.Name, err)
+		}
+	}
+	return nil
+}
+
+func (m *MatrixNotifier) Preview(participant *participant.Participant) (*Message, error) {
+	targets := nonEmpty(participant.ContactInfo)
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no matrix room or user id for %s", participant.Name)
+	}
+	return &Message{
+		Channel:     "matrix",
+		Recipients:  targets,
+		Body:        ChatBody(participant.Name, participant.Recipient.Name),
+		ContentType: "text/plain",
+	}, nil
+}
+
+func (m *MatrixNotifier) IsConfigured() error {
+	if m.HomeserverURL == "" && m.AccessToken == "" {
+		return fmt.Errorf("matrix is not configured")
+	}
+	if m.HomeserverURL == "" || m.AccessToken == "" {
+		return fmt.Errorf("matrix requires homeserver_url and access_token")
+	}
+	return nil
+}
+
+func (m *MatrixNotifier) endpoint(path string) string {
+	return strings.TrimSuffix(m.HomeserverURL, "/") + "/_matrix/client/v3" + path
+}
+
+func (m *MatrixNotifier) headers() map[string]string {
+	return map[string]string{"Authorization": "Bearer " + m.AccessToken}
+}
+
+func (m *MatrixNotifier) createDirectRoom(userID string) (string, error) {
+	payload := map[string]interface{}{
+		"is_direct": true,
+		"invite":    []string{userID},
+		"preset":    "trusted_private_chat",
+	}
+	var resp struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := doJSON(m.HTTPClient, http.MethodPost, m.endpoint("/createRoom"), m.headers(), payload, &resp); err != nil {
+		return "", err
+	}
+	return resp.RoomID, nil
+}
+
+func (m *MatrixNotifier) sendMessage(roomID, body string) error {
+	txnID := fmt.Sprintf("secretsanta-%d-%d", time.Now().UnixNano(), atomic.AddUint64(&m.txnCounter, 1))
+	path := fmt.Sprintf("/rooms/%s/send/m.room.message/%s", url.PathEscape(roomID), txnID)
+	payload := map[string]string{"msgtype": "m.text", "body": body}
+	return doJSON(m.HTTPClient, http.MethodPut, m.endpoint(path), m.headers(), payload, nil)
+}
@@ -6,3 +6,16 @@ type Notifier interface {
 	SendNotification(participant *participant.Participant) error
 	IsConfigured() error
 }
+
+// MessageSender is implemented by notifiers that can deliver free-form
+// messages, such as reminders, to a participant's contact info. Channels
+// without subjects ignore subject.
+type MessageSender interface {
+	SendMessage(participant *participant.Participant, subject, body string) error
+}
+
+// MessageDeliverer is implemented by notifiers that can send a composed
+// Message as is, honoring its BCC recipients and content type
+type MessageDeliverer interface {
+	Deliver(message *Message) error
+}
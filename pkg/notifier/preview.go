@@ -0,0 +1,56 @@
+package notifier
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	"io"
+	"mime"
+	"strings"
+)
+
+// Message is a fully rendered notification as a channel would deliver it
+type Message struct {
+	Channel     string   `json:"channel"`
+	From        string   `json:"from,omitempty"`
+	Recipients  []string `json:"recipients"`
+	BCC         []string `json:"bcc,omitempty"`
+	Subject     string   `json:"subject,omitempty"`
+	Body        string   `json:"body"`
+	ContentType string   `json:"content_type"`
+}
+
+// Previewer is implemented by notifiers that can render a message without sending it
+type Previewer interface {
+	Preview(participant *participant.Participant) (*Message, error)
+}
+
+// WriteEML writes m as an RFC 5322 message that can be opened in a mail client.
+// Messages for channels without a subject get one naming the channel.
+func (m *Message) WriteEML(w io.Writer) error {
+	subject := m.Subject
+	if subject == "" {
+		subject = fmt.Sprintf("Secret Santa %s message", m.Channel)
+	}
+	contentType := m.ContentType
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+
+	bw := bufio.NewWriter(w)
+	if m.From != "" {
+		fmt.Fprintf(bw, "From: %s\r\n", m.From)
+	}
+	fmt.Fprintf(bw, "To: %s\r\n", strings.Join(m.Recipients, ", "))
+	if len(m.BCC) > 0 {
+		fmt.Fprintf(bw, "Bcc: %s\r\n", strings.Join(m.BCC, ", "))
+	}
+	fmt.Fprintf(bw, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(bw, "X-SecretSanta-Channel: %s\r\n", m.Channel)
+	fmt.Fprintf(bw, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(bw, "Content-Type: %s; charset=UTF-8\r\n", contentType)
+	fmt.Fprintf(bw, "Content-Transfer-Encoding: 8bit\r\n\r\n")
+	bw.WriteString(strings.ReplaceAll(strings.ReplaceAll(m.Body, "\r\n", "\n"), "\n", "\r\n"))
+	bw.WriteString("\r\n")
+	return bw.Flush()
+}
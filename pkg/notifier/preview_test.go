@@ -0,0 +1,92 @@
+package notifier_test
+
+import (
+	"bytes"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Preview", func() {
+	var testParticipant *participant.Participant
+
+	BeforeEach(func() {
+		testParticipant = &participant.Participant{
+			Name:        "Alice",
+			ContactInfo: []string{"alice@example.com"},
+			Recipient:   &participant.Participant{Name: "Bob"},
+		}
+	})
+
+	Context("EmailNotifier", func() {
+		It("renders the message that would be sent", func() {
+			emailNotifier := &notifier.EmailNotifier{
+				FromAddress: "santa@example.com",
+				FromName:    "Papa Elf",
+			}
+
+			message, err := emailNotifier.Preview(testParticipant)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(message.Channel).To(Equal("email"))
+			Expect(message.From).To(Equal(`"Papa Elf" <santa@example.com>`))
+			Expect(message.Recipients).To(Equal([]string{"alice@example.com"}))
+			Expect(message.BCC).To(Equal([]string{"santa@example.com"}))
+			Expect(message.Subject).To(Equal("Alice's Secret Santa Assignment"))
+			Expect(message.Body).To(ContainSubstring("perfect gift for Bob"))
+			Expect(message.ContentType).To(Equal("text/plain"))
+		})
+	})
+
+	Context("SMSNotifier", func() {
+		It("normalizes recipients and leaves the body to the caller's template", func() {
+			testParticipant.ContactInfo = []string{"+1 (555) 555-0123"}
+			smsNotifier := &notifier.SMSNotifier{Provider: &notifier.FakeSMSProvider{}}
+
+			message, err := smsNotifier.Preview(testParticipant)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(message.Recipients).To(Equal([]string{"+15555550123"}))
+			Expect(message.Body).To(BeEmpty())
+		})
+
+		It("errors on an invalid phone number", func() {
+			smsNotifier := &notifier.SMSNotifier{Provider: &notifier.FakeSMSProvider{}}
+			_, err := smsNotifier.Preview(testParticipant)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("WriteEML", func() {
+		It("writes headers and a CRLF body", func() {
+			message := &notifier.Message{
+				Channel:    "email",
+				From:       "<santa@example.com>",
+				Recipients: []string{"alice@example.com", "alice@work.example.com"},
+				BCC:        []string{"archive@example.com"},
+				Subject:    "Hello",
+				Body:       "line one\nline two",
+			}
+
+			var buf bytes.Buffer
+			Expect(message.WriteEML(&buf)).To(Succeed())
+			Expect(buf.String()).To(Equal("From: <santa@example.com>\r\n" +
+				"To: alice@example.com, alice@work.example.com\r\n" +
+				"Bcc: archive@example.com\r\n" +
+				"Subject: Hello\r\n" +
+				"X-SecretSanta-Channel: email\r\n" +
+				"MIME-Version: 1.0\r\n" +
+				"Content-Type: text/plain; charset=UTF-8\r\n" +
+				"Content-Transfer-Encoding: 8bit\r\n\r\n" +
+				"line one\r\nline two\r\n"))
+		})
+
+		It("names the channel when the message has no subject", func() {
+			message := &notifier.Message{Channel: "sms", Recipients: []string{"+15555550123"}, Body: "hi"}
+
+			var buf bytes.Buffer
+			Expect(message.WriteEML(&buf)).To(Succeed())
+			Expect(buf.String()).To(ContainSubstring("Subject: Secret Santa sms message\r\n"))
+			Expect(buf.String()).NotTo(ContainSubstring("From:"))
+		})
+	})
+})
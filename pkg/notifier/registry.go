@@ -0,0 +1,169 @@
+package notifier
+
+import (
+	"fmt"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Capabilities describes what a notification channel can deliver
+type Capabilities struct {
+	// Subject is true when messages carry a subject line
+	Subject bool `json:"subject"`
+	// HTML is true when bodies may be sent as text/html
+	HTML bool `json:"html"`
+	// LongForm is false when the channel needs a short-form message
+	LongForm bool `json:"long_form"`
+	// BCC is true when the archive address can be copied on each message
+	BCC bool `json:"bcc"`
+}
+
+// ConfigField describes a configuration key used by a channel
+type ConfigField struct {
+	Key         string `json:"key"`
+	Required    bool   `json:"required"`
+	Secret      bool   `json:"secret"`
+	Description string `json:"description"`
+}
+
+// Channel describes a notification channel selectable via notification_type
+type Channel struct {
+	Name        string
+	Description string
+	// ServiceType is the NotificationType enum name used by the external
+	// notifier service, or empty when the service does not support the channel
+	ServiceType  string
+	ConfigSchema []ConfigField
+	Capabilities Capabilities
+	// New builds the local implementation. It is nil for channels that are
+	// only available through the notifier service.
+	New func(cfg *config.Config) (Notifier, error)
+	// IsConfigured reports whether the local implementation can be used
+	IsConfigured func(cfg *config.Config) error
+	// ValidateContact checks a single contact info entry, if set
+	ValidateContact func(contact string) error
+	// ContactKind is the kind of contact the channel delivers to, if it has
+	// one. Routes normalizes the channel's contacts to that kind's Value,
+	// such as the bare address of "Alice <alice@example.com>".
+	ContactKind participant.ContactKind
+}
+
+// Local reports whether the channel has an in-process implementation
+func (c Channel) Local() bool {
+	return c.New != nil
+}
+
+// Configured reports whether the channel can be used locally with cfg
+func (c Channel) Configured(cfg *config.Config) error {
+	if !c.Local() {
+		return fmt.Errorf("%s is only available through the notifier service", c.Name)
+	}
+	if c.IsConfigured != nil {
+		return c.IsConfigured(cfg)
+	}
+	n, err := c.New(cfg)
+	if err != nil {
+		return err
+	}
+	return n.IsConfigured()
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Channel{}
+)
+
+// Register adds a channel to the registry, replacing any channel with the same name
+func Register(channel Channel) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[channel.Name] = channel
+}
+
+// Lookup returns the channel registered under name
+func Lookup(name string) (Channel, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	channel, ok := registry[name]
+	return channel, ok
+}
+
+// LookupServiceType returns the channel mapped to a notifier service NotificationType enum name
+func LookupServiceType(serviceType string) (Channel, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, channel := range registry {
+		if channel.ServiceType != "" && channel.ServiceType == serviceType {
+			return channel, true
+		}
+	}
+	return Channel{}, false
+}
+
+// Channels returns all registered channels sorted by name
+func Channels() []Channel {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	channels := make([]Channel, 0, len(registry))
+	for _, channel := range registry {
+		channels = append(channels, channel)
+	}
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].Name < channels[j].Name
+	})
+	return channels
+}
+
+// SplitNotificationType parses a notification type string that may include an account
+// Format: "type" or "type:account" (e.g., "email:notify")
+// Returns: (type, account)
+func SplitNotificationType(notifType string) (string, string) {
+	parts := strings.SplitN(notifType, ":", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return parts[0], ""
+}
+
+// contactValidator returns a ValidateContact function that accepts only the
+// given contact kinds, reporting a mismatch such as a Slack handle on an
+// email channel
+func contactValidator(channel string, kinds ...participant.ContactKind) func(string) error {
+	return func(contact string) error {
+		detected := participant.DetectContactKind(contact)
+		for _, kind := range kinds {
+			if detected == kind {
+				_, err := participant.ParseContactAs(contact, kind)
+				return err
+			}
+		}
+		if detected == participant.ContactUnknown {
+			// Report the format problem for the expected kind
+			_, err := participant.ParseContactAs(contact, kinds[0])
+			return err
+		}
+		return fmt.Errorf("contact %q looks like %s, but %s expects %s",
+			contact, detected.Description(), channel, kinds[0].Description())
+	}
+}
+
+// Channels that are only delivered by the external notifier service
+func init() {
+	Register(Channel{
+		Name:            "slack",
+		Description:     "Slack message via the notifier service",
+		ServiceType:     "NOTIFICATION_TYPE_SLACK",
+		Capabilities:    Capabilities{LongForm: true},
+		ValidateContact: contactValidator("slack", participant.ContactSlack),
+		ContactKind:     participant.ContactSlack,
+	})
+	Register(Channel{
+		Name:         "ntfy",
+		Description:  "ntfy push notification via the notifier service",
+		ServiceType:  "NOTIFICATION_TYPE_NTFY",
+		Capabilities: Capabilities{Subject: true, LongForm: true},
+	})
+}
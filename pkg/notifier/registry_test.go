@@ -0,0 +1,71 @@
+package notifier_test
+
+import (
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Registry", func() {
+	It("should register every built-in channel", func() {
+		var names []string
+		for _, channel := range notifier.Channels() {
+			names = append(names, channel.Name)
+		}
+		Expect(names).To(Equal([]string{"discord", "email", "matrix", "ntfy", "slack", "sms", "stdout", "telegram", "webhook"}))
+	})
+
+	It("should map notifier service types back to channels", func() {
+		channel, ok := notifier.LookupServiceType("NOTIFICATION_TYPE_SLACK")
+		Expect(ok).To(BeTrue())
+		Expect(channel.Name).To(Equal("slack"))
+		Expect(channel.Local()).To(BeFalse())
+
+		_, ok = notifier.LookupServiceType("")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should report configuration from the app config", func() {
+		cfg := &config.Config{}
+		email, _ := notifier.Lookup("email")
+		stdout, _ := notifier.Lookup("stdout")
+		slack, _ := notifier.Lookup("slack")
+		Expect(email.Configured(cfg)).To(MatchError("smtp is not configured"))
+		Expect(stdout.Configured(cfg)).To(Succeed())
+		Expect(slack.Configured(cfg)).To(HaveOccurred())
+
+		cfg.SMTP.Host, cfg.SMTP.FromAddress = "smtp.example.com", "santa@example.com"
+		Expect(email.Configured(cfg)).To(MatchError(ContainSubstring("smtp.port")))
+
+		cfg.SMTP.Port = "587"
+		Expect(email.Configured(cfg)).To(Succeed())
+	})
+
+	It("should build local notifiers from config", func() {
+		telegram, _ := notifier.Lookup("telegram")
+		n, err := telegram.New(&config.Config{Telegram: config.TelegramConfig{BotToken: "123:abc"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(BeAssignableToTypeOf(&notifier.TelegramNotifier{}))
+		Expect(n.IsConfigured()).To(Succeed())
+	})
+
+	It("should validate contact info for channels that define a format", func() {
+		sms, _ := notifier.Lookup("sms")
+		Expect(sms.ValidateContact("+15555550123")).To(Succeed())
+		Expect(sms.ValidateContact("@carol")).To(HaveOccurred())
+	})
+
+	It("should flag a contact that does not match the channel", func() {
+		email, _ := notifier.Lookup("email")
+		Expect(email.ValidateContact("alice@example.com")).To(Succeed())
+		Expect(email.ValidateContact("@carol")).To(MatchError(`contact "@carol" looks like a Slack handle, but email expects an email address`))
+		Expect(email.ValidateContact("carol")).To(MatchError(ContainSubstring("invalid email address")))
+	})
+
+	It("should split the account from a notification type", func() {
+		name, account := notifier.SplitNotificationType("email:notify")
+		Expect(name).To(Equal("email"))
+		Expect(account).To(Equal("notify"))
+	})
+})
@@ -0,0 +1,108 @@
+package notifier
+
+import (
+	"github.com/igodwin/secretsanta/pkg/participant"
+	"strings"
+)
+
+// Route is one step in a participant's delivery chain
+type Route struct {
+	Channel     string
+	Account     string
+	ContactInfo []string
+}
+
+// Routes returns the channels to try for a participant, in order.
+//
+// Contact info entries may be prefixed with a registered channel name
+// (e.g. "sms:+15555550123") to deliver them over that channel. Unprefixed
+// entries use the participant's NotificationType. Channels are tried in the
+// order they first appear in the contact info. Contacts are normalized for
+// their channel, e.g. "Alice <alice@example.com>" becomes alice@example.com.
+func Routes(p *participant.Participant) []Route {
+	defaultChannel, defaultAccount := SplitNotificationType(p.NotificationType)
+
+	var routes []Route
+	index := make(map[string]int)
+	add := func(channel, contact string) {
+		i, ok := index[channel]
+		if !ok {
+			route := Route{Channel: channel}
+			if channel == defaultChannel {
+				route.Account = defaultAccount
+			}
+			routes = append(routes, route)
+			i = len(routes) - 1
+			index[channel] = i
+		}
+		if contact != "" {
+			routes[i].ContactInfo = append(routes[i].ContactInfo, normalizeContact(channel, contact))
+		}
+	}
+
+	for _, contact := range p.ContactInfo {
+		channel, value := SplitContact(contact)
+		if channel == "" {
+			channel, value = defaultChannel, contact
+		}
+		add(channel, value)
+	}
+
+	if len(routes) == 0 {
+		add(defaultChannel, "")
+	}
+	return routes
+}
+
+// PersonalData is participant.PersonalData plus each contact in the form
+// Routes delivers it, such as the bare address of "Alice <alice@example.com>",
+// since that is the form delivery errors quote
+func PersonalData(participants ...*participant.Participant) []string {
+	values := participant.PersonalData(participants...)
+	for _, p := range participants {
+		for _, route := range Routes(p) {
+			values = append(values, route.ContactInfo...)
+		}
+	}
+	return values
+}
+
+// normalizeContact returns contact in the normalized form of channel's
+// contact kind, so notifiers never get a display name or a formatted phone
+// number. Contacts that don't parse are left for validation to report.
+func normalizeContact(channelName, contact string) string {
+	channel, ok := Lookup(channelName)
+	if !ok || channel.ContactKind == participant.ContactUnknown {
+		return contact
+	}
+	parsed, err := participant.ParseContactAs(contact, channel.ContactKind)
+	if err != nil {
+		return contact
+	}
+	return parsed.Value
+}
+
+// SplitContact splits a contact info entry of the form "channel:value" when
+// channel is a registered channel name. It returns an empty channel for
+// untyped entries such as plain addresses, URLs or Matrix IDs.
+func SplitContact(contact string) (string, string) {
+	prefix, value, found := strings.Cut(contact, ":")
+	if !found {
+		return "", contact
+	}
+	if _, ok := Lookup(prefix); !ok {
+		return "", contact
+	}
+	return prefix, value
+}
+
+// ForRoute returns a copy of p addressed to a single route of its delivery chain
+func ForRoute(p *participant.Participant, route Route) *participant.Participant {
+	routed := *p
+	routed.NotificationType = route.Channel
+	if route.Account != "" {
+		routed.NotificationType = route.Channel + ":" + route.Account
+	}
+	routed.ContactInfo = route.ContactInfo
+	return &routed
+}
@@ -0,0 +1,82 @@
+package notifier_test
+
+import (
+	"errors"
+	"github.com/igodwin/secretsanta/internal/logging"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Routes", func() {
+	It("should use the notification type for untyped contacts", func() {
+		p := &participant.Participant{NotificationType: "email:work", ContactInfo: []string{"a@example.com", "b@example.com"}}
+		Expect(notifier.Routes(p)).To(Equal([]notifier.Route{
+			{Channel: "email", Account: "work", ContactInfo: []string{"a@example.com", "b@example.com"}},
+		}))
+	})
+
+	It("should order channels by first appearance of typed contacts", func() {
+		p := &participant.Participant{
+			NotificationType: "email",
+			ContactInfo:      []string{"sms:+15555550123", "alice@example.com", "email:alice@work.example.com"},
+		}
+		Expect(notifier.Routes(p)).To(Equal([]notifier.Route{
+			{Channel: "sms", ContactInfo: []string{"+15555550123"}},
+			{Channel: "email", ContactInfo: []string{"alice@example.com", "alice@work.example.com"}},
+		}))
+	})
+
+	It("should normalize contacts for their channel", func() {
+		p := &participant.Participant{
+			NotificationType: "email",
+			ContactInfo:      []string{"Alice <alice@example.com>", "sms:+1 (555) 555-0123", "not an address"},
+		}
+		Expect(notifier.Routes(p)).To(Equal([]notifier.Route{
+			{Channel: "email", ContactInfo: []string{"alice@example.com", "not an address"}},
+			{Channel: "sms", ContactInfo: []string{"+15555550123"}},
+		}))
+	})
+
+	It("should not treat URLs or Matrix IDs as typed contacts", func() {
+		channel, _ := notifier.SplitContact("https://hooks.example.com/x")
+		Expect(channel).To(BeEmpty())
+		channel, value := notifier.SplitContact("@alice:matrix.org")
+		Expect(channel).To(BeEmpty())
+		Expect(value).To(Equal("@alice:matrix.org"))
+	})
+
+	It("should return a single route for participants without contact info", func() {
+		p := &participant.Participant{NotificationType: "stdout"}
+		Expect(notifier.Routes(p)).To(Equal([]notifier.Route{{Channel: "stdout"}}))
+	})
+
+	It("should address a copy of the participant to one route", func() {
+		p := &participant.Participant{Name: "Alice", NotificationType: "email", ContactInfo: []string{"a@example.com", "sms:+15555550123"}}
+		routed := notifier.ForRoute(p, notifier.Routes(p)[1])
+		Expect(routed.NotificationType).To(Equal("sms"))
+		Expect(routed.ContactInfo).To(Equal([]string{"+15555550123"}))
+		Expect(p.ContactInfo).To(HaveLen(2))
+	})
+
+	It("should redact contacts in the form delivery errors quote them", func() {
+		p := &participant.Participant{
+			Name:             "Alice",
+			NotificationType: "email",
+			ContactInfo:      []string{"sms:+1 (555) 555-0123", "Alice Smith <alice@example.com>"},
+		}
+		routes := notifier.Routes(p)
+		smsNotifier := &notifier.SMSNotifier{Provider: &notifier.FakeSMSProvider{Err: errors.New("carrier rejected")}}
+		err := errors.Join(
+			smsNotifier.SendMessage(notifier.ForRoute(p, routes[0]), "", "Hi"),
+			errors.New("mailbox full for "+routes[1].ContactInfo[0]),
+		)
+		Expect(err.Error()).To(ContainSubstring("+15555550123"))
+
+		logged := logging.RedactError(err, notifier.PersonalData(p)...).LogValue().String()
+		Expect(logged).NotTo(ContainSubstring("+15555550123"))
+		Expect(logged).NotTo(ContainSubstring("alice@example.com"))
+		Expect(logged).To(ContainSubstring("carrier rejected"))
+	})
+})
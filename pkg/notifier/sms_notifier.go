@@ -0,0 +1,198 @@
+package notifier
+
+import (
+	"fmt"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultTwilioBaseURL = "https://api.twilio.com"
+
+// SMSProvider delivers a single text message to an E.164 phone number
+type SMSProvider interface {
+	SendSMS(to, body string) error
+	IsConfigured() error
+}
+
+type SMSNotifier struct {
+	Provider SMSProvider
+}
+
+func init() {
+	Register(Channel{
+		Name:        "sms",
+		Description: "Text message via an SMS provider",
+		ConfigSchema: []ConfigField{
+			{Key: "sms.provider", Description: "SMS provider (twilio)"},
+			{Key: "sms.account_sid", Required: true, Description: "Provider account SID"},
+			{Key: "sms.auth_token", Required: true, Secret: true, Description: "Provider auth token"},
+			{Key: "sms.from_number", Required: true, Description: "Sending phone number (E.164)"},
+			{Key: "sms.base_url", Description: "Override for Twilio-compatible APIs"},
+		},
+		Capabilities: Capabilities{},
+		New: func(cfg *config.Config) (Notifier, error) {
+			provider, err := NewSMSProvider(cfg.SMS)
+			if err != nil {
+				return nil, err
+			}
+			return &SMSNotifier{Provider: provider}, nil
+		},
+		ValidateContact: contactValidator("sms", participant.ContactPhone),
+		ContactKind:     participant.ContactPhone,
+	})
+}
+
+// NewSMSProvider returns the provider selected by the sms config section
+func NewSMSProvider(smsConfig config.SMSConfig) (SMSProvider, error) {
+	switch smsConfig.Provider {
+	case "", "twilio":
+		return &TwilioProvider{
+			BaseURL:    smsConfig.BaseURL,
+			AccountSID: smsConfig.AccountSID,
+			AuthToken:  smsConfig.AuthToken,
+			FromNumber: smsConfig.FromNumber,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported sms provider: %s", smsConfig.Provider)
+	}
+}
+
+// SendNotification is not supported: SMS is a short-form channel, so the
+// assignment message comes from the caller's template through SendMessage
+func (s *SMSNotifier) SendNotification(p *participant.Participant) error {
+	return fmt.Errorf("sms needs a short-form message; send one with SendMessage")
+}
+
+func (s *SMSNotifier) SendMessage(p *participant.Participant, subject, body string) error {
+	if s.Provider == nil {
+		return fmt.Errorf("sms is not configured")
+	}
+
+	for _, contact := range p.ContactInfo {
+		to, err := participant.NormalizePhone(contact)
+		if err != nil {
+			return err
+		}
+		if err := s.Provider.SendSMS(to, body); err != nil {
+			return fmt.Errorf("failed to send sms to %s: %w", to, err)
+		}
+	}
+	return nil
+}
+
+// Preview returns the message's addressing. The body is left for the
+// caller's short-form template, as with SendNotification.
+func (s *SMSNotifier) Preview(p *participant.Participant) (*Message, error) {
+	message := &Message{
+		Channel:     "sms",
+		ContentType: "text/plain",
+	}
+	if twilio, ok := s.Provider.(*TwilioProvider); ok {
+		message.From = twilio.FromNumber
+	}
+	for _, contact := range p.ContactInfo {
+		to, err := participant.NormalizePhone(contact)
+		if err != nil {
+			return nil, err
+		}
+		message.Recipients = append(message.Recipients, to)
+	}
+	return message, nil
+}
+
+func (s *SMSNotifier) IsConfigured() error {
+	if s.Provider == nil {
+		return fmt.Errorf("sms is not configured")
+	}
+	return s.Provider.IsConfigured()
+}
+
+// TwilioProvider sends messages through the Twilio Messages API, or any
+// service exposing a compatible endpoint at BaseURL
+type TwilioProvider struct {
+	BaseURL    string
+	AccountSID string
+	AuthToken  string
+	FromNumber string
+	HTTPClient *http.Client
+}
+
+func (t *TwilioProvider) SendSMS(to, body string) error {
+	baseURL := t.BaseURL
+	if baseURL == "" {
+		baseURL = defaultTwilioBaseURL
+	}
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", strings.TrimSuffix(baseURL, "/"), url.PathEscape(t.AccountSID))
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", t.FromNumber)
+	form.Set("Body", body)
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.AccountSID, t.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if t.HTTPClient == nil {
+		t.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := t.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms provider returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+func (t *TwilioProvider) IsConfigured() error {
+	if t.AccountSID == "" && t.AuthToken == "" && t.FromNumber == "" {
+		return fmt.Errorf("sms is not configured")
+	}
+	if t.AccountSID == "" || t.AuthToken == "" || t.FromNumber == "" {
+		return fmt.Errorf("sms requires account_sid, auth_token and from_number")
+	}
+	return nil
+}
+
+// SMSMessage is a message captured by FakeSMSProvider
+type SMSMessage struct {
+	To   string
+	Body string
+}
+
+// FakeSMSProvider records messages instead of sending them. Set Err to
+// simulate a provider failure.
+type FakeSMSProvider struct {
+	Err      error
+	mu       sync.Mutex
+	Messages []SMSMessage
+}
+
+func (f *FakeSMSProvider) SendSMS(to, body string) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Messages = append(f.Messages, SMSMessage{To: to, Body: body})
+	return nil
+}
+
+func (f *FakeSMSProvider) IsConfigured() error {
+	return nil
+}
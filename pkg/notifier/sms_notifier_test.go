@@ -0,0 +1,122 @@
+package notifier_test
+
+import (
+	"errors"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+)
+
+var _ = Describe("SMS Notifier", func() {
+	var (
+		fakeProvider    *notifier.FakeSMSProvider
+		smsNotifier     *notifier.SMSNotifier
+		testParticipant *participant.Participant
+	)
+
+	BeforeEach(func() {
+		fakeProvider = &notifier.FakeSMSProvider{}
+		smsNotifier = &notifier.SMSNotifier{Provider: fakeProvider}
+		testParticipant = &participant.Participant{
+			Name:             "Grandma",
+			NotificationType: "sms",
+			ContactInfo:      []string{"+1 (555) 555-0123"},
+			Recipient: &participant.Participant{
+				Name: "TestRecipient",
+			},
+		}
+	})
+
+	Context("SendNotification", func() {
+		It("should ask for a short-form message instead", func() {
+			Expect(smsNotifier.SendNotification(testParticipant)).To(MatchError(ContainSubstring("SendMessage")))
+			Expect(fakeProvider.Messages).To(BeEmpty())
+		})
+	})
+
+	Context("SendMessage", func() {
+		It("should send the body to the normalized number", func() {
+			Expect(smsNotifier.SendMessage(testParticipant, "", "Hi Grandma!")).To(Succeed())
+			Expect(fakeProvider.Messages).To(HaveLen(1))
+			Expect(fakeProvider.Messages[0].To).To(Equal("+15555550123"))
+			Expect(fakeProvider.Messages[0].Body).To(Equal("Hi Grandma!"))
+		})
+
+		It("should reject numbers that are not E.164", func() {
+			testParticipant.ContactInfo = []string{"555-0123"}
+			Expect(smsNotifier.SendMessage(testParticipant, "", "Hi Grandma!")).To(MatchError(ContainSubstring("E.164")))
+			Expect(fakeProvider.Messages).To(BeEmpty())
+		})
+
+		It("should surface provider failures", func() {
+			fakeProvider.Err = errors.New("carrier rejected")
+			Expect(smsNotifier.SendMessage(testParticipant, "", "Hi Grandma!")).To(MatchError(ContainSubstring("carrier rejected")))
+		})
+	})
+
+	Context("IsConfigured", func() {
+		It("should error without a provider", func() {
+			Expect((&notifier.SMSNotifier{}).IsConfigured()).To(MatchError("sms is not configured"))
+		})
+
+		It("should defer to the provider", func() {
+			Expect(smsNotifier.IsConfigured()).To(Succeed())
+		})
+	})
+})
+
+var _ = Describe("Twilio Provider", func() {
+	var (
+		server   *httptest.Server
+		form     url.Values
+		path     string
+		user     string
+		status   int
+		provider *notifier.TwilioProvider
+	)
+
+	BeforeEach(func() {
+		status = http.StatusCreated
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.ParseForm()).To(Succeed())
+			form = r.PostForm
+			path = r.URL.Path
+			user, _, _ = r.BasicAuth()
+			w.WriteHeader(status)
+		}))
+		provider = &notifier.TwilioProvider{
+			BaseURL:    server.URL,
+			AccountSID: "AC123",
+			AuthToken:  "token",
+			FromNumber: "+15555550100",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should post a Twilio-compatible message", func() {
+		Expect(provider.SendSMS("+15555550123", "hello")).To(Succeed())
+		Expect(path).To(Equal("/2010-04-01/Accounts/AC123/Messages.json"))
+		Expect(user).To(Equal("AC123"))
+		Expect(form.Get("To")).To(Equal("+15555550123"))
+		Expect(form.Get("From")).To(Equal("+15555550100"))
+		Expect(form.Get("Body")).To(Equal("hello"))
+	})
+
+	It("should error on a non-2xx response", func() {
+		status = http.StatusBadRequest
+		Expect(provider.SendSMS("+15555550123", "hello")).To(MatchError(ContainSubstring("status 400")))
+	})
+
+	It("should require credentials and a from number", func() {
+		Expect((&notifier.TwilioProvider{}).IsConfigured()).To(MatchError("sms is not configured"))
+		provider.FromNumber = ""
+		Expect(provider.IsConfigured()).To(HaveOccurred())
+	})
+})
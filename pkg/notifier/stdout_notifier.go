@@ -2,6 +2,7 @@ package notifier
 
 import (
 	"fmt"
+	"github.com/igodwin/secretsanta/pkg/config"
 	"github.com/igodwin/secretsanta/pkg/participant"
 )
 
@@ -11,11 +12,37 @@ const stdoutAssignmentTemplate = `%s has %s
 type Stdout struct {
 }
 
+func init() {
+	Register(Channel{
+		Name:         "stdout",
+		Description:  "Print assignments to the server console",
+		ServiceType:  "NOTIFICATION_TYPE_STDOUT",
+		Capabilities: Capabilities{LongForm: true},
+		New: func(cfg *config.Config) (Notifier, error) {
+			return &Stdout{}, nil
+		},
+	})
+}
+
 func (s *Stdout) SendNotification(participant *participant.Participant) error {
 	fmt.Printf(stdoutAssignmentTemplate, participant.Name, participant.Recipient.Name)
 	return nil
 }
 
+func (s *Stdout) SendMessage(participant *participant.Participant, subject, body string) error {
+	fmt.Printf("%s: %s\n", participant.Name, body)
+	return nil
+}
+
+func (s *Stdout) Preview(participant *participant.Participant) (*Message, error) {
+	return &Message{
+		Channel:     "stdout",
+		Recipients:  []string{"stdout"},
+		Body:        fmt.Sprintf(stdoutAssignmentTemplate, participant.Name, participant.Recipient.Name),
+		ContentType: "text/plain",
+	}, nil
+}
+
 func (s *Stdout) IsConfigured() error {
 	return nil
 }
@@ -0,0 +1,91 @@
+package notifier
+
+import (
+	"fmt"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	"net/http"
+	"strings"
+)
+
+const defaultTelegramBaseURL = "https://api.telegram.org"
+
+// TelegramNotifier sends assignments with the Bot API sendMessage method.
+// Contact info holds the chat ID of each participant's conversation with the bot.
+type TelegramNotifier struct {
+	BotToken   string
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func init() {
+	Register(Channel{
+		Name:        "telegram",
+		Description: "Telegram bot message by chat ID",
+		ConfigSchema: []ConfigField{
+			{Key: "telegram.bot_token", Required: true, Secret: true, Description: "Bot API token"},
+			{Key: "telegram.base_url", Description: "Override for the Bot API"},
+		},
+		Capabilities: Capabilities{LongForm: true},
+		New: func(cfg *config.Config) (Notifier, error) {
+			return &TelegramNotifier{
+				BotToken: cfg.Telegram.BotToken,
+				BaseURL:  cfg.Telegram.BaseURL,
+			}, nil
+		},
+	})
+}
+
+func (t *TelegramNotifier) SendNotification(participant *participant.Participant) error {
+	return t.SendMessage(participant, "", ChatBody(participant.Name, participant.Recipient.Name))
+}
+
+func (t *TelegramNotifier) SendMessage(participant *participant.Participant, subject, text string) error {
+	chatIDs := nonEmpty(participant.ContactInfo)
+	if len(chatIDs) == 0 {
+		return fmt.Errorf("no telegram chat id for %s", participant.Name)
+	}
+
+	baseURL := t.BaseURL
+	if baseURL == "" {
+		baseURL = defaultTelegramBaseURL
+	}
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(baseURL, "/"), t.BotToken)
+	t.HTTPClient = defaultHTTPClient(t.HTTPClient)
+
+	for _, chatID := range chatIDs {
+		var resp struct {
+			OK          bool   `json:"ok"`
+			Description string `json:"description"`
+		}
+		err := doJSON(t.HTTPClient, http.MethodPost, endpoint, nil,
+			map[string]string{"chat_id": chatID, "text": text}, &resp)
+		if err != nil {
+			return fmt.Errorf("failed to send telegram message to %s: %w", participant.Name, err)
+		}
+		if !resp.OK {
+			return fmt.Errorf("failed to send telegram message to %s: %s", participant.Name, resp.Description)
+		}
+	}
+	return nil
+}
+
+func (t *TelegramNotifier) Preview(participant *participant.Participant) (*Message, error) {
+	chatIDs := nonEmpty(participant.ContactInfo)
+	if len(chatIDs) == 0 {
+		return nil, fmt.Errorf("no telegram chat id for %s", participant.Name)
+	}
+	return &Message{
+		Channel:     "telegram",
+		Recipients:  chatIDs,
+		Body:        ChatBody(participant.Name, participant.Recipient.Name),
+		ContentType: "text/plain",
+	}, nil
+}
+
+func (t *TelegramNotifier) IsConfigured() error {
+	if t.BotToken == "" {
+		return fmt.Errorf("telegram is not configured")
+	}
+	return nil
+}
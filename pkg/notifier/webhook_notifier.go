@@ -0,0 +1,238 @@
+package notifier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/igodwin/secretsanta/pkg/config"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	webhookEventType       = "secret_santa.assignment"
+	webhookReminderEvent   = "secret_santa.reminder"
+	webhookSignatureHeader = "X-SecretSanta-Signature"
+	webhookEventHeader     = "X-SecretSanta-Event"
+	defaultWebhookTimeout  = 10 * time.Second
+)
+
+// WebhookPayload is the JSON document POSTed to the webhook URL.
+// It is also the data passed to BodyTemplate when one is configured.
+type WebhookPayload struct {
+	Event       string   `json:"event"`
+	Giver       string   `json:"giver"`
+	Recipient   string   `json:"recipient"`
+	ContactInfo []string `json:"contact_info,omitempty"`
+	// Subject and Message are set for free-form messages such as reminders
+	Subject   string            `json:"subject,omitempty"`
+	Message   string            `json:"message,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+type WebhookNotifier struct {
+	URL          string
+	Secret       string
+	BodyTemplate string
+	ContentType  string
+	MaxRetries   int
+	RetryDelay   time.Duration
+	Timeout      time.Duration
+	HTTPClient   *http.Client
+}
+
+func init() {
+	Register(Channel{
+		Name:        "webhook",
+		Description: "Signed JSON POST to an HTTP endpoint",
+		ConfigSchema: []ConfigField{
+			{Key: "webhook.url", Description: "Default webhook URL"},
+			{Key: "webhook.secret", Required: true, Secret: true, Description: "HMAC-SHA256 signing secret"},
+			{Key: "webhook.body_template", Description: "Go template for the request body"},
+			{Key: "webhook.content_type", Description: "Request content type"},
+			{Key: "webhook.max_retries", Description: "Retries after a failed delivery"},
+			{Key: "webhook.retry_delay", Description: "Delay between retries"},
+			{Key: "webhook.timeout", Description: "Per-request timeout"},
+		},
+		Capabilities: Capabilities{LongForm: true},
+		// Any contact is passed through in the payload, but URLs must be usable
+		ValidateContact: func(contact string) error {
+			if participant.DetectContactKind(contact) != participant.ContactURL {
+				return nil
+			}
+			_, err := participant.ParseContactAs(contact, participant.ContactURL)
+			return err
+		},
+		New: func(cfg *config.Config) (Notifier, error) {
+			return &WebhookNotifier{
+				URL:          cfg.Webhook.URL,
+				Secret:       cfg.Webhook.Secret,
+				BodyTemplate: cfg.Webhook.BodyTemplate,
+				ContentType:  cfg.Webhook.ContentType,
+				MaxRetries:   cfg.Webhook.MaxRetries,
+				RetryDelay:   cfg.Webhook.RetryDelay,
+				Timeout:      cfg.Webhook.Timeout,
+			}, nil
+		},
+	})
+}
+
+func (w *WebhookNotifier) SendNotification(participant *participant.Participant) error {
+	return w.deliver(participant, newWebhookPayload(participant))
+}
+
+// SendMessage posts a secret_santa.reminder event carrying subject and body
+func (w *WebhookNotifier) SendMessage(participant *participant.Participant, subject, body string) error {
+	payload := newWebhookPayload(participant)
+	payload.Event = webhookReminderEvent
+	payload.Subject = subject
+	payload.Message = body
+	return w.deliver(participant, payload)
+}
+
+func (w *WebhookNotifier) deliver(participant *participant.Participant, payload WebhookPayload) error {
+	url := w.resolveURL(participant)
+	if url == "" {
+		return fmt.Errorf("no webhook url configured for %s", participant.Name)
+	}
+
+	body, err := w.renderBody(payload)
+	if err != nil {
+		return err
+	}
+
+	if w.HTTPClient == nil {
+		timeout := w.Timeout
+		if timeout == 0 {
+			timeout = defaultWebhookTimeout
+		}
+		w.HTTPClient = &http.Client{Timeout: timeout}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= w.MaxRetries; attempt++ {
+		if attempt > 0 && w.RetryDelay > 0 {
+			time.Sleep(w.RetryDelay)
+		}
+		if lastErr = w.post(url, payload.Event, body); lastErr == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("webhook delivery failed after %d attempt(s): %w", w.MaxRetries+1, lastErr)
+}
+
+func (w *WebhookNotifier) Preview(participant *participant.Participant) (*Message, error) {
+	url := w.resolveURL(participant)
+	if url == "" {
+		return nil, fmt.Errorf("no webhook url configured for %s", participant.Name)
+	}
+	body, err := w.renderBody(newWebhookPayload(participant))
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := w.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	return &Message{
+		Channel:     "webhook",
+		Recipients:  []string{url},
+		Body:        string(body),
+		ContentType: contentType,
+	}, nil
+}
+
+func (w *WebhookNotifier) IsConfigured() error {
+	if w.URL == "" && w.Secret == "" {
+		return fmt.Errorf("webhook is not configured")
+	}
+	if w.Secret == "" {
+		return fmt.Errorf("webhook secret is not configured")
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the hex encoded HMAC-SHA256 of body using secret, in the
+// same "sha256=<hex>" form sent in the X-SecretSanta-Signature header
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookPayload(participant *participant.Participant) WebhookPayload {
+	return WebhookPayload{
+		Event:       webhookEventType,
+		Giver:       participant.Name,
+		Recipient:   participant.Recipient.Name,
+		ContactInfo: participant.ContactInfo,
+		Timestamp:   time.Now().UTC(),
+		Metadata: map[string]string{
+			"participant_name": participant.Name,
+			"recipient_name":   participant.Recipient.Name,
+			"event_type":       "secret_santa",
+		},
+	}
+}
+
+// resolveURL prefers a URL from the participant's contact info over the global URL
+func (w *WebhookNotifier) resolveURL(participant *participant.Participant) string {
+	for _, contact := range participant.ContactInfo {
+		if strings.HasPrefix(contact, "https://") || strings.HasPrefix(contact, "http://") {
+			return contact
+		}
+	}
+	return w.URL
+}
+
+func (w *WebhookNotifier) renderBody(payload WebhookPayload) ([]byte, error) {
+	if w.BodyTemplate == "" {
+		return json.Marshal(payload)
+	}
+
+	tmpl, err := template.New("webhook").Parse(w.BodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook body template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, payload); err != nil {
+		return nil, fmt.Errorf("failed to render webhook body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (w *WebhookNotifier) post(url, event string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	contentType := w.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(webhookEventHeader, event)
+	req.Header.Set(webhookSignatureHeader, SignWebhookPayload(w.Secret, body))
+
+	resp, err := w.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"github.com/igodwin/secretsanta/pkg/notifier"
+	"github.com/igodwin/secretsanta/pkg/participant"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+)
+
+var _ = Describe("Webhook Notifier", func() {
+	var (
+		webhookNotifier *notifier.WebhookNotifier
+		server          *httptest.Server
+		received        []byte
+		signature       string
+		calls           int32
+		failuresLeft    int32
+		testParticipant *participant.Participant
+	)
+
+	BeforeEach(func() {
+		received, signature = nil, ""
+		calls, failuresLeft = 0, 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			if atomic.AddInt32(&failuresLeft, -1) >= 0 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			received, _ = io.ReadAll(r.Body)
+			signature = r.Header.Get("X-SecretSanta-Signature")
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		webhookNotifier = &notifier.WebhookNotifier{
+			URL:    server.URL,
+			Secret: "s3cret",
+		}
+		testParticipant = &participant.Participant{
+			Name:             "Test",
+			NotificationType: "webhook",
+			ContactInfo:      []string{""},
+			Recipient: &participant.Participant{
+				Name: "TestRecipient",
+			},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("SendNotification", func() {
+		It("should post a signed JSON payload", func() {
+			Expect(webhookNotifier.SendNotification(testParticipant)).To(Succeed())
+
+			var payload notifier.WebhookPayload
+			Expect(json.Unmarshal(received, &payload)).To(Succeed())
+			Expect(payload.Event).To(Equal("secret_santa.assignment"))
+			Expect(payload.Giver).To(Equal("Test"))
+			Expect(payload.Recipient).To(Equal("TestRecipient"))
+			Expect(signature).To(Equal(notifier.SignWebhookPayload("s3cret", received)))
+		})
+
+		It("should prefer a URL from the participant's contact info", func() {
+			webhookNotifier.URL = "http://127.0.0.1:1/unused"
+			testParticipant.ContactInfo = []string{server.URL + "/hooks/test"}
+			Expect(webhookNotifier.SendNotification(testParticipant)).To(Succeed())
+			Expect(calls).To(BeEquivalentTo(1))
+		})
+
+		It("should render the body template when configured", func() {
+			webhookNotifier.BodyTemplate = `{"text":"{{.Giver}} has {{.Recipient}}"}`
+			Expect(webhookNotifier.SendNotification(testParticipant)).To(Succeed())
+			Expect(string(received)).To(Equal(`{"text":"Test has TestRecipient"}`))
+		})
+
+		It("should retry failed deliveries", func() {
+			failuresLeft = 2
+			webhookNotifier.MaxRetries = 2
+			Expect(webhookNotifier.SendNotification(testParticipant)).To(Succeed())
+			Expect(calls).To(BeEquivalentTo(3))
+		})
+
+		It("should error once retries are exhausted", func() {
+			failuresLeft = 5
+			webhookNotifier.MaxRetries = 1
+			Expect(webhookNotifier.SendNotification(testParticipant)).To(MatchError(ContainSubstring("status 502")))
+			Expect(calls).To(BeEquivalentTo(2))
+		})
+	})
+
+	Context("SendMessage", func() {
+		It("should post a reminder event with the message", func() {
+			Expect(webhookNotifier.SendMessage(testParticipant, "One week left", "Don't forget TestRecipient")).To(Succeed())
+
+			var payload notifier.WebhookPayload
+			Expect(json.Unmarshal(received, &payload)).To(Succeed())
+			Expect(payload.Event).To(Equal("secret_santa.reminder"))
+			Expect(payload.Subject).To(Equal("One week left"))
+			Expect(payload.Message).To(Equal("Don't forget TestRecipient"))
+		})
+	})
+
+	Context("IsConfigured", func() {
+		It("should not error when a secret is configured", func() {
+			Expect(webhookNotifier.IsConfigured()).NotTo(HaveOccurred())
+		})
+
+		It("should error when nothing is configured", func() {
+			Expect((&notifier.WebhookNotifier{}).IsConfigured()).To(MatchError("webhook is not configured"))
+		})
+
+		It("should error when the secret is missing", func() {
+			webhookNotifier.Secret = ""
+			Expect(webhookNotifier.IsConfigured()).To(MatchError("webhook secret is not configured"))
+		})
+	})
+})
@@ -0,0 +1,128 @@
+package participant
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ContactKind identifies the format of a contact info entry
+type ContactKind string
+
+const (
+	ContactUnknown ContactKind = ""
+	ContactEmail   ContactKind = "email"
+	ContactPhone   ContactKind = "phone"
+	ContactSlack   ContactKind = "slack"
+	ContactURL     ContactKind = "url"
+)
+
+var (
+	e164Pattern        = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+	phoneLikePattern   = regexp.MustCompile(`^[0-9 ().+-]{7,}$`)
+	slackHandlePattern = regexp.MustCompile(`^@[a-z0-9][a-z0-9._-]{0,79}$`)
+	slackIDPattern     = regexp.MustCompile(`^[UW][A-Z0-9]{8,}$`)
+)
+
+// Description returns a human readable name for the kind, for error messages
+func (k ContactKind) Description() string {
+	switch k {
+	case ContactEmail:
+		return "an email address"
+	case ContactPhone:
+		return "a phone number"
+	case ContactSlack:
+		return "a Slack handle"
+	case ContactURL:
+		return "a URL"
+	default:
+		return "an unrecognized contact"
+	}
+}
+
+// Contact is a parsed contact info entry
+type Contact struct {
+	Kind ContactKind
+	// Value is the normalized form: a bare email address, an E.164 number,
+	// a Slack handle or member ID, or an absolute URL
+	Value string
+	Raw   string
+}
+
+// DetectContactKind guesses the kind of a contact info entry from its shape
+// without validating it
+func DetectContactKind(raw string) ContactKind {
+	s := strings.TrimSpace(raw)
+	switch {
+	case strings.Contains(s, "://"):
+		return ContactURL
+	case strings.HasPrefix(s, "@") && strings.Contains(s, ":"):
+		// Matrix IDs (@user:server) have no dedicated kind
+		return ContactUnknown
+	case strings.HasPrefix(s, "@"), slackIDPattern.MatchString(s):
+		return ContactSlack
+	case strings.HasPrefix(s, "+"), phoneLikePattern.MatchString(s):
+		return ContactPhone
+	case strings.Contains(s, "@"):
+		return ContactEmail
+	default:
+		return ContactUnknown
+	}
+}
+
+// ParseContact detects the kind of a contact info entry and validates it
+func ParseContact(raw string) (Contact, error) {
+	kind := DetectContactKind(raw)
+	if kind == ContactUnknown {
+		return Contact{Raw: raw}, fmt.Errorf("unrecognized contact %q", raw)
+	}
+	return ParseContactAs(raw, kind)
+}
+
+// ParseContactAs validates a contact info entry as the given kind
+func ParseContactAs(raw string, kind ContactKind) (Contact, error) {
+	s := strings.TrimSpace(raw)
+	contact := Contact{Kind: kind, Raw: raw}
+
+	switch kind {
+	case ContactEmail:
+		// RFC 5322 address, optionally with a display name
+		addr, err := mail.ParseAddress(s)
+		if err != nil {
+			return contact, fmt.Errorf("invalid email address %q: %v", raw, err)
+		}
+		contact.Value = addr.Address
+	case ContactPhone:
+		phone, err := NormalizePhone(s)
+		if err != nil {
+			return contact, err
+		}
+		contact.Value = phone
+	case ContactSlack:
+		if !slackHandlePattern.MatchString(s) && !slackIDPattern.MatchString(s) {
+			return contact, fmt.Errorf("invalid Slack handle %q: expected @handle (lowercase) or a member ID", raw)
+		}
+		contact.Value = s
+	case ContactURL:
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return contact, fmt.Errorf("invalid URL %q: expected an absolute http(s) URL", raw)
+		}
+		contact.Value = u.String()
+	default:
+		return contact, fmt.Errorf("unrecognized contact %q", raw)
+	}
+	return contact, nil
+}
+
+// NormalizePhone strips common formatting characters and returns the number
+// in E.164 form, or an error if it is not a valid E.164 number
+func NormalizePhone(phone string) (string, error) {
+	normalized := strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+	if !e164Pattern.MatchString(normalized) {
+		return "", fmt.Errorf("invalid phone number %q: must be in E.164 format (e.g. +15555550123)", phone)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,64 @@
+package participant_test
+
+import (
+	. "github.com/igodwin/secretsanta/pkg/participant"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Contact", func() {
+	DescribeTable("DetectContactKind",
+		func(raw string, expected ContactKind) {
+			Expect(DetectContactKind(raw)).To(Equal(expected))
+		},
+		Entry("email", "alice@example.com", ContactEmail),
+		Entry("email with display name", "Alice <alice@example.com>", ContactEmail),
+		Entry("E.164 phone", "+15555550123", ContactPhone),
+		Entry("formatted phone", "(555) 555-0123", ContactPhone),
+		Entry("slack handle", "@carol", ContactSlack),
+		Entry("slack member id", "U024BE7LH", ContactSlack),
+		Entry("url", "https://hooks.example.com/x", ContactURL),
+		Entry("matrix id", "@carol:matrix.org", ContactUnknown),
+		Entry("unknown", "carol", ContactUnknown),
+	)
+
+	Describe("ParseContact", func() {
+		It("should normalize email addresses per RFC 5322", func() {
+			contact, err := ParseContact("Alice Johnson <alice@example.com>")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contact.Kind).To(Equal(ContactEmail))
+			Expect(contact.Value).To(Equal("alice@example.com"))
+		})
+
+		It("should reject malformed email addresses", func() {
+			_, err := ParseContact("alice@@example.com")
+			Expect(err).To(MatchError(ContainSubstring("invalid email address")))
+		})
+
+		It("should normalize phone numbers to E.164", func() {
+			contact, err := ParseContact("+1 (555) 555-0123")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contact.Value).To(Equal("+15555550123"))
+		})
+
+		It("should reject phone numbers without a country code", func() {
+			_, err := ParseContact("555-555-0123")
+			Expect(err).To(MatchError(ContainSubstring("E.164")))
+		})
+
+		It("should reject Slack handles with invalid characters", func() {
+			_, err := ParseContactAs("@Carol!", ContactSlack)
+			Expect(err).To(MatchError(ContainSubstring("invalid Slack handle")))
+		})
+
+		It("should require absolute http(s) URLs", func() {
+			_, err := ParseContact("ftp://example.com/x")
+			Expect(err).To(MatchError(ContainSubstring("invalid URL")))
+		})
+
+		It("should reject unrecognized contacts", func() {
+			_, err := ParseContact("carol")
+			Expect(err).To(MatchError(`unrecognized contact "carol"`))
+		})
+	})
+})
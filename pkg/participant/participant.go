@@ -8,6 +8,12 @@ type Participant struct {
 	ContactInfo      []string `json:"contact_info"`
 	Exclusions       []string `json:"exclusions"`
 	Recipient        *Participant
+	// NoReminders opts the participant out of event reminders
+	NoReminders bool `json:"no_reminders,omitempty" yaml:"no_reminders,omitempty" toml:"no_reminders,omitempty"`
+	// DeliveredVia records the channel that delivered the assignment
+	DeliveredVia string `json:"delivered_via,omitempty" yaml:"delivered_via,omitempty" toml:"delivered_via,omitempty"`
+	// NotificationID is the notifier service's ID for the assignment notification
+	NotificationID string `json:"notification_id,omitempty" yaml:"notification_id,omitempty" toml:"notification_id,omitempty"`
 }
 
 func (p *Participant) UpdateRecipient(participant *Participant) error {
@@ -23,3 +29,17 @@ func (p *Participant) UpdateRecipient(participant *Participant) error {
 
 	return nil
 }
+
+// PersonalData returns the names and contact info of participants and their
+// recipients, for redacting them from logged errors
+func PersonalData(participants ...*Participant) []string {
+	var values []string
+	for _, p := range participants {
+		values = append(values, p.Name)
+		values = append(values, p.ContactInfo...)
+		if p.Recipient != nil {
+			values = append(values, p.Recipient.Name)
+		}
+	}
+	return values
+}
@@ -44,4 +44,11 @@ var _ = Describe("Participant", func() {
 			Expect(ind1.UpdateRecipient(ind1)).To(MatchError("cannot update match with self"))
 		})
 	})
+
+	Describe("PersonalData", func() {
+		It("should list names, contact info and the recipient's name", func() {
+			Expect(ind1.UpdateRecipient(ind2)).To(Succeed())
+			Expect(PersonalData(ind1)).To(Equal([]string{"Jane Doe", "janedoe@example.com", "Jill Doe"}))
+		})
+	})
 })
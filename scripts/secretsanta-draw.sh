@@ -9,6 +9,9 @@
 #   ./secretsanta-draw.sh participants.json
 #   ./secretsanta-draw.sh participants.json http://localhost:8080
 #
+# When the server requires a login, set SECRETSANTA_USERNAME and
+# SECRETSANTA_PASSWORD to an organizer account.
+#
 
 set -e
 
@@ -77,6 +80,26 @@ if ! command -v curl &> /dev/null; then
     exit 1
 fi
 
+# Log in when credentials are given; the session cookie and CSRF token are
+# sent with the requests below
+AUTH_ARGS=()
+if [ -n "${SECRETSANTA_USERNAME:-}" ]; then
+    if [ "$JQ_AVAILABLE" != true ]; then
+        print_error "jq is required to log in"
+        exit 1
+    fi
+    COOKIE_JAR=$(mktemp)
+    trap 'rm -f "$COOKIE_JAR"' EXIT
+    LOGIN_BODY=$(jq -n --arg u "$SECRETSANTA_USERNAME" --arg p "${SECRETSANTA_PASSWORD:-}" '{username: $u, password: $p}')
+    if ! LOGIN_RESPONSE=$(curl -s -f -c "$COOKIE_JAR" -X POST "${SERVER_URL}/api/auth/login" \
+        -H "Content-Type: application/json" \
+        -d "$LOGIN_BODY"); then
+        print_error "Login as $SECRETSANTA_USERNAME failed"
+        exit 1
+    fi
+    AUTH_ARGS=(-b "$COOKIE_JAR" -H "X-CSRF-Token: $(echo "$LOGIN_RESPONSE" | jq -r '.csrf_token')")
+fi
+
 echo "=================================================="
 echo "Secret Santa Draw Tool"
 echo "=================================================="
@@ -87,7 +110,7 @@ echo ""
 
 # Step 1: Validate participants
 print_info "Step 1: Validating participants..."
-VALIDATE_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "$API_VALIDATE" \
+VALIDATE_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "$API_VALIDATE" "${AUTH_ARGS[@]}" \
     -H "Content-Type: application/json" \
     -d @"$PARTICIPANTS_FILE")
 
@@ -150,7 +173,7 @@ echo ""
 
 # Step 2: Run the draw
 print_info "Step 2: Running Secret Santa draw..."
-DRAW_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "$API_DRAW" \
+DRAW_RESPONSE=$(curl -s -w "\n%{http_code}" -X POST "$API_DRAW" "${AUTH_ARGS[@]}" \
     -H "Content-Type: application/json" \
     -d @"$PARTICIPANTS_FILE")
 